@@ -3,8 +3,10 @@ package collector
 import (
 	"maps"
 	"sync"
+	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
 )
 
 type metricCache struct {
@@ -37,6 +39,24 @@ func (c *metricCache) replace(other *metricCache) {
 	c.cache = other.cache
 }
 
+// invalidate drops the metrics labelled with the pool
+func (c *metricCache) invalidate(pool string) {
+	c.Lock()
+	defer c.Unlock()
+	for name, m := range c.cache {
+		out := &dto.Metric{}
+		if err := m.Write(out); err != nil {
+			continue
+		}
+		for _, label := range out.GetLabel() {
+			if label.GetName() == `pool` && label.GetValue() == pool {
+				delete(c.cache, name)
+				break
+			}
+		}
+	}
+}
+
 func (c *metricCache) index() map[string]struct{} {
 	c.RLock()
 	defer c.RUnlock()
@@ -51,3 +71,46 @@ func (c *metricCache) index() map[string]struct{} {
 func newMetricCache() *metricCache {
 	return &metricCache{cache: make(map[string]prometheus.Metric)}
 }
+
+// propertyCache holds the values of static properties between refreshes, per pool
+type propertyCache struct {
+	refreshed map[string]time.Time
+	values    map[string]map[string]map[string]string
+	sync.Mutex
+}
+
+// due reports whether the static properties of the pool should be refreshed
+func (c *propertyCache) due(pool string, interval time.Duration) bool {
+	c.Lock()
+	defer c.Unlock()
+	refreshed, ok := c.refreshed[pool]
+	return !ok || time.Since(refreshed) >= interval
+}
+
+// store replaces the cached static properties of the pool, indexed by object (pool, dataset, etc.) name
+func (c *propertyCache) store(pool string, values map[string]map[string]string) {
+	c.Lock()
+	defer c.Unlock()
+	c.refreshed[pool] = time.Now()
+	c.values[pool] = values
+}
+
+func (c *propertyCache) load(pool string) map[string]map[string]string {
+	c.Lock()
+	defer c.Unlock()
+	return c.values[pool]
+}
+
+// expire forces a refresh of the static properties of the pool on the next run
+func (c *propertyCache) expire(pool string) {
+	c.Lock()
+	defer c.Unlock()
+	delete(c.refreshed, pool)
+}
+
+func newPropertyCache() *propertyCache {
+	return &propertyCache{
+		refreshed: make(map[string]time.Time),
+		values:    make(map[string]map[string]map[string]string),
+	}
+}
@@ -4,7 +4,11 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"maps"
+	"path"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/alecthomas/kingpin/v2"
 	"github.com/prometheus/client_golang/prometheus"
@@ -18,8 +22,21 @@ const (
 	helpDefaultStateEnabled  = `enabled`
 	helpDefaultStateDisabled = `disabled`
 
-	subsystemDataset = `dataset`
-	subsystemPool    = `pool`
+	subsystemDataset   = `dataset`
+	subsystemDbuf      = `dbuf`
+	subsystemDMUTx     = `dmu_tx`
+	subsystemDnode     = `dnode`
+	subsystemEvents    = `events`
+	subsystemMultihost = `multihost`
+	subsystemPool      = `pool`
+	subsystemSPLKmem   = `spl_kmem`
+	subsystemSPLTaskq  = `spl_taskq`
+	subsystemTunable   = `tunable`
+	subsystemTXG       = `txg`
+	subsystemVdev      = `vdev`
+	subsystemZED       = `zed`
+	subsystemZfetch    = `zfetch`
+	subsystemZIL       = `zil`
 
 	propertyUnsupportedDesc = `!!! This property is unsupported, results are likely to be undesirable, please file an issue at https://github.com/waitingsong/zfs_exporter/issues to have this property supported !!!`
 	propertyUnsupportedMsg  = `Unsupported dataset property, results are likely to be undesirable`
@@ -42,8 +59,32 @@ var (
 		[]string{`collector`},
 		nil,
 	)
+	scrapeLastSuccessDescName = prometheus.BuildFQName(namespace, `scrape`, `collector_last_success_timestamp_seconds`)
+	scrapeLastSuccessDesc     = prometheus.NewDesc(
+		scrapeLastSuccessDescName,
+		`zfs_exporter: Unix timestamp of the last successful run of a scheduled collector, whose results are served from the cache.`,
+		[]string{`collector`},
+		nil,
+	)
+
+	versionInfoDescName = prometheus.BuildFQName(namespace, ``, `version_info`)
+	versionInfoDesc     = prometheus.NewDesc(
+		versionInfoDescName,
+		`OpenZFS kernel module and userland versions, and whether they differ [mismatch: true, false]. Versions which could not be determined are empty.`,
+		[]string{`kmod`, `userland`, `mismatch`},
+		nil,
+	)
+	binaryAvailableDescName = prometheus.BuildFQName(namespace, `binary`, `available`)
+	binaryAvailableDesc     = prometheus.NewDesc(
+		binaryAvailableDescName,
+		`Whether a command required by the exporter was found on PATH at startup [0: missing, 1: available].`,
+		[]string{`binary`},
+		nil,
+	)
 
 	errUnsupportedProperty = errors.New(`unsupported property`)
+
+	staticPropertiesInterval = kingpin.Flag(`properties.static-interval`, `Interval between refreshes of static properties, which are otherwise served from the cache. The tier of a property may be overridden by suffixing it with ':volatile' or ':static' (e.g. 'used,recordsize:volatile') (default: 1h)`).Default(`1h`).Duration()
 )
 
 type factoryFunc func(l *slog.Logger, c zfs.Client, properties []string) (Collector, error)
@@ -55,7 +96,35 @@ type State struct {
 	Name       string
 	Enabled    *bool
 	Properties *string
+	Interval   *time.Duration
 	factory    factoryFunc
+	store      *propertyStore
+}
+
+// scheduled reports whether the collector runs in the background on its own interval, rather than on every scrape.
+func (s State) scheduled() bool {
+	return s.Interval != nil && *s.Interval > 0
+}
+
+// collectorOption customizes a collector during registration
+type collectorOption func(name string, state *State)
+
+// withInterval schedules the collector to run in the background at most once per interval, with its last result
+// served from the cache. This is intended for collectors that are too expensive to run on every scrape.
+func withInterval(interval time.Duration) collectorOption {
+	return func(name string, state *State) {
+		flagName := fmt.Sprintf("collector.%s.interval", name)
+		flagHelp := fmt.Sprintf("Minimum interval between background runs of the %s collector, 0 to run on every scrape (default: %s)", name, interval)
+		state.Interval = kingpin.Flag(flagName, flagHelp).Default(interval.String()).Duration()
+	}
+}
+
+// withPropertyStore declares the properties supported by the collector, allowing those unknown to the installed
+// OpenZFS version to be disabled before the collector is instantiated.
+func withPropertyStore(store *propertyStore) collectorOption {
+	return func(name string, state *State) {
+		state.store = store
+	}
 }
 
 // Collector defines the minimum functionality for registering a collector
@@ -64,15 +133,56 @@ type Collector interface {
 	describe(ch chan<- *prometheus.Desc)
 }
 
+// expiringCollector is implemented by collectors caching static properties, allowing the pool to be refreshed on the
+// next run
+type expiringCollector interface {
+	expire(pool string)
+}
+
 type metric struct {
 	name       string
 	prometheus prometheus.Metric
 }
 
+// propertyTier determines how often a property is refreshed
+type propertyTier string
+
+const (
+	// tierVolatile properties are refreshed on every run
+	tierVolatile propertyTier = `volatile`
+	// tierStatic properties rarely change, and are only refreshed once per staticPropertiesInterval
+	tierStatic propertyTier = `static`
+)
+
 type property struct {
 	name      string
 	desc      *prometheus.Desc
 	transform transformFunc
+	tier      propertyTier
+	valueType prometheus.ValueType
+	// minVersion is the first OpenZFS release supporting the property, zero if supported by all releases
+	minVersion zfs.Version
+	// info is set for string properties, which are reported as labels of the info metric of their store, rather than
+	// as metrics of their own
+	info bool
+}
+
+// withTier returns a copy of the property assigned to the provided tier
+func (p property) withTier(tier propertyTier) property {
+	p.tier = tier
+	return p
+}
+
+// withValueType returns a copy of the property reported as the provided metric type, rather than a gauge
+func (p property) withValueType(valueType prometheus.ValueType) property {
+	p.valueType = valueType
+	return p
+}
+
+// withMinVersion returns a copy of the property, marked as unknown to OpenZFS releases older than major.minor
+func (p property) withMinVersion(major, minor int) property {
+	p.minVersion = zfs.Version{Major: major, Minor: minor}
+	return p
 }
 
 func (p property) push(ch chan<- metric, value string, labelValues ...string) error {
@@ -80,23 +190,50 @@ func (p property) push(ch chan<- metric, value string, labelValues ...string) er
 	if err != nil {
 		return err
 	}
+	p.pushValue(ch, v, labelValues...)
+
+	return nil
+}
+
+// pushValue reports a value known to the collector, rather than the raw value of a property
+func (p property) pushValue(ch chan<- metric, value float64, labelValues ...string) {
 	ch <- metric{
-		name: expandMetricName(p.name, labelValues...),
-		prometheus: prometheus.MustNewConstMetric(
-			p.desc,
-			prometheus.GaugeValue,
-			v,
-			labelValues...,
-		),
+		name:       expandMetricName(p.name, labelValues...),
+		prometheus: prometheus.MustNewConstMetric(p.desc, p.valueType, value, labelValues...),
 	}
+}
 
-	return nil
+// infoMetric describes a metric whose labels hold the values of the string properties of a store, following the
+// default labels of the store
+type infoMetric struct {
+	name       string
+	desc       *prometheus.Desc
+	properties []string
+}
+
+// push reports the values of the string properties, those not found or unset (`-`) being empty. The metric is cached
+// by the default labels only, so that a changed value replaces the previous one.
+func (m *infoMetric) push(ch chan<- metric, values map[string]string, labelValues ...string) {
+	infoValues := slices.Clone(labelValues)
+	for _, name := range m.properties {
+		v := values[name]
+		if v == `-` {
+			v = ``
+		}
+		infoValues = append(infoValues, v)
+	}
+	ch <- metric{
+		name:       expandMetricName(m.name, labelValues...),
+		prometheus: prometheus.MustNewConstMetric(m.desc, prometheus.GaugeValue, 1, infoValues...),
+	}
 }
 
 type propertyStore struct {
 	defaultSubsystem string
 	defaultLabels    []string
 	store            map[string]property
+	// info reports the string properties of the store, nil if the store has none
+	info *infoMetric
 }
 
 func (p *propertyStore) find(name string) (property, error) {
@@ -114,7 +251,87 @@ func (p *propertyStore) find(name string) (property, error) {
 	return prop, nil
 }
 
-func registerCollector(collector string, isDefaultEnabled bool, defaultProps string, factory factoryFunc) {
+// supported splits the requested properties by whether they are known to the provided OpenZFS version. All
+// properties are supported if the version is unknown.
+func (p *propertyStore) supported(props []string, version zfs.Version) (supported []string, unsupported []string) {
+	for _, v := range props {
+		name, _, _ := strings.Cut(v, `:`)
+		prop, ok := p.store[name]
+		if ok && version.Raw != `` && !version.AtLeast(prop.minVersion.Major, prop.minVersion.Minor) {
+			unsupported = append(unsupported, name)
+			continue
+		}
+		supported = append(supported, v)
+	}
+
+	return supported, unsupported
+}
+
+// propertyTiers holds the requested properties, split by tier
+type propertyTiers struct {
+	all      []string
+	volatile []string
+	static   []string
+}
+
+// tiers splits the requested properties by tier. The default tier of a property may be overridden with a `name:tier`
+// suffix.
+func (p *propertyStore) tiers(props []string) (propertyTiers, error) {
+	result := propertyTiers{}
+	for _, v := range props {
+		name, tier, found := strings.Cut(v, `:`)
+		if !found {
+			prop, _ := p.find(name)
+			tier = string(prop.tier)
+		}
+		switch propertyTier(tier) {
+		case tierVolatile:
+			result.volatile = append(result.volatile, name)
+		case tierStatic:
+			result.static = append(result.static, name)
+		default:
+			return result, fmt.Errorf("unknown tier '%s' for property '%s'", tier, name)
+		}
+		result.all = append(result.all, name)
+	}
+
+	return result, nil
+}
+
+// staticValues returns the values of the static properties found within values
+func (t propertyTiers) staticValues(values map[string]string) map[string]string {
+	result := make(map[string]string, len(t.static))
+	for _, name := range t.static {
+		if v, ok := values[name]; ok {
+			result[name] = v
+		}
+	}
+
+	return result
+}
+
+// mergeProperties returns the union of the provided property values, without modifying them
+func mergeProperties(values ...map[string]string) map[string]string {
+	result := make(map[string]string)
+	for _, v := range values {
+		maps.Copy(result, v)
+	}
+
+	return result
+}
+
+// matchAny reports whether the name matches any of the patterns, which may contain '*' wildcards
+func matchAny(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+func registerCollector(collector string, isDefaultEnabled bool, defaultProps string, factory factoryFunc, opts ...collectorOption) {
 	helpDefaultState := helpDefaultStateDisabled
 	if isDefaultEnabled {
 		helpDefaultState = helpDefaultStateEnabled
@@ -130,22 +347,46 @@ func registerCollector(collector string, isDefaultEnabled bool, defaultProps str
 	enabledFlag := kingpin.Flag(enabledFlagName, enabledFlagHelp).Default(enabledDefaultValue).Bool()
 	propsFlag := kingpin.Flag(propsFlagName, propsFlagHelp).Default(defaultProps).String()
 
-	collectorStates[collector] = State{
+	state := State{
 		Enabled:    enabledFlag,
 		Properties: propsFlag,
 		factory:    factory,
 	}
+	for _, opt := range opts {
+		opt(collector, &state)
+	}
+	collectorStates[collector] = state
 }
 
 func expandMetricName(prefix string, context ...string) string {
 	return strings.Join(append(context, prefix), `-`)
 }
 
+func newInfoMetric(subsystem, helpText string, labels []string, properties ...string) *infoMetric {
+	name := prometheus.BuildFQName(namespace, subsystem, `info`)
+	return &infoMetric{
+		name:       name,
+		desc:       prometheus.NewDesc(name, helpText, append(slices.Clone(labels), properties...), nil),
+		properties: properties,
+	}
+}
+
+// newInfoProperty describes a string property, reported as a label of the info metric of its store
+func newInfoProperty(name string) property {
+	return property{
+		name: name,
+		tier: tierVolatile,
+		info: true,
+	}
+}
+
 func newProperty(subsystem, metricName, helpText string, transform transformFunc, labels ...string) property {
 	name := prometheus.BuildFQName(namespace, subsystem, metricName)
 	return property{
 		name:      name,
 		desc:      prometheus.NewDesc(name, helpText, labels, nil),
 		transform: transform,
+		tier:      tierVolatile,
+		valueType: prometheus.GaugeValue,
 	}
 }
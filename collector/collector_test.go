@@ -5,6 +5,9 @@ import (
 	"context"
 	"io"
 	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
@@ -44,3 +47,47 @@ func stringPointer(s string) *string {
 func boolPointer(b bool) *bool {
 	return &b
 }
+
+// kstatFixture parses a named kstat from the fixtures of the zfs package, as returned by Client.Kstat
+func kstatFixture(t *testing.T, name string) map[string]string {
+	t.Helper()
+	f, err := os.Open(filepath.Join(`..`, `zfs`, `testdata`, `kstat`, name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	values, err := zfs.ParseNamedKstat(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return values
+}
+
+// newTestZFS returns a ZFS collector running the single named collector, with the requested properties
+func newTestZFS(t *testing.T, z zfs.Client, name string, props string, factory factoryFunc) *ZFS {
+	t.Helper()
+	collector, err := NewZFS(defaultConfig(z))
+	if err != nil {
+		t.Fatal(err)
+	}
+	collector.Collectors = map[string]State{
+		name: {
+			Name:       name,
+			Enabled:    boolPointer(true),
+			Properties: stringPointer(props),
+			factory:    factory,
+		},
+	}
+
+	return collector
+}
+
+// collectAndCompare fails the test unless the collector reports the expected metrics
+func collectAndCompare(t *testing.T, ctx context.Context, collector prometheus.Collector, metricResults string, metricNames []string) {
+	t.Helper()
+	if err := callCollector(ctx, collector, []byte(metricResults), metricNames); err != nil {
+		t.Fatal(err)
+	}
+}
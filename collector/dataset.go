@@ -27,7 +27,7 @@ var (
 				`Whether the access time for files is updated when they are read [0: off, 1: on].`,
 				transformBool,
 				datasetLabels...,
-			),
+			).withTier(tierStatic),
 			`available`: newProperty(
 				subsystemDataset,
 				`available_bytes`,
@@ -41,7 +41,7 @@ var (
 				`The compression algorithm used for this dataset. [0: off, 1: on, 2: lz4, 3: zstd, 4: zstd-fast, 3xx: zstd-N, 4xxxx: zstd-fast-N].`,
 				transformCompression,
 				datasetLabels...,
-			),
+			).withTier(tierStatic),
 			`compressratio`: newProperty(
 				subsystemDataset,
 				`compressratio`,
@@ -55,21 +55,21 @@ var (
 				`The time this dataset was created.`,
 				transformNumeric,
 				datasetLabels...,
-			),
+			).withTier(tierStatic),
 			`exec`: newProperty(
 				subsystemDataset,
 				`exec`,
 				`Whether processes can be executed from within this file system [0: off, 1: on].`,
 				transformBool,
 				datasetLabels...,
-			),
+			).withTier(tierStatic),
 			`logbias`: newProperty(
 				subsystemDataset,
 				`logbias`,
 				`Handling of synchronous requests in this dataset. [1: latency, 2: throughput].`,
 				transformLogbias,
 				datasetLabels...,
-			),
+			).withTier(tierStatic),
 			`logicalused`: newProperty(
 				subsystemDataset,
 				`logical_used_bytes`,
@@ -97,7 +97,7 @@ var (
 				`What is cached in the primary cache (ARC) [1: all, 2: metadata, 0: none].`,
 				transformPrimaryCache,
 				datasetLabels...,
-			),
+			).withTier(tierStatic),
 			`quota`: newProperty(
 				subsystemDataset,
 				`quota_bytes`,
@@ -111,7 +111,7 @@ var (
 				`Specifies a suggested block size for files in the file system.`,
 				transformNumeric,
 				datasetLabels...,
-			),
+			).withTier(tierStatic),
 			`refcompressratio`: newProperty(
 				subsystemDataset,
 				`refcompressratio`,
@@ -146,7 +146,7 @@ var (
 				`Controls the manner in which the access time is updated when atime=on is set [0: off, 1: on].`,
 				transformBool,
 				datasetLabels...,
-			),
+			).withTier(tierStatic),
 			`reservation`: newProperty(
 				subsystemDataset,
 				`reservation_bytes`,
@@ -174,7 +174,7 @@ var (
 				`The sync behavior of this dataset [1: standard, 2: always, 0: disabled].`,
 				transformSync,
 				datasetLabels...,
-			),
+			).withTier(tierStatic),
 			`used`: newProperty(
 				subsystemDataset,
 				`used_bytes`,
@@ -229,20 +229,25 @@ var (
 )
 
 func init() {
-	registerCollector(`dataset-filesystem`, defaultEnabled, defaultFilesystemProps, newFilesystemCollector)
-	registerCollector(`dataset-snapshot`, defaultDisabled, defaultSnapshotProps, newSnapshotCollector)
-	registerCollector(`dataset-volume`, defaultEnabled, defaultVolumeProps, newVolumeCollector)
+	registerCollector(`dataset-filesystem`, defaultEnabled, defaultFilesystemProps, newFilesystemCollector, withPropertyStore(&datasetProperties))
+	registerCollector(`dataset-snapshot`, defaultDisabled, defaultSnapshotProps, newSnapshotCollector, withPropertyStore(&datasetProperties))
+	registerCollector(`dataset-volume`, defaultEnabled, defaultVolumeProps, newVolumeCollector, withPropertyStore(&datasetProperties))
 }
 
 type datasetCollector struct {
 	kind   zfs.DatasetKind
 	log    *slog.Logger
 	client zfs.Client
-	props  []string
+	props  propertyTiers
+	static *propertyCache
+}
+
+func (c *datasetCollector) expire(pool string) {
+	c.static.expire(pool)
 }
 
 func (c *datasetCollector) describe(ch chan<- *prometheus.Desc) {
-	for _, k := range c.props {
+	for _, k := range c.props.all {
 		prop, err := datasetProperties.find(k)
 		if err != nil {
 			c.log.Warn(propertyUnsupportedMsg, `help`, helpIssue, `collector`, c.kind, `property`, k, `err`, err)
@@ -275,17 +280,52 @@ func (c *datasetCollector) update(ch chan<- metric, pools []string, excludes reg
 }
 
 func (c *datasetCollector) updatePoolMetrics(ch chan<- metric, pool string, excludes regexpCollection) error {
-	datasets := c.client.Datasets(pool, c.kind)
-	props, err := datasets.Properties(c.props...)
-	if err != nil {
-		return err
+	refresh := c.static.due(pool, *staticPropertiesInterval)
+	query := c.props.all
+	if !refresh {
+		query = c.props.volatile
 	}
 
-	for _, dataset := range props {
-		if excludes.MatchString(dataset.DatasetName()) {
+	values := make(map[string]map[string]string)
+	if len(query) > 0 {
+		datasets := c.client.Datasets(pool, c.kind)
+		props, err := datasets.Properties(query...)
+		if err != nil {
+			return err
+		}
+		for _, dataset := range props {
+			values[dataset.DatasetName()] = dataset.Properties()
+		}
+	}
+
+	if refresh {
+		static := make(map[string]map[string]string, len(values))
+		for name, v := range values {
+			static[name] = c.props.staticValues(v)
+		}
+		c.static.store(pool, static)
+	} else {
+		static := c.static.load(pool)
+		if len(query) == 0 {
+			for name := range static {
+				values[name] = nil
+			}
+		}
+		for name, v := range values {
+			cached, ok := static[name]
+			if !ok {
+				// Refresh static properties on the next run for datasets created since the last refresh.
+				c.static.expire(pool)
+			}
+			values[name] = mergeProperties(v, cached)
+		}
+	}
+
+	for name, v := range values {
+		if excludes.MatchString(name) {
 			continue
 		}
-		if err = c.updateDatasetMetrics(ch, pool, dataset); err != nil {
+		if err := c.updateDatasetMetrics(ch, pool, name, v); err != nil {
 			return err
 		}
 	}
@@ -293,10 +333,10 @@ func (c *datasetCollector) updatePoolMetrics(ch chan<- metric, pool string, excl
 	return nil
 }
 
-func (c *datasetCollector) updateDatasetMetrics(ch chan<- metric, pool string, dataset zfs.DatasetProperties) error {
-	labelValues := []string{dataset.DatasetName(), pool, string(c.kind)}
+func (c *datasetCollector) updateDatasetMetrics(ch chan<- metric, pool string, name string, values map[string]string) error {
+	labelValues := []string{name, pool, string(c.kind)}
 
-	for k, v := range dataset.Properties() {
+	for k, v := range values {
 		prop, err := datasetProperties.find(k)
 		if err != nil {
 			c.log.Warn(propertyUnsupportedMsg, `help`, helpIssue, `collector`, c.kind, `property`, k, `err`, err)
@@ -316,7 +356,12 @@ func newDatasetCollector(kind zfs.DatasetKind, l *slog.Logger, c zfs.Client, pro
 		return nil, fmt.Errorf("unknown dataset type: %s", kind)
 	}
 
-	return &datasetCollector{kind: kind, log: l, client: c, props: props}, nil
+	tiers, err := datasetProperties.tiers(props)
+	if err != nil {
+		return nil, err
+	}
+
+	return &datasetCollector{kind: kind, log: l, client: c, props: tiers, static: newPropertyCache()}, nil
 }
 
 func newFilesystemCollector(l *slog.Logger, c zfs.Client, props []string) (Collector, error) {
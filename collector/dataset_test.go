@@ -4,6 +4,7 @@ import (
 	"context"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/golang/mock/gomock"
 	"github.com/waitingsong/zfs_exporter/v3/zfs"
@@ -328,7 +329,7 @@ zfs_dataset_unsupported{name="testpool/test",pool="testpool",type="filesystem"}
 					zfsDatasetResults := make([]zfs.DatasetProperties, len(tc.propsResults[pool]))
 					for i, propResults := range tc.propsResults[pool] {
 						zfsDatasetProperties := mock_zfs.NewMockDatasetProperties(ctrl)
-						zfsDatasetProperties.EXPECT().DatasetName().Return(propResults.name).Times(2)
+						zfsDatasetProperties.EXPECT().DatasetName().Return(propResults.name).Times(1)
 						zfsDatasetProperties.EXPECT().Properties().Return(propResults.results).Times(1)
 						zfsDatasetResults[i] = zfsDatasetProperties
 					}
@@ -344,3 +345,99 @@ zfs_dataset_unsupported{name="testpool/test",pool="testpool",type="filesystem"}
 		})
 	}
 }
+
+func TestDatasetStaticProperties(t *testing.T) {
+	interval := *staticPropertiesInterval
+	*staticPropertiesInterval = time.Hour
+	defer func() {
+		*staticPropertiesInterval = interval
+	}()
+
+	metricNames := []string{`zfs_dataset_used_bytes`, `zfs_dataset_recordsize`}
+	runs := []struct {
+		propsRequested []string
+		propsResults   []datasetResults
+		metricResults  string
+	}{
+		{
+			propsRequested: []string{`used`, `recordsize`},
+			propsResults: []datasetResults{
+				{name: `testpool/test`, results: map[string]string{`used`: `1024`, `recordsize`: `131072`}},
+			},
+			metricResults: `# HELP zfs_dataset_recordsize Specifies a suggested block size for files in the file system.
+# TYPE zfs_dataset_recordsize gauge
+zfs_dataset_recordsize{name="testpool/test",pool="testpool",type="filesystem"} 131072
+# HELP zfs_dataset_used_bytes The amount of space in bytes consumed by this dataset and all its descendents.
+# TYPE zfs_dataset_used_bytes gauge
+zfs_dataset_used_bytes{name="testpool/test",pool="testpool",type="filesystem"} 1024
+`,
+		},
+		{
+			// Static properties are merged from the cache, datasets created since the last refresh only report volatile properties.
+			propsRequested: []string{`used`},
+			propsResults: []datasetResults{
+				{name: `testpool/test`, results: map[string]string{`used`: `2048`}},
+				{name: `testpool/new`, results: map[string]string{`used`: `512`}},
+			},
+			metricResults: `# HELP zfs_dataset_recordsize Specifies a suggested block size for files in the file system.
+# TYPE zfs_dataset_recordsize gauge
+zfs_dataset_recordsize{name="testpool/test",pool="testpool",type="filesystem"} 131072
+# HELP zfs_dataset_used_bytes The amount of space in bytes consumed by this dataset and all its descendents.
+# TYPE zfs_dataset_used_bytes gauge
+zfs_dataset_used_bytes{name="testpool/new",pool="testpool",type="filesystem"} 512
+zfs_dataset_used_bytes{name="testpool/test",pool="testpool",type="filesystem"} 2048
+`,
+		},
+		{
+			// The new dataset forces a refresh of static properties.
+			propsRequested: []string{`used`, `recordsize`},
+			propsResults: []datasetResults{
+				{name: `testpool/test`, results: map[string]string{`used`: `2048`, `recordsize`: `131072`}},
+				{name: `testpool/new`, results: map[string]string{`used`: `512`, `recordsize`: `16384`}},
+			},
+			metricResults: `# HELP zfs_dataset_recordsize Specifies a suggested block size for files in the file system.
+# TYPE zfs_dataset_recordsize gauge
+zfs_dataset_recordsize{name="testpool/new",pool="testpool",type="filesystem"} 16384
+zfs_dataset_recordsize{name="testpool/test",pool="testpool",type="filesystem"} 131072
+# HELP zfs_dataset_used_bytes The amount of space in bytes consumed by this dataset and all its descendents.
+# TYPE zfs_dataset_used_bytes gauge
+zfs_dataset_used_bytes{name="testpool/new",pool="testpool",type="filesystem"} 512
+zfs_dataset_used_bytes{name="testpool/test",pool="testpool",type="filesystem"} 2048
+`,
+		},
+	}
+
+	ctrl, ctx := gomock.WithContext(context.Background(), t)
+	zfsClient := mock_zfs.NewMockClient(ctrl)
+	zfsClient.EXPECT().PoolNames().Return([]string{`testpool`}, nil).Times(len(runs))
+
+	collector, err := NewZFS(defaultConfig(zfsClient))
+	if err != nil {
+		t.Fatal(err)
+	}
+	collector.Collectors = map[string]State{
+		`dataset-filesystem`: {
+			Name:       "dataset-filesystem",
+			Enabled:    boolPointer(true),
+			Properties: stringPointer(`used,recordsize`),
+			factory:    newFilesystemCollector,
+		},
+	}
+
+	for _, run := range runs {
+		zfsDatasetResults := make([]zfs.DatasetProperties, len(run.propsResults))
+		for i, propResults := range run.propsResults {
+			zfsDatasetProperties := mock_zfs.NewMockDatasetProperties(ctrl)
+			zfsDatasetProperties.EXPECT().DatasetName().Return(propResults.name).Times(1)
+			zfsDatasetProperties.EXPECT().Properties().Return(propResults.results).Times(1)
+			zfsDatasetResults[i] = zfsDatasetProperties
+		}
+		zfsDatasets := mock_zfs.NewMockDatasets(ctrl)
+		zfsDatasets.EXPECT().Properties(run.propsRequested).Return(zfsDatasetResults, nil).Times(1)
+		zfsClient.EXPECT().Datasets(`testpool`, zfs.DatasetFilesystem).Return(zfsDatasets).Times(1)
+
+		if err = callCollector(ctx, collector, []byte(run.metricResults), metricNames); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,168 @@
+package collector
+
+import (
+	"log/slog"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/waitingsong/zfs_exporter/v3/zfs"
+)
+
+const (
+	defaultDedupProps    = `dedup_table_size,dedup_table_quota,dedupcached`
+	defaultDedupInterval = 15 * time.Minute
+
+	dedupClassUnique    = `unique`
+	dedupClassDuplicate = `duplicate`
+)
+
+var (
+	dedupProperties = propertyStore{
+		defaultSubsystem: subsystemPool,
+		defaultLabels:    poolLabels,
+		store: map[string]property{
+			`dedup_table_size`: newProperty(
+				subsystemPool,
+				`dedup_table_size_bytes`,
+				`Amount of space in bytes used on disk by the deduplication tables of the pool.`,
+				transformNumeric,
+				poolLabels...,
+			).withMinVersion(2, 3),
+			`dedup_table_quota`: newProperty(
+				subsystemPool,
+				`dedup_table_quota_bytes`,
+				`Maximum size in bytes of the deduplication tables on disk, beyond which new blocks are not deduplicated [-1: auto, 0: none].`,
+				transformDedupQuota,
+				poolLabels...,
+			).withMinVersion(2, 3),
+			`dedupcached`: newProperty(
+				subsystemPool,
+				`dedup_cached_bytes`,
+				`Amount of memory in bytes used in the ARC by the deduplication tables of the pool.`,
+				transformNumeric,
+				poolLabels...,
+			).withMinVersion(2, 3),
+		},
+	}
+
+	dedupClassLabels = []string{`pool`, `class`}
+	dedupEntries     = newProperty(
+		subsystemPool,
+		`dedup_entries`,
+		`Number of entries in the deduplication tables of the pool, by class [unique: referenced once, duplicate: referenced more than once].`,
+		transformNumeric,
+		dedupClassLabels...,
+	)
+	dedupDiskBytes = newProperty(
+		subsystemPool,
+		`dedup_disk_bytes`,
+		`Estimated amount of space in bytes used on disk by the entries of the deduplication tables of the pool, by class, from the average entry size.`,
+		transformNumeric,
+		dedupClassLabels...,
+	)
+	dedupCoreBytes = newProperty(
+		subsystemPool,
+		`dedup_core_bytes`,
+		`Estimated amount of memory in bytes needed to hold the entries of the deduplication tables of the pool, by class, from the average entry size.`,
+		transformNumeric,
+		dedupClassLabels...,
+	)
+	dedupRefCount = newHistogramMetric(
+		subsystemPool,
+		`dedup_refcount`,
+		`Distribution of the entries of the deduplication tables of the pool by reference count, the sum being the number of referenced blocks. Counts are rounded to three significant digits by zpool.`,
+		poolLabels...,
+	)
+)
+
+func init() {
+	registerCollector(`dedup`, defaultDisabled, defaultDedupProps, newDedupCollector, withPropertyStore(&dedupProperties), withInterval(defaultDedupInterval))
+}
+
+// dedupCollector reports the size of the deduplication tables from `zpool status -D`, which walks the tables and is
+// therefore scheduled on its own interval
+type dedupCollector struct {
+	log    *slog.Logger
+	client zfs.Client
+	props  []string
+}
+
+func (c *dedupCollector) describe(ch chan<- *prometheus.Desc) {
+	for _, k := range c.props {
+		prop, err := dedupProperties.find(k)
+		if err != nil {
+			c.log.Warn(propertyUnsupportedMsg, `help`, helpIssue, `collector`, `dedup`, `property`, k, `err`, err)
+			continue
+		}
+		ch <- prop.desc
+	}
+	ch <- dedupEntries.desc
+	ch <- dedupDiskBytes.desc
+	ch <- dedupCoreBytes.desc
+	ch <- dedupRefCount.desc
+}
+
+func (c *dedupCollector) update(ch chan<- metric, pools []string, excludes regexpCollection) error {
+	for _, pool := range pools {
+		if err := c.updateDedupMetrics(ch, pool); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (c *dedupCollector) updateDedupMetrics(ch chan<- metric, pool string) error {
+	p := c.client.Pool(pool)
+	stats, err := p.DedupStats()
+	if err != nil {
+		return err
+	}
+
+	// Each entry is a block allocated once, so the allocated blocks of the first bucket are the unique entries.
+	var unique uint64
+	if len(stats.Histogram) > 0 && stats.Histogram[0].RefCount == 1 {
+		unique = min(stats.Histogram[0].Allocated.Blocks, stats.Entries)
+	}
+	for class, entries := range map[string]uint64{dedupClassUnique: unique, dedupClassDuplicate: stats.Entries - unique} {
+		dedupEntries.pushValue(ch, float64(entries), pool, class)
+		dedupDiskBytes.pushValue(ch, float64(entries*stats.DiskEntrySize), pool, class)
+		dedupCoreBytes.pushValue(ch, float64(entries*stats.CoreEntrySize), pool, class)
+	}
+
+	h := newHistogram(make([]float64, len(stats.Histogram)))
+	for i, bucket := range stats.Histogram {
+		h.upperBounds[i] = float64(2*bucket.RefCount - 1)
+		h.counts[i] = bucket.Allocated.Blocks
+		h.count += bucket.Allocated.Blocks
+		h.sum += float64(bucket.Referenced.Blocks)
+	}
+	dedupRefCount.push(ch, h, pool)
+
+	if len(c.props) == 0 {
+		return nil
+	}
+	props, err := p.Properties(c.props...)
+	if err != nil {
+		return err
+	}
+	for k, v := range props.Properties() {
+		prop, err := dedupProperties.find(k)
+		if err != nil {
+			c.log.Warn(propertyUnsupportedMsg, `help`, helpIssue, `collector`, `dedup`, `property`, k, `err`, err)
+		}
+		if err = prop.push(ch, v, pool); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func newDedupCollector(l *slog.Logger, c zfs.Client, props []string) (Collector, error) {
+	tiers, err := dedupProperties.tiers(props)
+	if err != nil {
+		return nil, err
+	}
+	return &dedupCollector{log: l, client: c, props: tiers.all}, nil
+}
@@ -0,0 +1,73 @@
+package collector
+
+import (
+	"context"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/waitingsong/zfs_exporter/v3/zfs"
+	"github.com/waitingsong/zfs_exporter/v3/zfs/mock_zfs"
+)
+
+func TestDedupMetrics(t *testing.T) {
+	const result = `# HELP zfs_pool_dedup_core_bytes Estimated amount of memory in bytes needed to hold the entries of the deduplication tables of the pool, by class, from the average entry size.
+# TYPE zfs_pool_dedup_core_bytes gauge
+zfs_pool_dedup_core_bytes{class="duplicate",pool="tank"} 2.8743324e+07
+zfs_pool_dedup_core_bytes{class="unique",pool="tank"} 2.00886138e+08
+# HELP zfs_pool_dedup_disk_bytes Estimated amount of space in bytes used on disk by the entries of the deduplication tables of the pool, by class, from the average entry size.
+# TYPE zfs_pool_dedup_disk_bytes gauge
+zfs_pool_dedup_disk_bytes{class="duplicate",pool="tank"} 4.4660326e+07
+zfs_pool_dedup_disk_bytes{class="unique",pool="tank"} 3.12129537e+08
+# HELP zfs_pool_dedup_entries Number of entries in the deduplication tables of the pool, by class [unique: referenced once, duplicate: referenced more than once].
+# TYPE zfs_pool_dedup_entries gauge
+zfs_pool_dedup_entries{class="duplicate",pool="tank"} 154534
+zfs_pool_dedup_entries{class="unique",pool="tank"} 1.080033e+06
+# HELP zfs_pool_dedup_refcount Distribution of the entries of the deduplication tables of the pool by reference count, the sum being the number of referenced blocks. Counts are rounded to three significant digits by zpool.
+# TYPE zfs_pool_dedup_refcount histogram
+zfs_pool_dedup_refcount_bucket{pool="tank",le="1"} 1.080033e+06
+zfs_pool_dedup_refcount_bucket{pool="tank",le="3"} 1.236705e+06
+zfs_pool_dedup_refcount_bucket{pool="tank",le="7"} 1.240196e+06
+zfs_pool_dedup_refcount_bucket{pool="tank",le="2047"} 1.240198e+06
+zfs_pool_dedup_refcount_bucket{pool="tank",le="+Inf"} 1.240198e+06
+zfs_pool_dedup_refcount_sum{pool="tank"} 1.435975e+06
+zfs_pool_dedup_refcount_count{pool="tank"} 1.240198e+06
+# HELP zfs_pool_dedup_table_quota_bytes Maximum size in bytes of the deduplication tables on disk, beyond which new blocks are not deduplicated [-1: auto, 0: none].
+# TYPE zfs_pool_dedup_table_quota_bytes gauge
+zfs_pool_dedup_table_quota_bytes{pool="tank"} -1
+# HELP zfs_pool_dedup_table_size_bytes Amount of space in bytes used on disk by the deduplication tables of the pool.
+# TYPE zfs_pool_dedup_table_size_bytes gauge
+zfs_pool_dedup_table_size_bytes{pool="tank"} 3.56806144e+08
+`
+
+	ctrl, ctx := gomock.WithContext(context.Background(), t)
+	zfsClient := mock_zfs.NewMockClient(ctrl)
+	zfsClient.EXPECT().PoolNames().Return([]string{`tank`}, nil).Times(1)
+	zfsPool := mock_zfs.NewMockPool(ctrl)
+	zfsPool.EXPECT().DedupStats().Return(zfs.DedupStats{
+		Entries:       1234567,
+		DiskEntrySize: 289,
+		CoreEntrySize: 186,
+		Histogram: []zfs.DedupBucket{
+			{RefCount: 1, Allocated: zfs.DedupBlocks{Blocks: 1080033}, Referenced: zfs.DedupBlocks{Blocks: 1080033}},
+			{RefCount: 2, Allocated: zfs.DedupBlocks{Blocks: 156672}, Referenced: zfs.DedupBlocks{Blocks: 337920}},
+			{RefCount: 4, Allocated: zfs.DedupBlocks{Blocks: 3491}, Referenced: zfs.DedupBlocks{Blocks: 15462}},
+			{RefCount: 1024, Allocated: zfs.DedupBlocks{Blocks: 2}, Referenced: zfs.DedupBlocks{Blocks: 2560}},
+		},
+	}, nil).Times(1)
+	zfsPoolProperties := mock_zfs.NewMockPoolProperties(ctrl)
+	zfsPoolProperties.EXPECT().Properties().Return(map[string]string{`dedup_table_size`: `356806144`, `dedup_table_quota`: `auto`}).Times(1)
+	zfsPool.EXPECT().Properties(`dedup_table_size`, `dedup_table_quota`).Return(zfsPoolProperties, nil).Times(1)
+	zfsClient.EXPECT().Pool(`tank`).Return(zfsPool).Times(1)
+
+	collector := newTestZFS(t, zfsClient, `dedup`, `dedup_table_size,dedup_table_quota`, newDedupCollector)
+
+	metricNames := []string{
+		`zfs_pool_dedup_core_bytes`,
+		`zfs_pool_dedup_disk_bytes`,
+		`zfs_pool_dedup_entries`,
+		`zfs_pool_dedup_refcount`,
+		`zfs_pool_dedup_table_quota_bytes`,
+		`zfs_pool_dedup_table_size_bytes`,
+	}
+	collectAndCompare(t, ctx, collector, result, metricNames)
+}
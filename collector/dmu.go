@@ -0,0 +1,431 @@
+package collector
+
+import (
+	"log/slog"
+	"slices"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/waitingsong/zfs_exporter/v3/zfs"
+)
+
+const (
+	defaultDMUProps = `zfetch_hits,zfetch_misses,zfetch_max_streams,zfetch_future,zfetch_stride,zfetch_past,dbuf_cache_size_bytes,dbuf_cache_target_bytes,dbuf_cache_total_evicts,dbuf_hash_hits,dbuf_hash_misses,dbuf_metadata_cache_size_bytes,dbuf_metadata_cache_overflow,dnode_hold_alloc_hits,dnode_hold_alloc_misses,dnode_hold_free_hits,dnode_hold_free_misses,dnode_allocate,dnode_buf_evict`
+)
+
+// dmuKstat maps properties to the fields of a kstat, by the property name prefix
+type dmuKstat struct {
+	name      string
+	prefix    string
+	subsystem string
+	// trim removes the prefix from the property name to find the field, unless the fields already carry it
+	trim bool
+}
+
+// find returns the property, describing unknown properties under the subsystem of the kstat
+func (ks dmuKstat) find(name string) (property, error) {
+	prop, err := dmuProperties.find(name)
+	if err != nil {
+		prop = newProperty(ks.subsystem, strings.TrimPrefix(name, ks.prefix), propertyUnsupportedDesc, transformNumeric)
+	}
+
+	return prop, err
+}
+
+var (
+	dmuKstats = []dmuKstat{
+		{name: `zfetchstats`, prefix: `zfetch_`, subsystem: subsystemZfetch, trim: true},
+		{name: `dbufstats`, prefix: `dbuf_`, subsystem: subsystemDbuf, trim: true},
+		{name: `dnodestats`, prefix: `dnode_`, subsystem: subsystemDnode},
+	}
+
+	dmuProperties = propertyStore{
+		store: map[string]property{
+			`zfetch_hits`: newProperty(
+				subsystemZfetch,
+				`hits_total`,
+				`Number of reads which hit an existing prefetch stream.`,
+				transformNumeric,
+			).withValueType(prometheus.CounterValue),
+			`zfetch_misses`: newProperty(
+				subsystemZfetch,
+				`misses_total`,
+				`Number of reads which did not match any prefetch stream.`,
+				transformNumeric,
+			).withValueType(prometheus.CounterValue),
+			`zfetch_max_streams`: newProperty(
+				subsystemZfetch,
+				`max_streams_total`,
+				`Number of times a prefetch stream could not be created, as the per-file stream limit was reached.`,
+				transformNumeric,
+			).withValueType(prometheus.CounterValue),
+			`zfetch_future`: newProperty(
+				subsystemZfetch,
+				`future_hits_total`,
+				`Number of reads which hit ahead of the current position of a prefetch stream.`,
+				transformNumeric,
+			).withValueType(prometheus.CounterValue),
+			`zfetch_stride`: newProperty(
+				subsystemZfetch,
+				`stride_hits_total`,
+				`Number of reads which matched a strided prefetch stream.`,
+				transformNumeric,
+			).withValueType(prometheus.CounterValue),
+			`zfetch_past`: newProperty(
+				subsystemZfetch,
+				`past_hits_total`,
+				`Number of reads which hit behind the current position of a prefetch stream.`,
+				transformNumeric,
+			).withValueType(prometheus.CounterValue),
+			`zfetch_io_issued`: newProperty(
+				subsystemZfetch,
+				`io_issued_total`,
+				`Number of prefetch I/Os issued.`,
+				transformNumeric,
+			).withValueType(prometheus.CounterValue),
+			`zfetch_io_active`: newProperty(
+				subsystemZfetch,
+				`io_active`,
+				`Number of prefetch I/Os currently in flight.`,
+				transformNumeric,
+			),
+			`dbuf_cache_count`: newProperty(
+				subsystemDbuf,
+				`cache_dbufs`,
+				`Number of dbufs in the dbuf cache.`,
+				transformNumeric,
+			),
+			`dbuf_cache_size_bytes`: newProperty(
+				subsystemDbuf,
+				`cache_size_bytes`,
+				`Size in bytes of the dbuf cache.`,
+				transformNumeric,
+			),
+			`dbuf_cache_size_bytes_max`: newProperty(
+				subsystemDbuf,
+				`cache_size_max_bytes`,
+				`Highest size in bytes reached by the dbuf cache.`,
+				transformNumeric,
+			),
+			`dbuf_cache_target_bytes`: newProperty(
+				subsystemDbuf,
+				`cache_target_bytes`,
+				`Target size in bytes of the dbuf cache.`,
+				transformNumeric,
+			),
+			`dbuf_cache_lowater_bytes`: newProperty(
+				subsystemDbuf,
+				`cache_lowater_bytes`,
+				`Size in bytes below which the dbuf cache stops evicting.`,
+				transformNumeric,
+			),
+			`dbuf_cache_hiwater_bytes`: newProperty(
+				subsystemDbuf,
+				`cache_hiwater_bytes`,
+				`Size in bytes above which the dbuf cache evicts synchronously.`,
+				transformNumeric,
+			),
+			`dbuf_cache_total_evicts`: newProperty(
+				subsystemDbuf,
+				`cache_evictions_total`,
+				`Number of dbufs evicted from the dbuf cache.`,
+				transformNumeric,
+			).withValueType(prometheus.CounterValue),
+			`dbuf_hash_hits`: newProperty(
+				subsystemDbuf,
+				`hash_hits_total`,
+				`Number of dbuf hash table lookups which found the dbuf.`,
+				transformNumeric,
+			).withValueType(prometheus.CounterValue),
+			`dbuf_hash_misses`: newProperty(
+				subsystemDbuf,
+				`hash_misses_total`,
+				`Number of dbuf hash table lookups which did not find the dbuf.`,
+				transformNumeric,
+			).withValueType(prometheus.CounterValue),
+			`dbuf_hash_collisions`: newProperty(
+				subsystemDbuf,
+				`hash_collisions_total`,
+				`Number of dbuf hash table insertions which collided with an existing entry.`,
+				transformNumeric,
+			).withValueType(prometheus.CounterValue),
+			`dbuf_hash_elements`: newProperty(
+				subsystemDbuf,
+				`hash_elements`,
+				`Number of dbufs in the dbuf hash table.`,
+				transformNumeric,
+			),
+			`dbuf_hash_elements_max`: newProperty(
+				subsystemDbuf,
+				`hash_elements_max`,
+				`Highest number of dbufs held in the dbuf hash table.`,
+				transformNumeric,
+			),
+			`dbuf_hash_chains`: newProperty(
+				subsystemDbuf,
+				`hash_chains`,
+				`Number of dbuf hash table buckets holding more than one dbuf.`,
+				transformNumeric,
+			),
+			`dbuf_hash_chain_max`: newProperty(
+				subsystemDbuf,
+				`hash_chain_max`,
+				`Longest dbuf hash table chain seen.`,
+				transformNumeric,
+			),
+			`dbuf_hash_insert_race`: newProperty(
+				subsystemDbuf,
+				`hash_insert_races_total`,
+				`Number of dbuf hash table insertions which raced with a concurrent insertion of the same dbuf.`,
+				transformNumeric,
+			).withValueType(prometheus.CounterValue),
+			`dbuf_hash_table_count`: newProperty(
+				subsystemDbuf,
+				`hash_table_buckets`,
+				`Number of buckets in the dbuf hash table.`,
+				transformNumeric,
+			),
+			`dbuf_hash_mutex_count`: newProperty(
+				subsystemDbuf,
+				`hash_mutexes`,
+				`Number of mutexes protecting the dbuf hash table.`,
+				transformNumeric,
+			),
+			`dbuf_metadata_cache_count`: newProperty(
+				subsystemDbuf,
+				`metadata_cache_dbufs`,
+				`Number of dbufs in the dbuf metadata cache.`,
+				transformNumeric,
+			),
+			`dbuf_metadata_cache_size_bytes`: newProperty(
+				subsystemDbuf,
+				`metadata_cache_size_bytes`,
+				`Size in bytes of the dbuf metadata cache.`,
+				transformNumeric,
+			),
+			`dbuf_metadata_cache_size_bytes_max`: newProperty(
+				subsystemDbuf,
+				`metadata_cache_size_max_bytes`,
+				`Highest size in bytes reached by the dbuf metadata cache.`,
+				transformNumeric,
+			),
+			`dbuf_metadata_cache_overflow`: newProperty(
+				subsystemDbuf,
+				`metadata_cache_overflows_total`,
+				`Number of times the dbuf metadata cache exceeded its limit.`,
+				transformNumeric,
+			).withValueType(prometheus.CounterValue),
+			`dnode_hold_dbuf_hold`: newProperty(
+				subsystemDnode,
+				`hold_dbuf_hold_failures_total`,
+				`Number of dnode holds which failed to hold the dnode block.`,
+				transformNumeric,
+			).withValueType(prometheus.CounterValue),
+			`dnode_hold_dbuf_read`: newProperty(
+				subsystemDnode,
+				`hold_dbuf_read_failures_total`,
+				`Number of dnode holds which failed to read the dnode block.`,
+				transformNumeric,
+			).withValueType(prometheus.CounterValue),
+			`dnode_hold_alloc_hits`: newProperty(
+				subsystemDnode,
+				`hold_alloc_hits_total`,
+				`Number of holds on allocated dnodes which found the dnode in memory.`,
+				transformNumeric,
+			).withValueType(prometheus.CounterValue),
+			`dnode_hold_alloc_misses`: newProperty(
+				subsystemDnode,
+				`hold_alloc_misses_total`,
+				`Number of holds on allocated dnodes which did not find an allocated dnode.`,
+				transformNumeric,
+			).withValueType(prometheus.CounterValue),
+			`dnode_hold_alloc_interior`: newProperty(
+				subsystemDnode,
+				`hold_alloc_interior_total`,
+				`Number of holds on allocated dnodes which referenced the interior of a multi-slot dnode.`,
+				transformNumeric,
+			).withValueType(prometheus.CounterValue),
+			`dnode_hold_alloc_lock_retry`: newProperty(
+				subsystemDnode,
+				`hold_alloc_lock_retries_total`,
+				`Number of holds on allocated dnodes which retried taking the slot lock.`,
+				transformNumeric,
+			).withValueType(prometheus.CounterValue),
+			`dnode_hold_alloc_lock_misses`: newProperty(
+				subsystemDnode,
+				`hold_alloc_lock_misses_total`,
+				`Number of holds on allocated dnodes which found the dnode changed while taking the slot lock.`,
+				transformNumeric,
+			).withValueType(prometheus.CounterValue),
+			`dnode_hold_alloc_type_none`: newProperty(
+				subsystemDnode,
+				`hold_alloc_type_none_total`,
+				`Number of holds on allocated dnodes which found a freed dnode.`,
+				transformNumeric,
+			).withValueType(prometheus.CounterValue),
+			`dnode_hold_free_hits`: newProperty(
+				subsystemDnode,
+				`hold_free_hits_total`,
+				`Number of holds on free dnodes which found the slots free.`,
+				transformNumeric,
+			).withValueType(prometheus.CounterValue),
+			`dnode_hold_free_misses`: newProperty(
+				subsystemDnode,
+				`hold_free_misses_total`,
+				`Number of holds on free dnodes which found the slots in use.`,
+				transformNumeric,
+			).withValueType(prometheus.CounterValue),
+			`dnode_hold_free_lock_misses`: newProperty(
+				subsystemDnode,
+				`hold_free_lock_misses_total`,
+				`Number of holds on free dnodes which found the slots in use after taking the slot lock.`,
+				transformNumeric,
+			).withValueType(prometheus.CounterValue),
+			`dnode_hold_free_lock_retry`: newProperty(
+				subsystemDnode,
+				`hold_free_lock_retries_total`,
+				`Number of holds on free dnodes which retried taking the slot lock.`,
+				transformNumeric,
+			).withValueType(prometheus.CounterValue),
+			`dnode_hold_free_overflow`: newProperty(
+				subsystemDnode,
+				`hold_free_overflows_total`,
+				`Number of holds on free dnodes which would have overflowed the dnode block.`,
+				transformNumeric,
+			).withValueType(prometheus.CounterValue),
+			`dnode_hold_free_refcount`: newProperty(
+				subsystemDnode,
+				`hold_free_refcount_total`,
+				`Number of holds on free dnodes which found the dnode still referenced.`,
+				transformNumeric,
+			).withValueType(prometheus.CounterValue),
+			`dnode_free_interior_lock_retry`: newProperty(
+				subsystemDnode,
+				`free_interior_lock_retries_total`,
+				`Number of retries taking the slot lock while freeing the interior of a multi-slot dnode.`,
+				transformNumeric,
+			).withValueType(prometheus.CounterValue),
+			`dnode_allocate`: newProperty(
+				subsystemDnode,
+				`allocations_total`,
+				`Number of dnodes allocated.`,
+				transformNumeric,
+			).withValueType(prometheus.CounterValue),
+			`dnode_reallocate`: newProperty(
+				subsystemDnode,
+				`reallocations_total`,
+				`Number of dnodes reallocated.`,
+				transformNumeric,
+			).withValueType(prometheus.CounterValue),
+			`dnode_buf_evict`: newProperty(
+				subsystemDnode,
+				`buf_evictions_total`,
+				`Number of dnode blocks evicted from the dbuf cache.`,
+				transformNumeric,
+			).withValueType(prometheus.CounterValue),
+			`dnode_alloc_next_chunk`: newProperty(
+				subsystemDnode,
+				`alloc_next_chunk_total`,
+				`Number of dnode allocations which advanced to the next chunk of the dnode block.`,
+				transformNumeric,
+			).withValueType(prometheus.CounterValue),
+			`dnode_alloc_race`: newProperty(
+				subsystemDnode,
+				`alloc_races_total`,
+				`Number of dnode allocations which raced with a concurrent allocation.`,
+				transformNumeric,
+			).withValueType(prometheus.CounterValue),
+			`dnode_alloc_next_block`: newProperty(
+				subsystemDnode,
+				`alloc_next_block_total`,
+				`Number of dnode allocations which advanced to the next dnode block.`,
+				transformNumeric,
+			).withValueType(prometheus.CounterValue),
+		},
+	}
+)
+
+func init() {
+	registerCollector(`dmu`, defaultDisabled, defaultDMUProps, newDMUCollector)
+}
+
+type dmuCollector struct {
+	log    *slog.Logger
+	client zfs.Client
+	props  []string
+}
+
+// kstat returns the kstat holding the property, by its prefix
+func (c *dmuCollector) kstat(name string) (dmuKstat, bool) {
+	i := slices.IndexFunc(dmuKstats, func(ks dmuKstat) bool { return strings.HasPrefix(name, ks.prefix) })
+	if i < 0 {
+		return dmuKstat{}, false
+	}
+
+	return dmuKstats[i], true
+}
+
+func (c *dmuCollector) describe(ch chan<- *prometheus.Desc) {
+	for _, k := range c.props {
+		ks, ok := c.kstat(k)
+		if !ok {
+			continue
+		}
+		// Unknown properties are still described, as they are reported under the subsystem of their kstat.
+		prop, _ := ks.find(k)
+		ch <- prop.desc
+	}
+}
+
+func (c *dmuCollector) update(ch chan<- metric, pools []string, excludes regexpCollection) error {
+	for _, k := range c.props {
+		if _, ok := c.kstat(k); !ok {
+			c.log.Warn(propertyUnsupportedMsg, `help`, helpIssue, `collector`, `dmu`, `property`, k, `err`, errUnsupportedProperty)
+		}
+	}
+	for _, ks := range dmuKstats {
+		if err := c.updateKstatMetrics(ch, ks); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (c *dmuCollector) updateKstatMetrics(ch chan<- metric, ks dmuKstat) error {
+	var values map[string]string
+	for _, k := range c.props {
+		if !strings.HasPrefix(k, ks.prefix) {
+			continue
+		}
+		// Only read the kstat if any of its properties were requested.
+		if values == nil {
+			var err error
+			if values, err = c.client.Kstat(ks.name); err != nil {
+				return err
+			}
+		}
+		field := k
+		if ks.trim {
+			field = strings.TrimPrefix(k, ks.prefix)
+		}
+		v, ok := values[field]
+		if !ok {
+			// Not all kstat fields are available in all versions of OpenZFS.
+			continue
+		}
+		prop, err := ks.find(k)
+		if err != nil {
+			c.log.Warn(propertyUnsupportedMsg, `help`, helpIssue, `collector`, `dmu`, `property`, k, `err`, err)
+		}
+		if err = prop.push(ch, v); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func newDMUCollector(l *slog.Logger, c zfs.Client, props []string) (Collector, error) {
+	return &dmuCollector{log: l, client: c, props: props}, nil
+}
@@ -0,0 +1,102 @@
+package collector
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/waitingsong/zfs_exporter/v3/zfs/mock_zfs"
+)
+
+func TestDMUMetrics(t *testing.T) {
+	testCases := []struct {
+		name           string
+		propsRequested []string
+		metricNames    []string
+		// kstats maps the kstats read to their fixture
+		kstats        map[string]string
+		metricResults string
+	}{
+		{
+			name:           `OpenZFS 0.8 prefetch, missing fields`,
+			propsRequested: []string{`zfetch_hits`, `zfetch_misses`, `zfetch_future`, `zfetch_io_active`},
+			metricNames:    []string{`zfs_zfetch_hits_total`, `zfs_zfetch_misses_total`, `zfs_zfetch_future_hits_total`, `zfs_zfetch_io_active`},
+			kstats:         map[string]string{`zfetchstats`: `0.8.6/zfetchstats`},
+			metricResults: `# HELP zfs_zfetch_hits_total Number of reads which hit an existing prefetch stream.
+# TYPE zfs_zfetch_hits_total counter
+zfs_zfetch_hits_total 10832
+# HELP zfs_zfetch_misses_total Number of reads which did not match any prefetch stream.
+# TYPE zfs_zfetch_misses_total counter
+zfs_zfetch_misses_total 448910
+`,
+		},
+		{
+			name:           `OpenZFS 2.2 prefetch, dbuf and dnode`,
+			propsRequested: []string{`zfetch_future`, `zfetch_io_active`, `dbuf_cache_size_bytes`, `dbuf_cache_total_evicts`, `dbuf_hash_elements_max`, `dbuf_hash_table_count`, `dnode_hold_alloc_hits`, `dnode_allocate`},
+			metricNames:    []string{`zfs_zfetch_future_hits_total`, `zfs_zfetch_io_active`, `zfs_dbuf_cache_size_bytes`, `zfs_dbuf_cache_evictions_total`, `zfs_dbuf_hash_elements_max`, `zfs_dbuf_hash_table_buckets`, `zfs_dnode_hold_alloc_hits_total`, `zfs_dnode_allocations_total`},
+			kstats: map[string]string{
+				`zfetchstats`: `2.2.6/zfetchstats`,
+				`dbufstats`:   `2.2.6/dbufstats`,
+				`dnodestats`:  `2.2.6/dnodestats`,
+			},
+			metricResults: `# HELP zfs_dbuf_cache_evictions_total Number of dbufs evicted from the dbuf cache.
+# TYPE zfs_dbuf_cache_evictions_total counter
+zfs_dbuf_cache_evictions_total 5422
+# HELP zfs_dbuf_cache_size_bytes Size in bytes of the dbuf cache.
+# TYPE zfs_dbuf_cache_size_bytes gauge
+zfs_dbuf_cache_size_bytes 3.8797312e+07
+# HELP zfs_dbuf_hash_table_buckets Number of buckets in the dbuf hash table.
+# TYPE zfs_dbuf_hash_table_buckets gauge
+zfs_dbuf_hash_table_buckets 1.048576e+06
+# HELP zfs_dnode_allocations_total Number of dnodes allocated.
+# TYPE zfs_dnode_allocations_total counter
+zfs_dnode_allocations_total 118930
+# HELP zfs_dnode_hold_alloc_hits_total Number of holds on allocated dnodes which found the dnode in memory.
+# TYPE zfs_dnode_hold_alloc_hits_total counter
+zfs_dnode_hold_alloc_hits_total 2.0384417e+07
+# HELP zfs_zfetch_future_hits_total Number of reads which hit ahead of the current position of a prefetch stream.
+# TYPE zfs_zfetch_future_hits_total counter
+zfs_zfetch_future_hits_total 192043
+# HELP zfs_zfetch_io_active Number of prefetch I/Os currently in flight.
+# TYPE zfs_zfetch_io_active gauge
+zfs_zfetch_io_active 3
+`,
+		},
+		{
+			// Unknown properties are reported under the subsystem of their kstat, those matching no kstat are skipped.
+			name:           `unsupported`,
+			propsRequested: []string{`dbuf_hash_hits`, `dnode_move_active`, `arc_hits`},
+			metricNames:    []string{`zfs_dbuf_hash_hits_total`, `zfs_dnode_move_active`, `zfs_dbuf_dnode_move_active`, `zfs_dbuf_arc_hits`},
+			kstats: map[string]string{
+				`dbufstats`:  `2.1.15/dbufstats`,
+				`dnodestats`: `2.1.15/dnodestats`,
+			},
+			metricResults: `# HELP zfs_dbuf_hash_hits_total Number of dbuf hash table lookups which found the dbuf.
+# TYPE zfs_dbuf_hash_hits_total counter
+zfs_dbuf_hash_hits_total 4.9183201e+07
+# HELP zfs_dnode_move_active !!! This property is unsupported, results are likely to be undesirable, please file an issue at https://github.com/waitingsong/zfs_exporter/issues to have this property supported !!!
+# TYPE zfs_dnode_move_active gauge
+zfs_dnode_move_active 0
+`,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			ctrl, ctx := gomock.WithContext(context.Background(), t)
+			zfsClient := mock_zfs.NewMockClient(ctrl)
+			zfsClient.EXPECT().PoolNames().Return([]string{`testpool`}, nil).Times(1)
+			// Only the kstats holding requested properties are read.
+			for name, fixture := range tc.kstats {
+				zfsClient.EXPECT().Kstat(name).Return(kstatFixture(t, fixture), nil).Times(1)
+			}
+
+			collector := newTestZFS(t, zfsClient, `dmu`, strings.Join(tc.propsRequested, `,`), newDMUCollector)
+
+			collectAndCompare(t, ctx, collector, tc.metricResults, tc.metricNames)
+		})
+	}
+}
@@ -0,0 +1,145 @@
+package collector
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/waitingsong/zfs_exporter/v3/zfs"
+)
+
+var (
+	eventsTotal = newProperty(
+		subsystemEvents,
+		`total`,
+		`Number of ZFS events posted while followed by the exporter, or queued by the kernel when first followed, by class, pool and vdev path. Events unrelated to a pool or vdev have an empty label.`,
+		transformNumeric,
+		`class`, `pool`, `vdev`,
+	).withValueType(prometheus.CounterValue)
+	eventsLastTimestamp = newProperty(
+		subsystemEvents,
+		`last_timestamp_seconds`,
+		`Unix timestamp of the last ZFS event of the class.`,
+		transformNumeric,
+		`class`,
+	)
+	eventsDropped = newProperty(
+		subsystemEvents,
+		`dropped_total`,
+		`Number of ZFS events missed by the exporter, as they left the kernel queue before being read (e.g. on 'zpool events -c').`,
+		transformNumeric,
+	).withValueType(prometheus.CounterValue)
+)
+
+func init() {
+	registerCollector(`events`, defaultDisabled, ``, newEventsCollector)
+}
+
+// eventKey identifies the counter of an event
+type eventKey struct {
+	class string
+	pool  string
+	vdev  string
+}
+
+// eventsCollector follows a single long-running `zpool events` process. As each run of the process starts with the
+// events still queued by the kernel, events already counted by a previous run are skipped by their EID.
+type eventsCollector struct {
+	log        *slog.Logger
+	client     zfs.Client
+	followerMu sync.Mutex
+	follower   *follower
+	mu         sync.Mutex
+	newRun     bool
+	lastEID    uint64
+	lastTime   time.Time
+	counts     map[eventKey]uint64
+	last       map[string]time.Time
+	dropped    uint64
+}
+
+func (c *eventsCollector) describe(ch chan<- *prometheus.Desc) {
+	ch <- eventsTotal.desc
+	ch <- eventsLastTimestamp.desc
+	ch <- eventsDropped.desc
+}
+
+func (c *eventsCollector) update(ch chan<- metric, pools []string, excludes regexpCollection) error {
+	c.follow()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for key, count := range c.counts {
+		if key.pool != `` && !slices.Contains(pools, key.pool) {
+			continue
+		}
+		eventsTotal.pushValue(ch, float64(count), key.class, key.pool, key.vdev)
+	}
+	for class, t := range c.last {
+		eventsLastTimestamp.pushValue(ch, float64(t.UnixNano())/1e9, class)
+	}
+	eventsDropped.pushValue(ch, float64(c.dropped))
+
+	return nil
+}
+
+// follow starts following events, unless already started
+func (c *eventsCollector) follow() {
+	c.followerMu.Lock()
+	defer c.followerMu.Unlock()
+	if c.follower != nil {
+		return
+	}
+	c.follower = startFollower(c.log, `events`, func(ctx context.Context) error {
+		c.mu.Lock()
+		c.newRun = true
+		c.mu.Unlock()
+		return c.client.Events(ctx, c.handle)
+	})
+}
+
+// handle counts the event, unless already counted by a previous run
+func (c *eventsCollector) handle(e zfs.Event) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if e.Dropped > 0 {
+		c.dropped += uint64(e.Dropped)
+		return
+	}
+	if e.EID != 0 {
+		// EIDs restart from 1 when the zfs module is reloaded, which is told apart from events still queued from the
+		// previous run by the first event of the run being newer than the last counted one.
+		if c.newRun && e.EID < c.lastEID && e.Time.After(c.lastTime) {
+			c.log.Info("ZFS event IDs were reset, the zfs module was likely reloaded", "eid", e.EID, "last_eid", c.lastEID)
+			c.lastEID = 0
+		}
+		c.newRun = false
+		if e.EID <= c.lastEID {
+			return
+		}
+		c.lastEID = e.EID
+		c.lastTime = e.Time
+	}
+	key := eventKey{class: e.Class, pool: e.Attributes[`pool`], vdev: e.Attributes[`vdev_path`]}
+	c.counts[key]++
+	t := e.Time
+	if t.IsZero() {
+		t = time.Now()
+	}
+	if t.After(c.last[e.Class]) {
+		c.last[e.Class] = t
+	}
+}
+
+func newEventsCollector(l *slog.Logger, c zfs.Client, props []string) (Collector, error) {
+	return &eventsCollector{
+		log:    l,
+		client: c,
+		counts: make(map[eventKey]uint64),
+		last:   make(map[string]time.Time),
+	}, nil
+}
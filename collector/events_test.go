@@ -0,0 +1,102 @@
+package collector
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/waitingsong/zfs_exporter/v3/zfs"
+	"github.com/waitingsong/zfs_exporter/v3/zfs/mock_zfs"
+)
+
+func TestEventsMetrics(t *testing.T) {
+	const result = `# HELP zfs_events_dropped_total Number of ZFS events missed by the exporter, as they left the kernel queue before being read (e.g. on 'zpool events -c').
+# TYPE zfs_events_dropped_total counter
+zfs_events_dropped_total 3
+# HELP zfs_events_last_timestamp_seconds Unix timestamp of the last ZFS event of the class.
+# TYPE zfs_events_last_timestamp_seconds gauge
+zfs_events_last_timestamp_seconds{class="ereport.fs.zfs.checksum"} 1.7185044525e+09
+zfs_events_last_timestamp_seconds{class="sysevent.fs.zfs.history_event"} 1.7185042e+09
+zfs_events_last_timestamp_seconds{class="sysevent.fs.zfs.pool_import"} 1.7185041e+09
+# HELP zfs_events_total Number of ZFS events posted while followed by the exporter, or queued by the kernel when first followed, by class, pool and vdev path. Events unrelated to a pool or vdev have an empty label.
+# TYPE zfs_events_total counter
+zfs_events_total{class="ereport.fs.zfs.checksum",pool="tank",vdev="/dev/sda1"} 2
+zfs_events_total{class="sysevent.fs.zfs.history_event",pool="tank",vdev=""} 1
+zfs_events_total{class="sysevent.fs.zfs.history_event",pool="",vdev=""} 1
+`
+
+	ctrl, ctx := gomock.WithContext(context.Background(), t)
+	zfsClient := mock_zfs.NewMockClient(ctrl)
+	zfsClient.EXPECT().PoolNames().Return([]string{`tank`}, nil).Times(1)
+	checksum := func(eid uint64, nsec int64) zfs.Event {
+		return zfs.Event{EID: eid, Class: `ereport.fs.zfs.checksum`, Time: time.Unix(1718504452, nsec), Attributes: map[string]string{`pool`: `tank`, `vdev_path`: `/dev/sda1`}}
+	}
+	// Events already counted are replayed after the queue was cleared, and events of other pools are not reported.
+	ready := make(chan struct{})
+	zfsClient.EXPECT().Events(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, h zfs.EventHandler) error {
+			h(zfs.Event{EID: 27, Class: `sysevent.fs.zfs.pool_import`, Time: time.Unix(1718504100, 0), Attributes: map[string]string{`pool`: `backup`}})
+			h(zfs.Event{EID: 28, Class: `sysevent.fs.zfs.history_event`, Time: time.Unix(1718504200, 0), Attributes: map[string]string{`pool`: `tank`}})
+			h(checksum(29, 0))
+			h(zfs.Event{Dropped: 3})
+			h(checksum(29, 0))
+			h(checksum(33, 500000000))
+			h(zfs.Event{EID: 34, Class: `sysevent.fs.zfs.history_event`, Time: time.Unix(1718504000, 0), Attributes: map[string]string{}})
+			close(ready)
+			<-ctx.Done()
+			return ctx.Err()
+		}).Times(1)
+
+	events, err := newEventsCollector(logger, zfsClient, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	events.(*eventsCollector).follow()
+	defer events.(*eventsCollector).follower.stop()
+	<-ready
+
+	collector := newTestZFS(t, zfsClient, `events`, ``, func(l *slog.Logger, c zfs.Client, props []string) (Collector, error) {
+		return events, nil
+	})
+
+	metricNames := []string{
+		`zfs_events_dropped_total`,
+		`zfs_events_last_timestamp_seconds`,
+		`zfs_events_total`,
+	}
+	collectAndCompare(t, ctx, collector, result, metricNames)
+}
+
+func TestEventsReset(t *testing.T) {
+	c, err := newEventsCollector(logger, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	events := c.(*eventsCollector)
+	event := func(eid uint64, sec int64) zfs.Event {
+		return zfs.Event{EID: eid, Class: `sysevent.fs.zfs.history_event`, Time: time.Unix(sec, 0), Attributes: map[string]string{`pool`: `tank`}}
+	}
+	runs := [][]zfs.Event{
+		{event(1, 1718504100), event(2, 1718504200)},
+		// A restarted run replays the events still queued, which are skipped.
+		{event(1, 1718504100), event(2, 1718504200), event(3, 1718504300)},
+		// EIDs restart once the module is reloaded.
+		{event(1, 1718505000), event(2, 1718505100)},
+	}
+	for _, run := range runs {
+		events.newRun = true
+		for _, e := range run {
+			events.handle(e)
+		}
+	}
+
+	key := eventKey{class: `sysevent.fs.zfs.history_event`, pool: `tank`}
+	if got := events.counts[key]; got != 5 {
+		t.Errorf(`got %d events, want 5`, got)
+	}
+	if events.lastEID != 2 {
+		t.Errorf(`got last EID %d, want 2`, events.lastEID)
+	}
+}
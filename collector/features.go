@@ -0,0 +1,100 @@
+package collector
+
+import (
+	"log/slog"
+	"slices"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/waitingsong/zfs_exporter/v3/zfs"
+)
+
+const (
+	featurePrefix = `feature@`
+)
+
+var (
+	featureStateMetric = newProperty(
+		subsystemPool,
+		`feature_state`,
+		`State of the feature flag of the pool [0: disabled, 1: enabled, 2: active].`,
+		transformNumeric,
+		`pool`, `feature`,
+	)
+	featurePendingMetric = newProperty(
+		subsystemPool,
+		`features_pending_upgrade`,
+		`Number of features supported by the installed OpenZFS version which are disabled on the pool, and would be enabled by 'zpool upgrade', i.e. excluding those not allowed by the compatibility property. Not reported if the feature set files of the compatibility property cannot be read.`,
+		transformNumeric,
+		poolLabels...,
+	)
+	featureCompatibilityMetric = newProperty(
+		subsystemPool,
+		`compatibility_info`,
+		`The compatibility property of the pool, restricting the features which may be enabled [off, legacy, or a list of feature set files].`,
+		transformNumeric,
+		`pool`, `compatibility`,
+	)
+)
+
+func init() {
+	registerCollector(`features`, defaultDisabled, ``, newFeaturesCollector)
+}
+
+// featuresCollector reports the feature flags of pools, e.g. to audit pools pending an upgrade
+type featuresCollector struct {
+	log    *slog.Logger
+	client zfs.Client
+}
+
+func (c *featuresCollector) describe(ch chan<- *prometheus.Desc) {
+	ch <- featureStateMetric.desc
+	ch <- featurePendingMetric.desc
+	ch <- featureCompatibilityMetric.desc
+}
+
+func (c *featuresCollector) update(ch chan<- metric, pools []string, excludes regexpCollection) error {
+	for _, pool := range pools {
+		props, err := c.client.Pool(pool).Properties(`all`)
+		if err != nil {
+			return err
+		}
+
+		values := props.Properties()
+		// The compatibility property was introduced in OpenZFS 2.1.
+		compatibility, hasCompatibility := values[`compatibility`]
+		if hasCompatibility {
+			featureCompatibilityMetric.pushValue(ch, 1, pool, compatibility)
+		}
+		allowed, compatErr := c.client.CompatibilityFeatures(compatibility)
+		if compatErr != nil {
+			c.log.Warn("Skipping pending upgrades of pool with unreadable compatibility feature sets", "pool", pool, "compatibility", compatibility, "err", compatErr)
+		}
+
+		var pending float64
+		for k, v := range values {
+			feature, ok := strings.CutPrefix(k, featurePrefix)
+			if !ok {
+				continue
+			}
+			state, err := transformFeatureState(v)
+			if err != nil {
+				return err
+			}
+			// Nil allowed features are unrestricted.
+			if state == float64(featureDisabled) && (allowed == nil || slices.Contains(allowed, feature)) {
+				pending++
+			}
+			featureStateMetric.pushValue(ch, state, pool, feature)
+		}
+		if compatErr == nil {
+			featurePendingMetric.pushValue(ch, pending, pool)
+		}
+	}
+
+	return nil
+}
+
+func newFeaturesCollector(l *slog.Logger, c zfs.Client, props []string) (Collector, error) {
+	return &featuresCollector{log: l, client: c}, nil
+}
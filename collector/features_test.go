@@ -0,0 +1,60 @@
+package collector
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/waitingsong/zfs_exporter/v3/zfs/mock_zfs"
+)
+
+func TestFeaturesMetrics(t *testing.T) {
+	const result = `# HELP zfs_pool_compatibility_info The compatibility property of the pool, restricting the features which may be enabled [off, legacy, or a list of feature set files].
+# TYPE zfs_pool_compatibility_info gauge
+zfs_pool_compatibility_info{compatibility="missing",pool="backup"} 1
+zfs_pool_compatibility_info{compatibility="openzfs-2.1-linux",pool="tank"} 1
+# HELP zfs_pool_feature_state State of the feature flag of the pool [0: disabled, 1: enabled, 2: active].
+# TYPE zfs_pool_feature_state gauge
+zfs_pool_feature_state{feature="async_destroy",pool="backup"} 0
+zfs_pool_feature_state{feature="async_destroy",pool="rpool"} 1
+zfs_pool_feature_state{feature="async_destroy",pool="tank"} 1
+zfs_pool_feature_state{feature="block_cloning",pool="rpool"} 0
+zfs_pool_feature_state{feature="block_cloning",pool="tank"} 0
+zfs_pool_feature_state{feature="device_removal",pool="rpool"} 2
+zfs_pool_feature_state{feature="device_removal",pool="tank"} 0
+# HELP zfs_pool_features_pending_upgrade Number of features supported by the installed OpenZFS version which are disabled on the pool, and would be enabled by 'zpool upgrade', i.e. excluding those not allowed by the compatibility property. Not reported if the feature set files of the compatibility property cannot be read.
+# TYPE zfs_pool_features_pending_upgrade gauge
+zfs_pool_features_pending_upgrade{pool="rpool"} 1
+zfs_pool_features_pending_upgrade{pool="tank"} 1
+`
+
+	ctrl, ctx := gomock.WithContext(context.Background(), t)
+	zfsClient := mock_zfs.NewMockClient(ctrl)
+	zfsClient.EXPECT().PoolNames().Return([]string{`backup`, `rpool`, `tank`}, nil).Times(1)
+	// Pools created before OpenZFS 2.1 lack the compatibility property.
+	for pool, props := range map[string]map[string]string{
+		`backup`: {`size`: `512`, `compatibility`: `missing`, `feature@async_destroy`: `disabled`},
+		`rpool`:  {`size`: `1024`, `feature@async_destroy`: `enabled`, `feature@block_cloning`: `disabled`, `feature@device_removal`: `active`},
+		`tank`:   {`size`: `2048`, `compatibility`: `openzfs-2.1-linux`, `feature@async_destroy`: `enabled`, `feature@block_cloning`: `disabled`, `feature@device_removal`: `disabled`},
+	} {
+		zfsPoolProperties := mock_zfs.NewMockPoolProperties(ctrl)
+		zfsPoolProperties.EXPECT().Properties().Return(props).Times(1)
+		zfsPool := mock_zfs.NewMockPool(ctrl)
+		zfsPool.EXPECT().Properties(`all`).Return(zfsPoolProperties, nil).Times(1)
+		zfsClient.EXPECT().Pool(pool).Return(zfsPool).Times(1)
+	}
+	zfsClient.EXPECT().CompatibilityFeatures(``).Return(nil, nil).Times(1)
+	zfsClient.EXPECT().CompatibilityFeatures(`missing`).Return(nil, os.ErrNotExist).Times(1)
+	// Block cloning was introduced in OpenZFS 2.2, and is not pending for pools restricted to 2.1.
+	zfsClient.EXPECT().CompatibilityFeatures(`openzfs-2.1-linux`).Return([]string{`async_destroy`, `device_removal`}, nil).Times(1)
+
+	collector := newTestZFS(t, zfsClient, `features`, ``, newFeaturesCollector)
+
+	metricNames := []string{
+		`zfs_pool_compatibility_info`,
+		`zfs_pool_feature_state`,
+		`zfs_pool_features_pending_upgrade`,
+	}
+	collectAndCompare(t, ctx, collector, result, metricNames)
+}
@@ -0,0 +1,56 @@
+package collector
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/jpillora/backoff"
+)
+
+const (
+	followerMinDelay = time.Second
+	followerMaxDelay = 5 * time.Minute
+)
+
+// follower supervises a long-running function, such as a command following the state of a pool, restarting it with
+// exponential backoff whenever it exits, until stopped
+type follower struct {
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// stop cancels the function, and waits for it to return
+func (f *follower) stop() {
+	f.cancel()
+	<-f.done
+}
+
+func startFollower(l *slog.Logger, name string, run func(ctx context.Context) error) *follower {
+	ctx, cancel := context.WithCancel(context.Background())
+	f := &follower{cancel: cancel, done: make(chan struct{})}
+	go func() {
+		defer close(f.done)
+		b := &backoff.Backoff{Min: followerMinDelay, Max: followerMaxDelay, Factor: 2, Jitter: true}
+		for {
+			begin := time.Now()
+			err := run(ctx)
+			if ctx.Err() != nil {
+				return
+			}
+			// A run outlasting the maximum delay was healthy, so restart promptly.
+			if time.Since(begin) > followerMaxDelay {
+				b.Reset()
+			}
+			delay := b.Duration()
+			l.Warn("Follower exited, restarting", "follower", name, "delay", delay, "err", err)
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(delay):
+			}
+		}
+	}()
+
+	return f
+}
@@ -0,0 +1,137 @@
+package collector
+
+import (
+	"math"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// nativeSchema is the resolution of native histograms, whose bucket boundaries are powers of two
+const nativeSchema = 0
+
+// histogram accumulates observations into buckets across runs, to be reported as a constant histogram
+type histogram struct {
+	upperBounds []float64
+	counts      []uint64
+	count       uint64
+	sum         float64
+}
+
+func (h *histogram) observe(v float64) {
+	for i, upper := range h.upperBounds {
+		if v <= upper {
+			h.counts[i]++
+			break
+		}
+	}
+	h.count++
+	h.sum += v
+}
+
+func (h *histogram) metric(desc *prometheus.Desc, labelValues ...string) prometheus.Metric {
+	buckets := make(map[float64]uint64, len(h.upperBounds))
+	var cumulative uint64
+	for i, upper := range h.upperBounds {
+		cumulative += h.counts[i]
+		buckets[upper] = cumulative
+	}
+
+	return prometheus.MustNewConstHistogram(desc, h.count, h.sum, buckets, labelValues...)
+}
+
+// nativeMetric returns the histogram as a constant histogram which also carries native buckets, for scrapes negotiating
+// the protobuf format. Each bucket is assigned to the native bucket holding its upper bound, and observations above
+// the last upper bound to the native bucket following it.
+func (h *histogram) nativeMetric(desc *prometheus.Desc, labelValues ...string) prometheus.Metric {
+	buckets := make(map[int]int64, len(h.upperBounds)+1)
+	var zero, cumulative uint64
+	index := 0
+	for i, upper := range h.upperBounds {
+		cumulative += h.counts[i]
+		if upper <= 0 {
+			zero += h.counts[i]
+			continue
+		}
+		index = nativeIndex(upper)
+		buckets[index] += int64(h.counts[i])
+	}
+	if h.count > cumulative {
+		buckets[index+1] += int64(h.count - cumulative)
+	}
+
+	return nativeHistogram{
+		Metric: h.metric(desc, labelValues...),
+		native: prometheus.MustNewConstNativeHistogram(desc, h.count, h.sum, buckets, nil, zero, nativeSchema, 0, time.Time{}, labelValues...),
+	}
+}
+
+// nativeIndex returns the index of the native bucket holding v, where bucket i holds values in (2^(i-1), 2^i]
+func nativeIndex(v float64) int {
+	frac, exp := math.Frexp(v)
+	if frac == 0.5 {
+		return exp - 1
+	}
+
+	return exp
+}
+
+// nativeHistogram merges the buckets of a native histogram into a classic histogram
+type nativeHistogram struct {
+	prometheus.Metric
+	native prometheus.Metric
+}
+
+func (m nativeHistogram) Write(out *dto.Metric) error {
+	if err := m.Metric.Write(out); err != nil {
+		return err
+	}
+	native := &dto.Metric{}
+	if err := m.native.Write(native); err != nil {
+		return err
+	}
+	out.Histogram.Schema = native.Histogram.Schema
+	out.Histogram.ZeroThreshold = native.Histogram.ZeroThreshold
+	out.Histogram.ZeroCount = native.Histogram.ZeroCount
+	out.Histogram.PositiveSpan = native.Histogram.PositiveSpan
+	out.Histogram.PositiveDelta = native.Histogram.PositiveDelta
+
+	return nil
+}
+
+func newHistogram(upperBounds []float64) *histogram {
+	return &histogram{
+		upperBounds: upperBounds,
+		counts:      make([]uint64, len(upperBounds)),
+	}
+}
+
+// histogramMetric describes a histogram, along with its name for caching
+type histogramMetric struct {
+	name string
+	desc *prometheus.Desc
+}
+
+func (m histogramMetric) push(ch chan<- metric, h *histogram, labelValues ...string) {
+	ch <- metric{
+		name:       expandMetricName(m.name, labelValues...),
+		prometheus: h.metric(m.desc, labelValues...),
+	}
+}
+
+// pushNative is like push, additionally carrying native buckets
+func (m histogramMetric) pushNative(ch chan<- metric, h *histogram, labelValues ...string) {
+	ch <- metric{
+		name:       expandMetricName(m.name, labelValues...),
+		prometheus: h.nativeMetric(m.desc, labelValues...),
+	}
+}
+
+func newHistogramMetric(subsystem, metricName, helpText string, labels ...string) histogramMetric {
+	name := prometheus.BuildFQName(namespace, subsystem, metricName)
+	return histogramMetric{
+		name: name,
+		desc: prometheus.NewDesc(name, helpText, labels, nil),
+	}
+}
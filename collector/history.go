@@ -0,0 +1,141 @@
+package collector
+
+import (
+	"log/slog"
+	"path"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/waitingsong/zfs_exporter/v3/zfs"
+)
+
+const (
+	defaultHistoryInterval = 5 * time.Minute
+
+	historyEventScrub   = `scrub_start`
+	historyEventImport  = `import`
+	historyEventDestroy = `destroy`
+)
+
+var (
+	historyCommands = newProperty(
+		subsystemPool,
+		`history_commands_total`,
+		`Number of zfs and zpool commands recorded in the pool history, by command (e.g. 'zpool upgrade') and the host they ran on.`,
+		transformNumeric,
+		`pool`, `command`, `host`,
+	).withValueType(prometheus.CounterValue)
+	historyLastEvent = newProperty(
+		subsystemPool,
+		`history_last_timestamp_seconds`,
+		`Unix timestamp of the last event of the pool history [scrub_start: start of a scrub, import: pool import, destroy: 'zfs destroy' command], only reported once recorded.`,
+		transformNumeric,
+		`pool`, `event`,
+	)
+)
+
+func init() {
+	registerCollector(`history`, defaultDisabled, ``, newHistoryCollector, withInterval(defaultHistoryInterval))
+}
+
+// historyCommandKey identifies the counter of a command
+type historyCommandKey struct {
+	command string
+	host    string
+}
+
+// poolHistory accumulates the records of the history of a pool across runs
+type poolHistory struct {
+	cursor   zfs.HistoryCursor
+	commands map[historyCommandKey]uint64
+	last     map[string]time.Time
+}
+
+func newPoolHistory() *poolHistory {
+	return &poolHistory{
+		commands: make(map[historyCommandKey]uint64),
+		last:     make(map[string]time.Time),
+	}
+}
+
+// handle accumulates a record of the pool history
+func (h *poolHistory) handle(r zfs.HistoryRecord) {
+	if r.Command != `` {
+		command := historyCommand(r.Command)
+		h.commands[historyCommandKey{command: command, host: r.Host}]++
+		if command == `zfs destroy` {
+			h.last[historyEventDestroy] = r.Time
+		}
+		return
+	}
+	switch {
+	case r.Event == `scan setup` && strings.Contains(r.Detail, `func=1`):
+		h.last[historyEventScrub] = r.Time
+	case r.Event == `import`:
+		h.last[historyEventImport] = r.Time
+	}
+}
+
+// historyCommand returns the program and subcommand of a command line, e.g. `zpool upgrade` for `zpool upgrade tank`
+func historyCommand(commandLine string) string {
+	fields := strings.Fields(commandLine)
+	if len(fields) == 0 {
+		return ``
+	}
+	fields[0] = path.Base(fields[0])
+	if len(fields) == 1 {
+		return fields[0]
+	}
+
+	return fields[0] + ` ` + fields[1]
+}
+
+// historyCollector reads the history of each pool incrementally, only handling the records added since the last run.
+// The history is read in full upon the first run, so that counters reflect the commands still recorded.
+type historyCollector struct {
+	log     *slog.Logger
+	client  zfs.Client
+	history map[string]*poolHistory
+}
+
+func (c *historyCollector) describe(ch chan<- *prometheus.Desc) {
+	ch <- historyCommands.desc
+	ch <- historyLastEvent.desc
+}
+
+func (c *historyCollector) update(ch chan<- metric, pools []string, excludes regexpCollection) error {
+	for _, pool := range pools {
+		history, ok := c.history[pool]
+		if !ok {
+			history = newPoolHistory()
+			c.history[pool] = history
+		}
+		// The cursor covers the records handled before any failure, which must not be handled again.
+		cursor, err := c.client.Pool(pool).History(history.cursor, history.handle)
+		history.cursor = cursor
+		if err != nil {
+			return err
+		}
+
+		for key, count := range history.commands {
+			historyCommands.pushValue(ch, float64(count), pool, key.command, key.host)
+		}
+		for event, t := range history.last {
+			historyLastEvent.pushValue(ch, float64(t.Unix()), pool, event)
+		}
+	}
+	// Forget pools which are gone, they are read in full should they return.
+	for pool := range c.history {
+		if !slices.Contains(pools, pool) {
+			delete(c.history, pool)
+		}
+	}
+
+	return nil
+}
+
+func newHistoryCollector(l *slog.Logger, c zfs.Client, props []string) (Collector, error) {
+	return &historyCollector{log: l, client: c, history: make(map[string]*poolHistory)}, nil
+}
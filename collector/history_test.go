@@ -0,0 +1,80 @@
+package collector
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/waitingsong/zfs_exporter/v3/zfs"
+	"github.com/waitingsong/zfs_exporter/v3/zfs/mock_zfs"
+)
+
+func TestHistoryMetrics(t *testing.T) {
+	const first = `# HELP zfs_pool_history_commands_total Number of zfs and zpool commands recorded in the pool history, by command (e.g. 'zpool upgrade') and the host they ran on.
+# TYPE zfs_pool_history_commands_total counter
+zfs_pool_history_commands_total{command="zpool create",host="nas",pool="tank"} 1
+zfs_pool_history_commands_total{command="zpool scrub",host="nas",pool="tank"} 1
+# HELP zfs_pool_history_last_timestamp_seconds Unix timestamp of the last event of the pool history [scrub_start: start of a scrub, import: pool import, destroy: 'zfs destroy' command], only reported once recorded.
+# TYPE zfs_pool_history_last_timestamp_seconds gauge
+zfs_pool_history_last_timestamp_seconds{event="scrub_start",pool="tank"} 1.717892641e+09
+`
+	const second = `# HELP zfs_pool_history_commands_total Number of zfs and zpool commands recorded in the pool history, by command (e.g. 'zpool upgrade') and the host they ran on.
+# TYPE zfs_pool_history_commands_total counter
+zfs_pool_history_commands_total{command="zfs destroy",host="backup",pool="tank"} 1
+zfs_pool_history_commands_total{command="zpool create",host="nas",pool="tank"} 1
+zfs_pool_history_commands_total{command="zpool import",host="backup",pool="tank"} 1
+zfs_pool_history_commands_total{command="zpool scrub",host="nas",pool="tank"} 1
+zfs_pool_history_commands_total{command="zpool upgrade",host="backup",pool="tank"} 1
+# HELP zfs_pool_history_last_timestamp_seconds Unix timestamp of the last event of the pool history [scrub_start: start of a scrub, import: pool import, destroy: 'zfs destroy' command], only reported once recorded.
+# TYPE zfs_pool_history_last_timestamp_seconds gauge
+zfs_pool_history_last_timestamp_seconds{event="destroy",pool="tank"} 1.71853572e+09
+zfs_pool_history_last_timestamp_seconds{event="import",pool="tank"} 1.7184744e+09
+zfs_pool_history_last_timestamp_seconds{event="scrub_start",pool="tank"} 1.717892641e+09
+`
+
+	ctrl, ctx := gomock.WithContext(context.Background(), t)
+	zfsClient := mock_zfs.NewMockClient(ctrl)
+	zfsClient.EXPECT().PoolNames().Return([]string{`tank`}, nil).Times(2)
+	zfsPool := mock_zfs.NewMockPool(ctrl)
+	zfsClient.EXPECT().Pool(`tank`).Return(zfsPool).Times(2)
+	scrubbed := zfs.HistoryCursor{Time: time.Unix(1717892641, 0), Records: 2}
+	gomock.InOrder(
+		zfsPool.EXPECT().History(zfs.HistoryCursor{}, gomock.Any()).DoAndReturn(
+			func(cursor zfs.HistoryCursor, h zfs.HistoryHandler) (zfs.HistoryCursor, error) {
+				h(zfs.HistoryRecord{Time: time.Unix(1717232400, 0), Event: `create`, Detail: `pool version 5000`, Host: `nas`})
+				h(zfs.HistoryRecord{Time: time.Unix(1717232400, 0), Command: `zpool create tank mirror /dev/sda /dev/sdb`, User: `root`, Host: `nas`})
+				h(zfs.HistoryRecord{Time: time.Unix(1717892641, 0), Event: `scan setup`, Detail: `tank (0) func=1 mintxg=0 maxtxg=8955`, Host: `nas`})
+				h(zfs.HistoryRecord{Time: time.Unix(1717892641, 0), Command: `zpool scrub tank`, User: `root`, Host: `nas`})
+				return scrubbed, nil
+			}).Times(1),
+		// Only the records following the cursor are handled by the next run.
+		zfsPool.EXPECT().History(scrubbed, gomock.Any()).DoAndReturn(
+			func(cursor zfs.HistoryCursor, h zfs.HistoryHandler) (zfs.HistoryCursor, error) {
+				h(zfs.HistoryRecord{Time: time.Unix(1718474400, 0), Event: `import`, Detail: `pool version 5000`, Host: `backup`})
+				h(zfs.HistoryRecord{Time: time.Unix(1718474400, 0), Command: `zpool import -N tank`, User: `root`, Host: `backup`})
+				// Resilvers start a scan too, but are not scrubs.
+				h(zfs.HistoryRecord{Time: time.Unix(1718500000, 0), Event: `scan setup`, Detail: `tank (0) func=2 mintxg=3 maxtxg=9805`, Host: `backup`})
+				h(zfs.HistoryRecord{Time: time.Unix(1718535720, 0), Command: `/usr/sbin/zfs destroy -r tank/old`, User: `alice`, Host: `backup`})
+				h(zfs.HistoryRecord{Time: time.Unix(1718535900, 0), Command: `zpool upgrade tank`, User: `root`, Host: `backup`})
+				return zfs.HistoryCursor{Time: time.Unix(1718535900, 0), Records: 1}, nil
+			}).Times(1),
+	)
+
+	history, err := newHistoryCollector(logger, zfsClient, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	collector := newTestZFS(t, zfsClient, `history`, ``, func(l *slog.Logger, c zfs.Client, props []string) (Collector, error) {
+		return history, nil
+	})
+
+	metricNames := []string{
+		`zfs_pool_history_commands_total`,
+		`zfs_pool_history_last_timestamp_seconds`,
+	}
+	for _, result := range []string{first, second} {
+		collectAndCompare(t, ctx, collector, result, metricNames)
+	}
+}
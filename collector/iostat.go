@@ -0,0 +1,258 @@
+package collector
+
+import (
+	"context"
+	"log/slog"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/alecthomas/kingpin/v2"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/waitingsong/zfs_exporter/v3/zfs"
+)
+
+const (
+	// iostatStaleIntervals is the number of missed reports after which a vdev is considered removed
+	iostatStaleIntervals = 3
+)
+
+var (
+	iostatInterval = kingpin.Flag(`collector.iostat.sample-interval`, `Reporting interval of the long-running 'zpool iostat' process followed by the iostat collector (default: 10s)`).Default(`10s`).Duration()
+
+	vdevLabels      = []string{`pool`, `vdev`}
+	vdevClassLabels = []string{`pool`, `vdev`, `class`, `op`}
+
+	// iostatCounters maps the per-second rates of `zpool iostat` to counters, accumulated over each interval
+	iostatCounters = []iostatCounter{
+		newIostatCounter(`read_ops`, `reads_total`, `Number of read operations issued to the vdev, while followed by the exporter.`),
+		newIostatCounter(`write_ops`, `writes_total`, `Number of write operations issued to the vdev, while followed by the exporter.`),
+		newIostatCounter(`read_bytes`, `read_bytes_total`, `Amount of data in bytes read from the vdev, while followed by the exporter.`),
+		newIostatCounter(`write_bytes`, `written_bytes_total`, `Amount of data in bytes written to the vdev, while followed by the exporter.`),
+	}
+
+	// iostatWaits maps the average wait times of `zpool iostat -l` to their I/O class and operation
+	iostatWaits = []struct {
+		column string
+		class  string
+		op     string
+	}{
+		{column: `total_wait_read`, class: `total`, op: `read`},
+		{column: `total_wait_write`, class: `total`, op: `write`},
+		{column: `disk_wait_read`, class: `disk`, op: `read`},
+		{column: `disk_wait_write`, class: `disk`, op: `write`},
+		{column: `syncq_wait_read`, class: `sync`, op: `read`},
+		{column: `syncq_wait_write`, class: `sync`, op: `write`},
+		{column: `asyncq_wait_read`, class: `async`, op: `read`},
+		{column: `asyncq_wait_write`, class: `async`, op: `write`},
+		{column: `scrub_wait`, class: `scrub`, op: `read`},
+		{column: `trim_wait`, class: `trim`, op: `write`},
+		{column: `rebuild_wait`, class: `rebuild`, op: `write`},
+	}
+
+	// iostatQueues maps the queues of `zpool iostat -q` to their I/O class and operation, each reported with
+	// `_pend` and `_activ` columns
+	iostatQueues = []struct {
+		column string
+		class  string
+		op     string
+	}{
+		{column: `syncq_read`, class: `sync`, op: `read`},
+		{column: `syncq_write`, class: `sync`, op: `write`},
+		{column: `asyncq_read`, class: `async`, op: `read`},
+		{column: `asyncq_write`, class: `async`, op: `write`},
+		{column: `scrubq_read`, class: `scrub`, op: `read`},
+		{column: `trimq_write`, class: `trim`, op: `write`},
+		{column: `rebuildq_write`, class: `rebuild`, op: `write`},
+	}
+
+	iostatWaitDescName = prometheus.BuildFQName(namespace, subsystemVdev, `wait_seconds`)
+	iostatWaitDesc     = prometheus.NewDesc(
+		iostatWaitDescName,
+		`Average wait time in seconds of I/O to the vdev over the last interval, by I/O class [total: queued and on disk, disk: on disk, sync, async, scrub, trim, rebuild: queued].`,
+		vdevClassLabels,
+		nil,
+	)
+	iostatPendingDescName = prometheus.BuildFQName(namespace, subsystemVdev, `queue_pending`)
+	iostatPendingDesc     = prometheus.NewDesc(
+		iostatPendingDescName,
+		`Number of I/O operations queued for the vdev, by I/O class.`,
+		vdevClassLabels,
+		nil,
+	)
+	iostatActiveDescName = prometheus.BuildFQName(namespace, subsystemVdev, `queue_active`)
+	iostatActiveDesc     = prometheus.NewDesc(
+		iostatActiveDescName,
+		`Number of I/O operations issued to the vdev and not yet completed, by I/O class.`,
+		vdevClassLabels,
+		nil,
+	)
+)
+
+func init() {
+	registerCollector(`iostat`, defaultDisabled, ``, newIostatCollector)
+}
+
+// iostatCounter accumulates a per-second rate column of `zpool iostat` into a counter
+type iostatCounter struct {
+	column string
+	name   string
+	desc   *prometheus.Desc
+}
+
+func newIostatCounter(column, metricName, helpText string) iostatCounter {
+	name := prometheus.BuildFQName(namespace, subsystemVdev, metricName)
+	return iostatCounter{column: column, name: name, desc: prometheus.NewDesc(name, helpText, vdevLabels, nil)}
+}
+
+// iostatVdev holds the accumulated statistics of a pool or vdev
+type iostatVdev struct {
+	totals []float64
+	row    map[string]string
+	seen   time.Time
+}
+
+// iostatCollector follows a long-running `zpool iostat` process per pool, as its rates are only meaningful over an
+// interval. The pool itself is reported as a vdev named after the pool.
+type iostatCollector struct {
+	log         *slog.Logger
+	client      zfs.Client
+	interval    time.Duration
+	followersMu sync.Mutex
+	followers   map[string]*follower
+	mu          sync.Mutex
+	vdevs       map[string]map[string]*iostatVdev
+}
+
+func (c *iostatCollector) describe(ch chan<- *prometheus.Desc) {
+	for _, counter := range iostatCounters {
+		ch <- counter.desc
+	}
+	ch <- iostatWaitDesc
+	ch <- iostatPendingDesc
+	ch <- iostatActiveDesc
+}
+
+func (c *iostatCollector) update(ch chan<- metric, pools []string, excludes regexpCollection) error {
+	c.follow(pools)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for pool, vdevs := range c.vdevs {
+		for name, v := range vdevs {
+			c.push(ch, pool, name, v)
+		}
+	}
+
+	return nil
+}
+
+// follow starts following newly found pools, and stops following pools which are gone
+func (c *iostatCollector) follow(pools []string) {
+	c.followersMu.Lock()
+	defer c.followersMu.Unlock()
+
+	current := make(map[string]struct{}, len(pools))
+	for _, pool := range pools {
+		current[pool] = struct{}{}
+		if _, ok := c.followers[pool]; ok {
+			continue
+		}
+		handler := c.handler(pool)
+		c.followers[pool] = startFollower(c.log, `iostat `+pool, func(ctx context.Context) error {
+			return c.client.Iostat(ctx, pool, c.interval, handler)
+		})
+	}
+	for pool, f := range c.followers {
+		if _, ok := current[pool]; ok {
+			continue
+		}
+		f.stop()
+		delete(c.followers, pool)
+		c.mu.Lock()
+		delete(c.vdevs, pool)
+		c.mu.Unlock()
+	}
+}
+
+// handler accumulates the rows of the pool, as they are reported. As `zpool iostat -y` reports the rates over
+// each interval, the rates multiplied by the interval add up to the operations and bytes since the process started.
+func (c *iostatCollector) handler(pool string) zfs.IostatHandler {
+	return func(row map[string]string) {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+
+		now := time.Now()
+		vdevs, ok := c.vdevs[pool]
+		if !ok {
+			vdevs = make(map[string]*iostatVdev)
+			c.vdevs[pool] = vdevs
+		}
+		name := row[`name`]
+		if name == pool {
+			// Each report starts with the pool, drop any vdevs which have stopped reporting.
+			for k, v := range vdevs {
+				if now.Sub(v.seen) > iostatStaleIntervals*c.interval {
+					delete(vdevs, k)
+				}
+			}
+		}
+		v, ok := vdevs[name]
+		if !ok {
+			v = &iostatVdev{totals: make([]float64, len(iostatCounters))}
+			vdevs[name] = v
+		}
+		v.row = row
+		v.seen = now
+		for i, counter := range iostatCounters {
+			rate, err := strconv.ParseFloat(row[counter.column], 64)
+			if err != nil {
+				continue
+			}
+			v.totals[i] += rate * c.interval.Seconds()
+		}
+	}
+}
+
+func (c *iostatCollector) push(ch chan<- metric, pool, name string, v *iostatVdev) {
+	for i, counter := range iostatCounters {
+		ch <- metric{
+			name:       expandMetricName(counter.name, pool, name),
+			prometheus: prometheus.MustNewConstMetric(counter.desc, prometheus.CounterValue, v.totals[i], pool, name),
+		}
+	}
+	for _, w := range iostatWaits {
+		ns, err := strconv.ParseFloat(v.row[w.column], 64)
+		if err != nil {
+			continue
+		}
+		ch <- metric{
+			name:       expandMetricName(iostatWaitDescName, pool, name, w.class, w.op),
+			prometheus: prometheus.MustNewConstMetric(iostatWaitDesc, prometheus.GaugeValue, ns/1e9, pool, name, w.class, w.op),
+		}
+	}
+	for _, q := range iostatQueues {
+		if pending, err := strconv.ParseFloat(v.row[q.column+`_pend`], 64); err == nil {
+			ch <- metric{
+				name:       expandMetricName(iostatPendingDescName, pool, name, q.class, q.op),
+				prometheus: prometheus.MustNewConstMetric(iostatPendingDesc, prometheus.GaugeValue, pending, pool, name, q.class, q.op),
+			}
+		}
+		if active, err := strconv.ParseFloat(v.row[q.column+`_activ`], 64); err == nil {
+			ch <- metric{
+				name:       expandMetricName(iostatActiveDescName, pool, name, q.class, q.op),
+				prometheus: prometheus.MustNewConstMetric(iostatActiveDesc, prometheus.GaugeValue, active, pool, name, q.class, q.op),
+			}
+		}
+	}
+}
+
+func newIostatCollector(l *slog.Logger, c zfs.Client, props []string) (Collector, error) {
+	return &iostatCollector{
+		log:       l,
+		client:    c,
+		interval:  *iostatInterval,
+		followers: make(map[string]*follower),
+		vdevs:     make(map[string]map[string]*iostatVdev),
+	}, nil
+}
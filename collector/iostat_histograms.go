@@ -0,0 +1,138 @@
+package collector
+
+import (
+	"log/slog"
+
+	"github.com/alecthomas/kingpin/v2"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/waitingsong/zfs_exporter/v3/zfs"
+)
+
+var (
+	iostatNativeHistograms = kingpin.Flag(`collector.iostat_histograms.native`, `Additionally expose the iostat_histograms collector histograms as native histograms, to scrapes negotiating the protobuf format (default: false)`).Default(`false`).Bool()
+
+	iostatLatencyMetric = newHistogramMetric(
+		subsystemVdev,
+		`latency_seconds`,
+		`Latency in seconds of I/O to the vdev since the pool was imported, by I/O class [total: queued and on disk, disk: on disk, sync, async, scrub, trim, rebuild: queued]. The sum is estimated from bucket upper bounds.`,
+		vdevClassLabels...,
+	)
+	iostatRequestSizeMetric = newHistogramMetric(
+		subsystemVdev,
+		`request_size_bytes`,
+		`Size in bytes of I/O requests to the vdev since the pool was imported, by I/O class and whether they were issued individually or aggregated [individual, aggregated]. The sum is estimated from bucket upper bounds.`,
+		`pool`, `vdev`, `class`, `op`, `type`,
+	)
+
+	// iostatRequestSizes maps the request size columns of `zpool iostat -r` to their I/O class and operation
+	iostatRequestSizes = []struct {
+		column string
+		class  string
+		op     string
+		kind   string
+	}{
+		{column: `sync_read_ind`, class: `sync`, op: `read`, kind: `individual`},
+		{column: `sync_read_agg`, class: `sync`, op: `read`, kind: `aggregated`},
+		{column: `sync_write_ind`, class: `sync`, op: `write`, kind: `individual`},
+		{column: `sync_write_agg`, class: `sync`, op: `write`, kind: `aggregated`},
+		{column: `async_read_ind`, class: `async`, op: `read`, kind: `individual`},
+		{column: `async_read_agg`, class: `async`, op: `read`, kind: `aggregated`},
+		{column: `async_write_ind`, class: `async`, op: `write`, kind: `individual`},
+		{column: `async_write_agg`, class: `async`, op: `write`, kind: `aggregated`},
+		{column: `scrub_ind`, class: `scrub`, op: `read`, kind: `individual`},
+		{column: `scrub_agg`, class: `scrub`, op: `read`, kind: `aggregated`},
+		{column: `trim_ind`, class: `trim`, op: `write`, kind: `individual`},
+		{column: `trim_agg`, class: `trim`, op: `write`, kind: `aggregated`},
+		{column: `rebuild_ind`, class: `rebuild`, op: `write`, kind: `individual`},
+		{column: `rebuild_agg`, class: `rebuild`, op: `write`, kind: `aggregated`},
+	}
+)
+
+func init() {
+	registerCollector(`iostat_histograms`, defaultDisabled, ``, newIostatHistogramsCollector)
+}
+
+type iostatHistogramsCollector struct {
+	log    *slog.Logger
+	client zfs.Client
+	native bool
+}
+
+func (c *iostatHistogramsCollector) describe(ch chan<- *prometheus.Desc) {
+	ch <- iostatLatencyMetric.desc
+	ch <- iostatRequestSizeMetric.desc
+}
+
+func (c *iostatHistogramsCollector) update(ch chan<- metric, pools []string, excludes regexpCollection) error {
+	for _, pool := range pools {
+		latencies, err := c.client.IostatHistograms(pool, zfs.IostatLatency)
+		if err != nil {
+			return err
+		}
+		for _, h := range latencies {
+			for _, w := range iostatWaits {
+				counts, ok := h.Counts[w.column]
+				if !ok {
+					continue
+				}
+				// Latency buckets are labelled with their upper bound in nanoseconds.
+				c.push(ch, iostatLatencyMetric, iostatBuckets(h.Labels, counts, func(label uint64) uint64 {
+					return label
+				}, 1e9), pool, h.Vdev, w.class, w.op)
+			}
+		}
+
+		sizes, err := c.client.IostatHistograms(pool, zfs.IostatRequestSize)
+		if err != nil {
+			return err
+		}
+		for _, h := range sizes {
+			for _, r := range iostatRequestSizes {
+				counts, ok := h.Counts[r.column]
+				if !ok {
+					continue
+				}
+				// Request size buckets are labelled with their lower bound, and hold sizes up to the next label.
+				c.push(ch, iostatRequestSizeMetric, iostatBuckets(h.Labels, counts, func(label uint64) uint64 {
+					return 2*label - 1
+				}, 1), pool, h.Vdev, r.class, r.op, r.kind)
+			}
+		}
+	}
+
+	return nil
+}
+
+func (c *iostatHistogramsCollector) push(ch chan<- metric, m histogramMetric, h *histogram, labelValues ...string) {
+	if c.native {
+		m.pushNative(ch, h, labelValues...)
+		return
+	}
+	m.push(ch, h, labelValues...)
+}
+
+// iostatBuckets converts the buckets of a `zpool iostat` histogram, given the upper bound of each bucket label, which
+// is divided by scale. The last bucket also counts anything larger, so it is only reported within the +Inf bucket.
+func iostatBuckets(labels []uint64, counts []uint64, upperBound func(label uint64) uint64, scale float64) *histogram {
+	upperBounds := make([]float64, 0, len(labels))
+	for _, label := range labels[:len(labels)-1] {
+		upperBounds = append(upperBounds, float64(upperBound(label))/scale)
+	}
+
+	h := newHistogram(upperBounds)
+	var sum uint64
+	for i, count := range counts {
+		if i < len(upperBounds) {
+			h.counts[i] = count
+		}
+		h.count += count
+		sum += count * upperBound(labels[i])
+	}
+	h.sum = float64(sum) / scale
+
+	return h
+}
+
+func newIostatHistogramsCollector(l *slog.Logger, c zfs.Client, props []string) (Collector, error) {
+	return &iostatHistogramsCollector{log: l, client: c, native: *iostatNativeHistograms}, nil
+}
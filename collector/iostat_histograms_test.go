@@ -0,0 +1,74 @@
+package collector
+
+import (
+	"context"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/waitingsong/zfs_exporter/v3/zfs"
+	"github.com/waitingsong/zfs_exporter/v3/zfs/mock_zfs"
+)
+
+func TestIostatHistogramsMetrics(t *testing.T) {
+	const result = `# HELP zfs_vdev_latency_seconds Latency in seconds of I/O to the vdev since the pool was imported, by I/O class [total: queued and on disk, disk: on disk, sync, async, scrub, trim, rebuild: queued]. The sum is estimated from bucket upper bounds.
+# TYPE zfs_vdev_latency_seconds histogram
+zfs_vdev_latency_seconds_bucket{class="total",op="read",pool="tank",vdev="sda",le="1.023e-06"} 2
+zfs_vdev_latency_seconds_bucket{class="total",op="read",pool="tank",vdev="sda",le="2.047e-06"} 5
+zfs_vdev_latency_seconds_bucket{class="total",op="read",pool="tank",vdev="sda",le="+Inf"} 6
+zfs_vdev_latency_seconds_sum{class="total",op="read",pool="tank",vdev="sda"} 1.2282e-05
+zfs_vdev_latency_seconds_count{class="total",op="read",pool="tank",vdev="sda"} 6
+# HELP zfs_vdev_request_size_bytes Size in bytes of I/O requests to the vdev since the pool was imported, by I/O class and whether they were issued individually or aggregated [individual, aggregated]. The sum is estimated from bucket upper bounds.
+# TYPE zfs_vdev_request_size_bytes histogram
+zfs_vdev_request_size_bytes_bucket{class="sync",op="read",pool="tank",type="individual",vdev="sda",le="1023"} 4
+zfs_vdev_request_size_bytes_bucket{class="sync",op="read",pool="tank",type="individual",vdev="sda",le="2047"} 4
+zfs_vdev_request_size_bytes_bucket{class="sync",op="read",pool="tank",type="individual",vdev="sda",le="+Inf"} 5
+zfs_vdev_request_size_bytes_sum{class="sync",op="read",pool="tank",type="individual",vdev="sda"} 8187
+zfs_vdev_request_size_bytes_count{class="sync",op="read",pool="tank",type="individual",vdev="sda"} 5
+`
+
+	ctrl, ctx := gomock.WithContext(context.Background(), t)
+	zfsClient := mock_zfs.NewMockClient(ctrl)
+	zfsClient.EXPECT().PoolNames().Return([]string{`tank`}, nil).Times(1)
+	zfsClient.EXPECT().IostatHistograms(`tank`, zfs.IostatLatency).Return([]zfs.IostatHistogram{
+		{Vdev: `sda`, Labels: []uint64{1023, 2047, 4095}, Counts: map[string][]uint64{`total_wait_read`: {2, 3, 1}}},
+	}, nil).Times(1)
+	zfsClient.EXPECT().IostatHistograms(`tank`, zfs.IostatRequestSize).Return([]zfs.IostatHistogram{
+		{Vdev: `sda`, Labels: []uint64{512, 1024, 2048}, Counts: map[string][]uint64{`sync_read_ind`: {4, 0, 1}}},
+	}, nil).Times(1)
+
+	collector := newTestZFS(t, zfsClient, `iostat_histograms`, ``, newIostatHistogramsCollector)
+
+	metricNames := []string{
+		`zfs_vdev_latency_seconds`,
+		`zfs_vdev_request_size_bytes`,
+	}
+	collectAndCompare(t, ctx, collector, result, metricNames)
+}
+
+func TestIostatHistogramsNative(t *testing.T) {
+	h := iostatBuckets([]uint64{512, 1024, 2048}, []uint64{4, 0, 1}, func(label uint64) uint64 {
+		return 2*label - 1
+	}, 1)
+	desc := prometheus.NewDesc(`test_bytes`, `Test histogram.`, nil, nil)
+	out := &dto.Metric{}
+	if err := h.nativeMetric(desc).Write(out); err != nil {
+		t.Fatal(err)
+	}
+
+	// Classic buckets are retained, along with native buckets from (512, 1024] up to the overflow in (2048, 4096].
+	if len(out.Histogram.Bucket) != 2 {
+		t.Errorf(`got %d classic buckets, want 2`, len(out.Histogram.Bucket))
+	}
+	if out.Histogram.GetSchema() != nativeSchema {
+		t.Errorf(`got schema %d, want %d`, out.Histogram.GetSchema(), nativeSchema)
+	}
+	spans := out.Histogram.GetPositiveSpan()
+	if len(spans) != 1 || spans[0].GetOffset() != 10 || spans[0].GetLength() != 3 {
+		t.Errorf(`got spans %v`, spans)
+	}
+	if deltas := out.Histogram.GetPositiveDelta(); len(deltas) != 3 || deltas[0] != 4 || deltas[1] != -4 || deltas[2] != 1 {
+		t.Errorf(`got deltas %v`, deltas)
+	}
+}
@@ -0,0 +1,88 @@
+package collector
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/waitingsong/zfs_exporter/v3/zfs"
+	"github.com/waitingsong/zfs_exporter/v3/zfs/mock_zfs"
+)
+
+func TestIostatMetrics(t *testing.T) {
+	const result = `# HELP zfs_vdev_queue_active Number of I/O operations issued to the vdev and not yet completed, by I/O class.
+# TYPE zfs_vdev_queue_active gauge
+zfs_vdev_queue_active{class="async",op="write",pool="tank",vdev="sda"} 5
+zfs_vdev_queue_active{class="async",op="write",pool="tank",vdev="tank"} 10
+# HELP zfs_vdev_queue_pending Number of I/O operations queued for the vdev, by I/O class.
+# TYPE zfs_vdev_queue_pending gauge
+zfs_vdev_queue_pending{class="async",op="write",pool="tank",vdev="sda"} 6
+zfs_vdev_queue_pending{class="async",op="write",pool="tank",vdev="tank"} 12
+# HELP zfs_vdev_read_bytes_total Amount of data in bytes read from the vdev, while followed by the exporter.
+# TYPE zfs_vdev_read_bytes_total counter
+zfs_vdev_read_bytes_total{pool="tank",vdev="sda"} 7.86432e+07
+zfs_vdev_read_bytes_total{pool="tank",vdev="tank"} 3.145728e+08
+# HELP zfs_vdev_reads_total Number of read operations issued to the vdev, while followed by the exporter.
+# TYPE zfs_vdev_reads_total counter
+zfs_vdev_reads_total{pool="tank",vdev="sda"} 600
+zfs_vdev_reads_total{pool="tank",vdev="tank"} 3600
+# HELP zfs_vdev_wait_seconds Average wait time in seconds of I/O to the vdev over the last interval, by I/O class [total: queued and on disk, disk: on disk, sync, async, scrub, trim, rebuild: queued].
+# TYPE zfs_vdev_wait_seconds gauge
+zfs_vdev_wait_seconds{class="disk",op="read",pool="tank",vdev="sda"} 0.003004114
+zfs_vdev_wait_seconds{class="disk",op="read",pool="tank",vdev="tank"} 0.003210311
+zfs_vdev_wait_seconds{class="total",op="read",pool="tank",vdev="sda"} 0.004512002
+zfs_vdev_wait_seconds{class="total",op="read",pool="tank",vdev="tank"} 0.004829104
+# HELP zfs_vdev_writes_total Number of write operations issued to the vdev, while followed by the exporter.
+# TYPE zfs_vdev_writes_total counter
+zfs_vdev_writes_total{pool="tank",vdev="sda"} 1500
+zfs_vdev_writes_total{pool="tank",vdev="tank"} 6200
+# HELP zfs_vdev_written_bytes_total Amount of data in bytes written to the vdev, while followed by the exporter.
+# TYPE zfs_vdev_written_bytes_total counter
+zfs_vdev_written_bytes_total{pool="tank",vdev="sda"} 2.0447232e+08
+zfs_vdev_written_bytes_total{pool="tank",vdev="tank"} 8.388608e+08
+`
+
+	ctrl, ctx := gomock.WithContext(context.Background(), t)
+	zfsClient := mock_zfs.NewMockClient(ctrl)
+	zfsClient.EXPECT().PoolNames().Return([]string{`tank`}, nil).Times(1)
+	// The pool is followed until stopped, each row being accumulated over the interval.
+	ready := make(chan struct{})
+	zfsClient.EXPECT().Iostat(gomock.Any(), `tank`, 10*time.Second, gomock.Any()).DoAndReturn(
+		func(ctx context.Context, pool string, interval time.Duration, h zfs.IostatHandler) error {
+			for _, rates := range []string{`120`, `240`} {
+				h(map[string]string{`name`: `tank`, `read_ops`: rates, `write_ops`: `310`, `read_bytes`: `15728640`, `write_bytes`: `41943040`, `total_wait_read`: `4829104`, `disk_wait_read`: `3210311`, `asyncq_write_pend`: `12`, `asyncq_write_activ`: `10`})
+			}
+			h(map[string]string{`name`: `sda`, `read_ops`: `60`, `write_ops`: `150`, `read_bytes`: `7864320`, `write_bytes`: `20447232`, `total_wait_read`: `4512002`, `disk_wait_read`: `3004114`, `asyncq_write_pend`: `6`, `asyncq_write_activ`: `5`})
+			close(ready)
+			<-ctx.Done()
+			return ctx.Err()
+		}).Times(1)
+
+	iostat := &iostatCollector{
+		log:       logger,
+		client:    zfsClient,
+		interval:  10 * time.Second,
+		followers: make(map[string]*follower),
+		vdevs:     make(map[string]map[string]*iostatVdev),
+	}
+	defer iostat.follow(nil)
+	iostat.follow([]string{`tank`})
+	<-ready
+
+	collector := newTestZFS(t, zfsClient, `iostat`, ``, func(l *slog.Logger, c zfs.Client, props []string) (Collector, error) {
+		return iostat, nil
+	})
+
+	metricNames := []string{
+		`zfs_vdev_queue_active`,
+		`zfs_vdev_queue_pending`,
+		`zfs_vdev_read_bytes_total`,
+		`zfs_vdev_reads_total`,
+		`zfs_vdev_wait_seconds`,
+		`zfs_vdev_writes_total`,
+		`zfs_vdev_written_bytes_total`,
+	}
+	collectAndCompare(t, ctx, collector, result, metricNames)
+}
@@ -0,0 +1,266 @@
+package collector
+
+import (
+	"errors"
+	"log/slog"
+	"os"
+	"sort"
+	"strconv"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/waitingsong/zfs_exporter/v3/zfs"
+)
+
+var (
+	multihostWritesDescName = prometheus.BuildFQName(namespace, subsystemMultihost, `writes_total`)
+	multihostWritesDesc     = prometheus.NewDesc(
+		multihostWritesDescName,
+		`Number of completed MMP uberblock writes, including failed writes.`,
+		poolLabels,
+		nil,
+	)
+	multihostSkippedDescName = prometheus.BuildFQName(namespace, subsystemMultihost, `skipped_writes_total`)
+	multihostSkippedDesc     = prometheus.NewDesc(
+		multihostSkippedDescName,
+		`Number of MMP uberblock writes skipped, as no leaf vdev was available to write to.`,
+		poolLabels,
+		nil,
+	)
+	multihostFailedDescName = prometheus.BuildFQName(namespace, subsystemMultihost, `failed_writes_total`)
+	multihostFailedDesc     = prometheus.NewDesc(
+		multihostFailedDescName,
+		`Number of MMP uberblock writes which failed with an I/O error.`,
+		poolLabels,
+		nil,
+	)
+	multihostLastWriteDescName = prometheus.BuildFQName(namespace, subsystemMultihost, `last_write_timestamp_seconds`)
+	multihostLastWriteDesc     = prometheus.NewDesc(
+		multihostLastWriteDescName,
+		`Unix timestamp of the last successful MMP uberblock write.`,
+		poolLabels,
+		nil,
+	)
+	multihostDelayDescName = prometheus.BuildFQName(namespace, subsystemMultihost, `delay_seconds`)
+	multihostDelayDesc     = prometheus.NewDesc(
+		multihostDelayDescName,
+		`Average interval in seconds between MMP uberblock writes, as of the last write.`,
+		poolLabels,
+		nil,
+	)
+	multihostInfoDescName = prometheus.BuildFQName(namespace, subsystemMultihost, `info`)
+	multihostInfoDesc     = prometheus.NewDesc(
+		multihostInfoDescName,
+		`The multihost property of the pool and the hostid of the system used to claim it, for pools with multihost enabled.`,
+		[]string{`pool`, `multihost`, `hostid`},
+		nil,
+	)
+	multihostWriteMetric = newHistogramMetric(subsystemMultihost, `write_duration_seconds`, `Duration in seconds of completed MMP uberblock writes.`, poolLabels...)
+	multihostBuckets     = prometheus.ExponentialBuckets(0.0001, 4, 10)
+)
+
+func init() {
+	registerCollector(`multihost`, defaultDisabled, ``, newMultihostCollector)
+}
+
+// multihostHistory accumulates MMP writes across runs, as the multihost kstat only holds recent history
+type multihostHistory struct {
+	lastID    uint64
+	writes    uint64
+	skipped   uint64
+	failed    uint64
+	lastWrite uint64
+	delay     float64
+	duration  *histogram
+}
+
+func newMultihostHistory() *multihostHistory {
+	return &multihostHistory{duration: newHistogram(multihostBuckets)}
+}
+
+// multihostWrite holds a parsed row of the multihost kstat
+type multihostWrite struct {
+	id        uint64
+	timestamp uint64
+	err       int64
+	duration  float64
+	delay     float64
+	vdevGUID  string
+}
+
+func parseMultihostWrite(row map[string]string) (multihostWrite, error) {
+	var (
+		w   = multihostWrite{vdevGUID: row[`vdev_guid`]}
+		err error
+	)
+	if w.id, err = strconv.ParseUint(row[`id`], 10, 64); err != nil {
+		return w, err
+	}
+	if w.timestamp, err = strconv.ParseUint(row[`timestamp`], 10, 64); err != nil {
+		return w, err
+	}
+	if w.err, err = strconv.ParseInt(row[`error`], 10, 64); err != nil {
+		return w, err
+	}
+	if w.duration, err = strconv.ParseFloat(row[`duration`], 64); err != nil {
+		return w, err
+	}
+	if w.delay, err = strconv.ParseFloat(row[`mmp_delay`], 64); err != nil {
+		return w, err
+	}
+
+	return w, nil
+}
+
+// multihostCollector reports the MMP writes of the pools with multihost enabled from their multihost kstat. The
+// multihost property is refreshed once per static properties interval, and kept for a suspended pool, on which
+// `zpool get` blocks. The kstat only records writes when the zfs_multihost_history module parameter is non-zero.
+type multihostCollector struct {
+	log     *slog.Logger
+	client  zfs.Client
+	static  *propertyCache
+	history map[string]*multihostHistory
+}
+
+func (c *multihostCollector) describe(ch chan<- *prometheus.Desc) {
+	ch <- multihostWritesDesc
+	ch <- multihostSkippedDesc
+	ch <- multihostFailedDesc
+	ch <- multihostLastWriteDesc
+	ch <- multihostDelayDesc
+	ch <- multihostInfoDesc
+	ch <- multihostWriteMetric.desc
+}
+
+func (c *multihostCollector) expire(pool string) {
+	c.static.expire(pool)
+}
+
+func (c *multihostCollector) update(ch chan<- metric, pools []string, excludes regexpCollection) error {
+	var hostID string
+	for _, pool := range pools {
+		enabled, err := c.multihost(pool)
+		if err != nil {
+			return err
+		}
+		if enabled != `on` {
+			c.log.Debug("Skipping pool without multihost enabled", "pool", pool)
+			delete(c.history, pool)
+			continue
+		}
+		if hostID == `` {
+			if hostID, err = c.client.HostID(); err != nil {
+				return err
+			}
+		}
+		ch <- metric{
+			name:       expandMetricName(multihostInfoDescName, pool),
+			prometheus: prometheus.MustNewConstMetric(multihostInfoDesc, prometheus.GaugeValue, 1, pool, enabled, hostID),
+		}
+
+		// Without any writes recorded, none were observed yet.
+		rows, err := c.client.KstatTable(pool + `/multihost`)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		if err = c.updateWriteMetrics(ch, pool, rows); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// multihost returns the multihost property of the pool, refreshed once per static properties interval unless the pool
+// is suspended
+func (c *multihostCollector) multihost(pool string) (string, error) {
+	if c.static.due(pool, *staticPropertiesInterval) {
+		p := c.client.Pool(pool)
+		if state, err := p.State(); err == nil && state == zfs.PoolSuspended {
+			c.log.Debug("Keeping the last known multihost property of suspended pool", "pool", pool)
+		} else {
+			props, err := p.Properties(`multihost`)
+			if err != nil {
+				return ``, err
+			}
+			c.static.store(pool, map[string]map[string]string{pool: props.Properties()})
+		}
+	}
+
+	return c.static.load(pool)[pool][`multihost`], nil
+}
+
+func (c *multihostCollector) updateWriteMetrics(ch chan<- metric, pool string, rows []map[string]string) error {
+	writes := make([]multihostWrite, 0, len(rows))
+	for _, row := range rows {
+		w, err := parseMultihostWrite(row)
+		if err != nil {
+			return err
+		}
+		writes = append(writes, w)
+	}
+	sort.Slice(writes, func(i, j int) bool { return writes[i].id < writes[j].id })
+
+	history, ok := c.history[pool]
+	if !ok || (len(writes) > 0 && writes[len(writes)-1].id < history.lastID) {
+		// New pool, or the pool has been re-imported, start over.
+		history = newMultihostHistory()
+		c.history[pool] = history
+	}
+
+	for _, w := range writes {
+		if w.id <= history.lastID {
+			continue
+		}
+		skipped := w.vdevGUID == `0`
+		if !skipped && w.err == 0 && w.duration == 0 {
+			// The write is still in flight, pick it and any later writes up on the next run.
+			break
+		}
+		switch {
+		case skipped:
+			history.skipped++
+		case w.err != 0:
+			history.writes++
+			history.failed++
+			history.duration.observe(w.duration / 1e9)
+		default:
+			history.writes++
+			history.duration.observe(w.duration / 1e9)
+			history.lastWrite = max(history.lastWrite, w.timestamp)
+		}
+		history.delay = w.delay / 1e9
+		history.lastID = w.id
+	}
+
+	ch <- metric{
+		name:       expandMetricName(multihostWritesDescName, pool),
+		prometheus: prometheus.MustNewConstMetric(multihostWritesDesc, prometheus.CounterValue, float64(history.writes), pool),
+	}
+	ch <- metric{
+		name:       expandMetricName(multihostSkippedDescName, pool),
+		prometheus: prometheus.MustNewConstMetric(multihostSkippedDesc, prometheus.CounterValue, float64(history.skipped), pool),
+	}
+	ch <- metric{
+		name:       expandMetricName(multihostFailedDescName, pool),
+		prometheus: prometheus.MustNewConstMetric(multihostFailedDesc, prometheus.CounterValue, float64(history.failed), pool),
+	}
+	if history.lastWrite > 0 {
+		ch <- metric{
+			name:       expandMetricName(multihostLastWriteDescName, pool),
+			prometheus: prometheus.MustNewConstMetric(multihostLastWriteDesc, prometheus.GaugeValue, float64(history.lastWrite), pool),
+		}
+	}
+	if history.lastID > 0 {
+		ch <- metric{
+			name:       expandMetricName(multihostDelayDescName, pool),
+			prometheus: prometheus.MustNewConstMetric(multihostDelayDesc, prometheus.GaugeValue, history.delay, pool),
+		}
+	}
+	multihostWriteMetric.push(ch, history.duration, pool)
+
+	return nil
+}
+
+func newMultihostCollector(l *slog.Logger, c zfs.Client, props []string) (Collector, error) {
+	return &multihostCollector{log: l, client: c, static: newPropertyCache(), history: make(map[string]*multihostHistory)}, nil
+}
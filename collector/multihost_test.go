@@ -0,0 +1,107 @@
+package collector
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/waitingsong/zfs_exporter/v3/zfs"
+	"github.com/waitingsong/zfs_exporter/v3/zfs/mock_zfs"
+)
+
+func TestMultihostMetrics(t *testing.T) {
+	const result = `# HELP zfs_multihost_delay_seconds Average interval in seconds between MMP uberblock writes, as of the last write.
+# TYPE zfs_multihost_delay_seconds gauge
+zfs_multihost_delay_seconds{pool="tank"} 0.124978012
+# HELP zfs_multihost_failed_writes_total Number of MMP uberblock writes which failed with an I/O error.
+# TYPE zfs_multihost_failed_writes_total counter
+zfs_multihost_failed_writes_total{pool="rpool"} 0
+zfs_multihost_failed_writes_total{pool="tank"} 1
+# HELP zfs_multihost_info The multihost property of the pool and the hostid of the system used to claim it, for pools with multihost enabled.
+# TYPE zfs_multihost_info gauge
+zfs_multihost_info{hostid="8aadbeef",multihost="on",pool="rpool"} 1
+zfs_multihost_info{hostid="8aadbeef",multihost="on",pool="tank"} 1
+# HELP zfs_multihost_last_write_timestamp_seconds Unix timestamp of the last successful MMP uberblock write.
+# TYPE zfs_multihost_last_write_timestamp_seconds gauge
+zfs_multihost_last_write_timestamp_seconds{pool="tank"} 1.718584512e+09
+# HELP zfs_multihost_skipped_writes_total Number of MMP uberblock writes skipped, as no leaf vdev was available to write to.
+# TYPE zfs_multihost_skipped_writes_total counter
+zfs_multihost_skipped_writes_total{pool="rpool"} 0
+zfs_multihost_skipped_writes_total{pool="tank"} 1
+# HELP zfs_multihost_write_duration_seconds Duration in seconds of completed MMP uberblock writes.
+# TYPE zfs_multihost_write_duration_seconds histogram
+zfs_multihost_write_duration_seconds_bucket{pool="rpool",le="0.0001"} 0
+zfs_multihost_write_duration_seconds_bucket{pool="rpool",le="0.0004"} 0
+zfs_multihost_write_duration_seconds_bucket{pool="rpool",le="0.0016"} 0
+zfs_multihost_write_duration_seconds_bucket{pool="rpool",le="0.0064"} 0
+zfs_multihost_write_duration_seconds_bucket{pool="rpool",le="0.0256"} 0
+zfs_multihost_write_duration_seconds_bucket{pool="rpool",le="0.1024"} 0
+zfs_multihost_write_duration_seconds_bucket{pool="rpool",le="0.4096"} 0
+zfs_multihost_write_duration_seconds_bucket{pool="rpool",le="1.6384"} 0
+zfs_multihost_write_duration_seconds_bucket{pool="rpool",le="6.5536"} 0
+zfs_multihost_write_duration_seconds_bucket{pool="rpool",le="26.2144"} 0
+zfs_multihost_write_duration_seconds_bucket{pool="rpool",le="+Inf"} 0
+zfs_multihost_write_duration_seconds_sum{pool="rpool"} 0
+zfs_multihost_write_duration_seconds_count{pool="rpool"} 0
+zfs_multihost_write_duration_seconds_bucket{pool="tank",le="0.0001"} 0
+zfs_multihost_write_duration_seconds_bucket{pool="tank",le="0.0004"} 1
+zfs_multihost_write_duration_seconds_bucket{pool="tank",le="0.0016"} 1
+zfs_multihost_write_duration_seconds_bucket{pool="tank",le="0.0064"} 2
+zfs_multihost_write_duration_seconds_bucket{pool="tank",le="0.0256"} 2
+zfs_multihost_write_duration_seconds_bucket{pool="tank",le="0.1024"} 3
+zfs_multihost_write_duration_seconds_bucket{pool="tank",le="0.4096"} 3
+zfs_multihost_write_duration_seconds_bucket{pool="tank",le="1.6384"} 3
+zfs_multihost_write_duration_seconds_bucket{pool="tank",le="6.5536"} 3
+zfs_multihost_write_duration_seconds_bucket{pool="tank",le="26.2144"} 3
+zfs_multihost_write_duration_seconds_bucket{pool="tank",le="+Inf"} 3
+zfs_multihost_write_duration_seconds_sum{pool="tank"} 0.050319481
+zfs_multihost_write_duration_seconds_count{pool="tank"} 3
+# HELP zfs_multihost_writes_total Number of completed MMP uberblock writes, including failed writes.
+# TYPE zfs_multihost_writes_total counter
+zfs_multihost_writes_total{pool="rpool"} 0
+zfs_multihost_writes_total{pool="tank"} 3
+`
+
+	ctrl, ctx := gomock.WithContext(context.Background(), t)
+	zfsClient := mock_zfs.NewMockClient(ctrl)
+	zfsClient.EXPECT().PoolNames().Return([]string{`backup`, `rpool`, `shared`, `tank`}, nil).Times(1)
+	zfsClient.EXPECT().HostID().Return(`8aadbeef`, nil).Times(1)
+	// The multihost property of a suspended pool is not queried, as `zpool get` would block.
+	for pool, status := range map[string]zfs.PoolStatus{`backup`: zfs.PoolOnline, `rpool`: zfs.PoolOnline, `shared`: zfs.PoolSuspended, `tank`: zfs.PoolOnline} {
+		zfsPool := mock_zfs.NewMockPool(ctrl)
+		zfsPool.EXPECT().State().Return(status, nil).Times(1)
+		if status != zfs.PoolSuspended {
+			multihost := `on`
+			if pool == `backup` {
+				multihost = `off`
+			}
+			zfsPoolProperties := mock_zfs.NewMockPoolProperties(ctrl)
+			zfsPoolProperties.EXPECT().Properties().Return(map[string]string{`multihost`: multihost}).Times(1)
+			zfsPool.EXPECT().Properties(`multihost`).Return(zfsPoolProperties, nil).Times(1)
+		}
+		zfsClient.EXPECT().Pool(pool).Return(zfsPool).Times(1)
+	}
+	// Writes are only recorded with zfs_multihost_history set, and the last write of tank is still in flight.
+	zfsClient.EXPECT().KstatTable(`rpool/multihost`).Return(nil, os.ErrNotExist).Times(1)
+	zfsClient.EXPECT().KstatTable(`tank/multihost`).Return([]map[string]string{
+		{`id`: `20468`, `txg`: `8954`, `timestamp`: `1718584512`, `error`: `0`, `duration`: `231872`, `mmp_delay`: `124978012`, `vdev_guid`: `14706353417519462521`, `vdev_label`: `2`, `vdev_path`: `/dev/sdb1`},
+		{`id`: `20469`, `txg`: `8954`, `timestamp`: `1718584512`, `error`: `0`, `duration`: `1873654`, `mmp_delay`: `124978012`, `vdev_guid`: `9340911718264035132`, `vdev_label`: `0`, `vdev_path`: `/dev/sdc1`},
+		{`id`: `20470`, `txg`: `8955`, `timestamp`: `1718584513`, `error`: `2`, `duration`: `0`, `mmp_delay`: `124978012`, `vdev_guid`: `0`, `vdev_label`: `-`, `vdev_path`: `-`},
+		{`id`: `20471`, `txg`: `8955`, `timestamp`: `1718584513`, `error`: `5`, `duration`: `48213955`, `mmp_delay`: `124978012`, `vdev_guid`: `14706353417519462521`, `vdev_label`: `1`, `vdev_path`: `/dev/sdb1`},
+		{`id`: `20472`, `txg`: `8955`, `timestamp`: `1718584513`, `error`: `0`, `duration`: `0`, `mmp_delay`: `124978012`, `vdev_guid`: `9340911718264035132`, `vdev_label`: `3`, `vdev_path`: `/dev/sdc1`},
+	}, nil).Times(1)
+
+	collector := newTestZFS(t, zfsClient, `multihost`, ``, newMultihostCollector)
+
+	metricNames := []string{
+		`zfs_multihost_delay_seconds`,
+		`zfs_multihost_failed_writes_total`,
+		`zfs_multihost_info`,
+		`zfs_multihost_last_write_timestamp_seconds`,
+		`zfs_multihost_skipped_writes_total`,
+		`zfs_multihost_write_duration_seconds`,
+		`zfs_multihost_writes_total`,
+	}
+	collectAndCompare(t, ctx, collector, result, metricNames)
+}
@@ -3,6 +3,7 @@ package collector
 import (
 	"fmt"
 	"log/slog"
+	"slices"
 	"sync"
 
 	"github.com/prometheus/client_golang/prometheus"
@@ -10,10 +11,21 @@ import (
 )
 
 const (
-	defaultPoolProps = `allocated,dedupratio,fragmentation,free,freeing,health,leaked,readonly,size`
+	defaultPoolProps = `allocated,dedupratio,fragmentation,free,freeing,health,leaked,readonly,size,guid,load_guid,version,altroot,cachefile,failmode,multihost,compatibility`
+
+	healthSourceKstat = `kstat`
+	healthSourceZpool = `zpool`
 )
 
 var (
+	poolHealthSourceDescName = prometheus.BuildFQName(namespace, subsystemPool, `health_source_info`)
+	poolHealthSourceDesc     = prometheus.NewDesc(
+		poolHealthSourceDescName,
+		`The source of the health status of the pool [kstat: /proc/spl/kstat/zfs/<pool>/state, zpool: zpool get].`,
+		[]string{`pool`, `source`},
+		nil,
+	)
+
 	poolLabels     = []string{`pool`}
 	poolProperties = propertyStore{
 		defaultSubsystem: subsystemPool,
@@ -32,28 +44,28 @@ var (
 				`Pool sector size exponent, to the power of 2.`,
 				transformNumeric,
 				poolLabels...,
-			),
+			).withTier(tierStatic),
 			`autoexpand`: newProperty(
 				subsystemPool,
 				`autoexpand`,
 				`Controls automatic pool expansion when the underlying LUN is grown [0: off, 1: on].`,
 				transformBool,
 				poolLabels...,
-			),
+			).withTier(tierStatic),
 			`autoreplace`: newProperty(
 				subsystemPool,
 				`autoreplace`,
 				`Controls automatic device replacement. [0: off, 1: on].`,
 				transformBool,
 				poolLabels...,
-			),
+			).withTier(tierStatic),
 			`autotrim`: newProperty(
 				subsystemPool,
 				`autotrim`,
 				`Auto trim status of the pool [0: off, 1: on].`,
 				transformBool,
 				poolLabels...,
-			),
+			).withTier(tierStatic).withMinVersion(0, 8),
 			`dedupratio`: newProperty(
 				subsystemPool,
 				`dedupratio`,
@@ -132,29 +144,59 @@ var (
 				transformNumeric,
 				poolLabels...,
 			),
+			`guid`:          newInfoProperty(`guid`).withTier(tierStatic),
+			`load_guid`:     newInfoProperty(`load_guid`).withMinVersion(0, 8),
+			`version`:       newInfoProperty(`version`).withTier(tierStatic),
+			`altroot`:       newInfoProperty(`altroot`),
+			`cachefile`:     newInfoProperty(`cachefile`),
+			`failmode`:      newInfoProperty(`failmode`),
+			`multihost`:     newInfoProperty(`multihost`).withMinVersion(0, 7),
+			`compatibility`: newInfoProperty(`compatibility`).withMinVersion(2, 1),
 		},
+		info: newInfoMetric(
+			subsystemPool,
+			`Identity and configuration of the pool, from its string properties. The guid identifies the pool across renames, and the load_guid changes whenever the pool is imported. Properties which are not collected or not set are empty.`,
+			poolLabels,
+			`guid`, `load_guid`, `version`, `altroot`, `cachefile`, `failmode`, `multihost`, `compatibility`,
+		),
 	}
 )
 
 func init() {
-	registerCollector(`pool`, defaultEnabled, defaultPoolProps, newPoolCollector)
+	registerCollector(`pool`, defaultEnabled, defaultPoolProps, newPoolCollector, withPropertyStore(&poolProperties))
 }
 
 type poolCollector struct {
 	log    *slog.Logger
 	client zfs.Client
-	props  []string
+	props  propertyTiers
+	static *propertyCache
+}
+
+func (c *poolCollector) expire(pool string) {
+	c.static.expire(pool)
 }
 
 func (c *poolCollector) describe(ch chan<- *prometheus.Desc) {
-	for _, k := range c.props {
+	if slices.Contains(c.props.all, `health`) {
+		ch <- poolHealthSourceDesc
+	}
+	info := false
+	for _, k := range c.props.all {
 		prop, err := poolProperties.find(k)
 		if err != nil {
 			c.log.Warn(propertyUnsupportedMsg, `help`, helpIssue, `collector`, `pool`, `property`, k, `err`, err)
 			continue
 		}
+		if prop.info {
+			info = true
+			continue
+		}
 		ch <- prop.desc
 	}
+	if info {
+		ch <- poolProperties.info.desc
+	}
 }
 
 func (c *poolCollector) update(ch chan<- metric, pools []string, excludes regexpCollection) error {
@@ -180,26 +222,91 @@ func (c *poolCollector) update(ch chan<- metric, pools []string, excludes regexp
 }
 
 func (c *poolCollector) updatePoolMetrics(ch chan<- metric, pool string) error {
-	p := c.client.Pool(pool)
-	props, err := p.Properties(c.props...)
-	if err != nil {
-		return err
+	refresh := c.static.due(pool, *staticPropertiesInterval)
+	query := c.props.all
+	if !refresh {
+		query = c.props.volatile
 	}
 
+	p := c.client.Pool(pool)
 	labelValues := []string{pool}
-	for k, v := range props.Properties() {
+	if slices.Contains(query, `health`) {
+		state, err := c.updateHealthMetrics(ch, p, pool)
+		if err != nil {
+			return err
+		}
+		if state == zfs.PoolSuspended {
+			// `zpool get` blocks on a suspended pool, only report its health until it resumes.
+			return nil
+		}
+		if state != `` {
+			query = slices.DeleteFunc(slices.Clone(query), func(k string) bool { return k == `health` })
+		}
+	}
+
+	values := make(map[string]string)
+	if len(query) > 0 {
+		props, err := p.Properties(query...)
+		if err != nil {
+			return err
+		}
+		values = props.Properties()
+	}
+	if refresh {
+		c.static.store(pool, map[string]map[string]string{pool: c.props.staticValues(values)})
+	} else {
+		values = mergeProperties(values, c.static.load(pool)[pool])
+	}
+
+	info := make(map[string]string)
+	for k, v := range values {
 		prop, err := poolProperties.find(k)
 		if err != nil {
 			c.log.Warn(propertyUnsupportedMsg, `help`, helpIssue, `collector`, `pool`, `property`, k, `err`, err)
 		}
+		if prop.info {
+			info[k] = v
+			continue
+		}
 		if err = prop.push(ch, v, labelValues...); err != nil {
 			return err
 		}
 	}
+	if len(info) > 0 {
+		poolProperties.info.push(ch, info, labelValues...)
+	}
 
 	return nil
 }
 
+// updateHealthMetrics reports the health of the pool from its state kstat, returning the state found. An empty state
+// is returned when the kstat is unavailable, in which case health should be queried through `zpool get` instead.
+func (c *poolCollector) updateHealthMetrics(ch chan<- metric, p zfs.Pool, pool string) (zfs.PoolStatus, error) {
+	source := healthSourceKstat
+	state, err := p.State()
+	if err != nil {
+		c.log.Debug("Pool state kstat unavailable, falling back to zpool", "pool", pool, "err", err)
+		source = healthSourceZpool
+		state = ``
+	} else {
+		prop, _ := poolProperties.find(`health`)
+		if err = prop.push(ch, string(state), pool); err != nil {
+			return ``, err
+		}
+	}
+
+	ch <- metric{
+		name:       expandMetricName(poolHealthSourceDescName, pool),
+		prometheus: prometheus.MustNewConstMetric(poolHealthSourceDesc, prometheus.GaugeValue, 1, pool, source),
+	}
+
+	return state, nil
+}
+
 func newPoolCollector(l *slog.Logger, c zfs.Client, props []string) (Collector, error) {
-	return &poolCollector{log: l, client: c, props: props}, nil
+	tiers, err := poolProperties.tiers(props)
+	if err != nil {
+		return nil, err
+	}
+	return &poolCollector{log: l, client: c, props: tiers, static: newPropertyCache()}, nil
 }
@@ -2,10 +2,15 @@ package collector
 
 import (
 	"context"
+	"fmt"
+	"os"
+	"slices"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/golang/mock/gomock"
+	"github.com/waitingsong/zfs_exporter/v3/zfs"
 	"github.com/waitingsong/zfs_exporter/v3/zfs/mock_zfs"
 )
 
@@ -17,6 +22,7 @@ func TestPoolMetrics(t *testing.T) {
 		propsRequested []string
 		metricNames    []string
 		propsResults   map[string]map[string]string
+		stateResults   map[string]string
 		metricResults  string
 	}{
 		{
@@ -149,6 +155,40 @@ zfs_pool_health{pool="offlinepool"} 3
 zfs_pool_health{pool="unavailpool"} 4
 zfs_pool_health{pool="removedpool"} 5
 zfs_pool_health{pool="suspendedpool"} 6
+`,
+		},
+		{
+			name:           `health status from kstat`,
+			pools:          []string{`onlinepool`, `suspendedpool`, `fallbackpool`},
+			propsRequested: []string{`health`, `size`},
+			metricNames:    []string{`zfs_pool_health`, `zfs_pool_health_source_info`, `zfs_pool_size_bytes`},
+			propsResults: map[string]map[string]string{
+				`onlinepool`: {
+					`size`: `1024`,
+				},
+				`fallbackpool`: {
+					`health`: `DEGRADED`,
+					`size`:   `2048`,
+				},
+			},
+			stateResults: map[string]string{
+				`onlinepool`:    `ONLINE`,
+				`suspendedpool`: `SUSPENDED`,
+			},
+			metricResults: `# HELP zfs_pool_health Health status code for the pool [0: ONLINE, 1: DEGRADED, 2: FAULTED, 3: OFFLINE, 4: UNAVAIL, 5: REMOVED, 6: SUSPENDED].
+# TYPE zfs_pool_health gauge
+zfs_pool_health{pool="fallbackpool"} 1
+zfs_pool_health{pool="onlinepool"} 0
+zfs_pool_health{pool="suspendedpool"} 6
+# HELP zfs_pool_health_source_info The source of the health status of the pool [kstat: /proc/spl/kstat/zfs/<pool>/state, zpool: zpool get].
+# TYPE zfs_pool_health_source_info gauge
+zfs_pool_health_source_info{pool="fallbackpool",source="zpool"} 1
+zfs_pool_health_source_info{pool="onlinepool",source="kstat"} 1
+zfs_pool_health_source_info{pool="suspendedpool",source="kstat"} 1
+# HELP zfs_pool_size_bytes Total size in bytes of the storage pool.
+# TYPE zfs_pool_size_bytes gauge
+zfs_pool_size_bytes{pool="fallbackpool"} 2048
+zfs_pool_size_bytes{pool="onlinepool"} 1024
 `,
 		},
 		{
@@ -164,6 +204,31 @@ zfs_pool_health{pool="suspendedpool"} 6
 			metricResults: `# HELP zfs_pool_unsupported !!! This property is unsupported, results are likely to be undesirable, please file an issue at https://github.com/waitingsong/zfs_exporter/issues to have this property supported !!!
 # TYPE zfs_pool_unsupported gauge
 zfs_pool_unsupported{pool="testpool"} 1024
+`,
+		},
+		{
+			name:           `info`,
+			pools:          []string{`testpool`},
+			propsRequested: []string{`size`, `guid`, `load_guid`, `version`, `altroot`, `cachefile`, `failmode`, `multihost`},
+			metricNames:    []string{`zfs_pool_size_bytes`, `zfs_pool_info`},
+			propsResults: map[string]map[string]string{
+				`testpool`: {
+					`size`:      `2048`,
+					`guid`:      `12345678901234567890`,
+					`load_guid`: `9876543210987654321`,
+					`version`:   `-`,
+					`altroot`:   `/mnt`,
+					`cachefile`: `none`,
+					`failmode`:  `wait`,
+					`multihost`: `off`,
+				},
+			},
+			metricResults: `# HELP zfs_pool_info Identity and configuration of the pool, from its string properties. The guid identifies the pool across renames, and the load_guid changes whenever the pool is imported. Properties which are not collected or not set are empty.
+# TYPE zfs_pool_info gauge
+zfs_pool_info{altroot="/mnt",cachefile="none",compatibility="",failmode="wait",guid="12345678901234567890",load_guid="9876543210987654321",multihost="off",pool="testpool",version=""} 1
+# HELP zfs_pool_size_bytes Total size in bytes of the storage pool.
+# TYPE zfs_pool_size_bytes gauge
+zfs_pool_size_bytes{pool="testpool"} 2048
 `,
 		},
 		{
@@ -212,11 +277,27 @@ zfs_pool_dedupratio{pool="testpool"} 2.5
 						continue
 					}
 				}
-				zfsPoolProperties := mock_zfs.NewMockPoolProperties(ctrl)
-				zfsPoolProperties.EXPECT().Properties().Return(tc.propsResults[pool]).Times(1)
 				zfsPool := mock_zfs.NewMockPool(ctrl)
-				zfsPool.EXPECT().Properties(tc.propsRequested).Return(zfsPoolProperties, nil).Times(1)
 				zfsClient.EXPECT().Pool(pool).Return(zfsPool).Times(1)
+				propsRequested := tc.propsRequested
+				if slices.Contains(propsRequested, `health`) {
+					state, ok := tc.stateResults[pool]
+					if !ok {
+						zfsPool.EXPECT().State().Return(zfs.PoolStatus(``), os.ErrNotExist).Times(1)
+					} else {
+						zfsPool.EXPECT().State().Return(zfs.PoolStatus(state), nil).Times(1)
+						if zfs.PoolStatus(state) == zfs.PoolSuspended {
+							continue
+						}
+						propsRequested = slices.DeleteFunc(slices.Clone(propsRequested), func(k string) bool { return k == `health` })
+					}
+				}
+				if len(propsRequested) == 0 {
+					continue
+				}
+				zfsPoolProperties := mock_zfs.NewMockPoolProperties(ctrl)
+				zfsPoolProperties.EXPECT().Properties().Return(tc.propsResults[pool]).Times(1)
+				zfsPool.EXPECT().Properties(propsRequested).Return(zfsPoolProperties, nil).Times(1)
 			}
 
 			collector, err := NewZFS(config)
@@ -238,3 +319,59 @@ zfs_pool_dedupratio{pool="testpool"} 2.5
 		})
 	}
 }
+
+func TestPoolStaticProperties(t *testing.T) {
+	interval := *staticPropertiesInterval
+	*staticPropertiesInterval = time.Hour
+	defer func() {
+		*staticPropertiesInterval = interval
+	}()
+
+	const metricResults = `# HELP zfs_pool_allocated_bytes Amount of storage in bytes used within the pool.
+# TYPE zfs_pool_allocated_bytes gauge
+zfs_pool_allocated_bytes{pool="testpool"} %s
+# HELP zfs_pool_ashift Pool sector size exponent, to the power of 2.
+# TYPE zfs_pool_ashift gauge
+zfs_pool_ashift{pool="testpool"} 12
+# HELP zfs_pool_autotrim Auto trim status of the pool [0: off, 1: on].
+# TYPE zfs_pool_autotrim gauge
+zfs_pool_autotrim{pool="testpool"} %s
+`
+	metricNames := []string{`zfs_pool_allocated_bytes`, `zfs_pool_ashift`, `zfs_pool_autotrim`}
+
+	ctrl, ctx := gomock.WithContext(context.Background(), t)
+	zfsClient := mock_zfs.NewMockClient(ctrl)
+	zfsClient.EXPECT().PoolNames().Return([]string{`testpool`}, nil).Times(2)
+
+	// The first run queries all properties, the second only queries volatile properties.
+	first := mock_zfs.NewMockPoolProperties(ctrl)
+	first.EXPECT().Properties().Return(map[string]string{`allocated`: `1024`, `ashift`: `12`, `autotrim`: `off`}).Times(1)
+	second := mock_zfs.NewMockPoolProperties(ctrl)
+	second.EXPECT().Properties().Return(map[string]string{`allocated`: `2048`, `autotrim`: `on`}).Times(1)
+	zfsPool := mock_zfs.NewMockPool(ctrl)
+	gomock.InOrder(
+		zfsPool.EXPECT().Properties(`allocated`, `ashift`, `autotrim`).Return(first, nil).Times(1),
+		zfsPool.EXPECT().Properties(`allocated`, `autotrim`).Return(second, nil).Times(1),
+	)
+	zfsClient.EXPECT().Pool(`testpool`).Return(zfsPool).Times(2)
+
+	collector, err := NewZFS(defaultConfig(zfsClient))
+	if err != nil {
+		t.Fatal(err)
+	}
+	collector.Collectors = map[string]State{
+		`pool`: {
+			Name:       "pool",
+			Enabled:    boolPointer(true),
+			Properties: stringPointer(`allocated,ashift,autotrim:volatile`),
+			factory:    newPoolCollector,
+		},
+	}
+
+	if err = callCollector(ctx, collector, []byte(fmt.Sprintf(metricResults, `1024`, `0`)), metricNames); err != nil {
+		t.Fatal(err)
+	}
+	if err = callCollector(ctx, collector, []byte(fmt.Sprintf(metricResults, `2048`, `1`)), metricNames); err != nil {
+		t.Fatal(err)
+	}
+}
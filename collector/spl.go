@@ -0,0 +1,283 @@
+package collector
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/alecthomas/kingpin/v2"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/waitingsong/zfs_exporter/v3/zfs"
+)
+
+const (
+	defaultSPLProps  = `slab_size,slab_alloc,slab_objsize,slab_obj_total,slab_obj_alloc,taskq_act,taskq_pend,taskq_prio,taskq_delay,taskq_nthr`
+	defaultSPLCaches = `abd_t,arc_buf_hdr_t_full,arc_buf_t,dmu_buf_impl_t,dnode_t,sa_cache,zfs_znode_cache,zio_buf_comb_*,zio_cache,zio_data_buf_comb_*,zio_link_cache`
+
+	splSlabPrefix  = `slab_`
+	splTaskqPrefix = `taskq_`
+)
+
+var (
+	splCaches = kingpin.Flag(`collector.spl.caches`, `SPL kmem caches reported by the spl collector, comma-separated. Names may contain '*' wildcards, although every cache reported adds a label value to each slab metric`).Default(defaultSPLCaches).String()
+
+	splSlabLabels     = []string{`cache`}
+	splSlabProperties = propertyStore{
+		defaultSubsystem: subsystemSPLKmem,
+		defaultLabels:    splSlabLabels,
+		store: map[string]property{
+			`size`: newProperty(
+				subsystemSPLKmem,
+				`cache_size_bytes`,
+				`Size in bytes of the slabs allocated to the SPL kmem cache.`,
+				transformNumeric,
+				splSlabLabels...,
+			),
+			`alloc`: newProperty(
+				subsystemSPLKmem,
+				`cache_allocated_bytes`,
+				`Size in bytes of the objects allocated from the SPL kmem cache.`,
+				transformNumeric,
+				splSlabLabels...,
+			),
+			`slabsize`: newProperty(
+				subsystemSPLKmem,
+				`cache_slab_size_bytes`,
+				`Size in bytes of each slab of the SPL kmem cache.`,
+				transformNumeric,
+				splSlabLabels...,
+			),
+			`objsize`: newProperty(
+				subsystemSPLKmem,
+				`cache_object_size_bytes`,
+				`Size in bytes of each object of the SPL kmem cache.`,
+				transformNumeric,
+				splSlabLabels...,
+			),
+			`slab_total`: newProperty(
+				subsystemSPLKmem,
+				`cache_slabs`,
+				`Number of slabs in the SPL kmem cache.`,
+				transformNumeric,
+				splSlabLabels...,
+			),
+			`slab_alloc`: newProperty(
+				subsystemSPLKmem,
+				`cache_slabs_allocated`,
+				`Number of slabs in the SPL kmem cache holding allocated objects.`,
+				transformNumeric,
+				splSlabLabels...,
+			),
+			`slab_max`: newProperty(
+				subsystemSPLKmem,
+				`cache_slabs_max`,
+				`Highest number of slabs held by the SPL kmem cache.`,
+				transformNumeric,
+				splSlabLabels...,
+			),
+			`obj_total`: newProperty(
+				subsystemSPLKmem,
+				`cache_objects`,
+				`Number of objects in the SPL kmem cache.`,
+				transformNumeric,
+				splSlabLabels...,
+			),
+			`obj_alloc`: newProperty(
+				subsystemSPLKmem,
+				`cache_objects_allocated`,
+				`Number of objects allocated from the SPL kmem cache.`,
+				transformNumeric,
+				splSlabLabels...,
+			),
+			`obj_max`: newProperty(
+				subsystemSPLKmem,
+				`cache_objects_max`,
+				`Highest number of objects allocated from the SPL kmem cache.`,
+				transformNumeric,
+				splSlabLabels...,
+			),
+			`emerg_alloc`: newProperty(
+				subsystemSPLKmem,
+				`cache_emergency_objects_allocated`,
+				`Number of emergency objects allocated outside of the slabs of the SPL kmem cache.`,
+				transformNumeric,
+				splSlabLabels...,
+			),
+		},
+	}
+
+	splTaskqLabels     = []string{`taskq`, `id`}
+	splTaskqProperties = propertyStore{
+		defaultSubsystem: subsystemSPLTaskq,
+		defaultLabels:    splTaskqLabels,
+		store: map[string]property{
+			`act`: newProperty(
+				subsystemSPLTaskq,
+				`active_tasks`,
+				`Number of tasks being executed by the SPL taskq.`,
+				transformNumeric,
+				splTaskqLabels...,
+			),
+			`pend`: newProperty(
+				subsystemSPLTaskq,
+				`pending_tasks`,
+				`Number of tasks queued on the SPL taskq, waiting for a thread.`,
+				transformNumeric,
+				splTaskqLabels...,
+			),
+			`prio`: newProperty(
+				subsystemSPLTaskq,
+				`pending_priority_tasks`,
+				`Number of high priority tasks queued on the SPL taskq, waiting for a thread.`,
+				transformNumeric,
+				splTaskqLabels...,
+			),
+			`delay`: newProperty(
+				subsystemSPLTaskq,
+				`delayed_tasks`,
+				`Number of tasks scheduled on the SPL taskq for a later time.`,
+				transformNumeric,
+				splTaskqLabels...,
+			),
+			`wait`: newProperty(
+				subsystemSPLTaskq,
+				`waiting_threads`,
+				`Number of threads waiting on the completion of tasks of the SPL taskq.`,
+				transformNumeric,
+				splTaskqLabels...,
+			),
+			`nthr`: newProperty(
+				subsystemSPLTaskq,
+				`threads`,
+				`Number of threads of the SPL taskq.`,
+				transformNumeric,
+				splTaskqLabels...,
+			),
+			`spwn`: newProperty(
+				subsystemSPLTaskq,
+				`spawning_threads`,
+				`Number of threads being spawned by the SPL taskq.`,
+				transformNumeric,
+				splTaskqLabels...,
+			),
+			`maxt`: newProperty(
+				subsystemSPLTaskq,
+				`threads_max`,
+				`Maximum number of threads of the SPL taskq.`,
+				transformNumeric,
+				splTaskqLabels...,
+			),
+		},
+	}
+)
+
+func init() {
+	registerCollector(`spl`, defaultDisabled, defaultSPLProps, newSPLCollector)
+}
+
+type splCollector struct {
+	log    *slog.Logger
+	client zfs.Client
+	slab   []string
+	taskq  []string
+	caches []string
+}
+
+func (c *splCollector) describe(ch chan<- *prometheus.Desc) {
+	for _, k := range c.slab {
+		prop, err := splSlabProperties.find(k)
+		if err != nil {
+			c.log.Warn(propertyUnsupportedMsg, `help`, helpIssue, `collector`, `spl`, `property`, splSlabPrefix+k, `err`, err)
+			continue
+		}
+		ch <- prop.desc
+	}
+	for _, k := range c.taskq {
+		prop, err := splTaskqProperties.find(k)
+		if err != nil {
+			c.log.Warn(propertyUnsupportedMsg, `help`, helpIssue, `collector`, `spl`, `property`, splTaskqPrefix+k, `err`, err)
+			continue
+		}
+		ch <- prop.desc
+	}
+}
+
+func (c *splCollector) update(ch chan<- metric, pools []string, excludes regexpCollection) error {
+	if len(c.slab) > 0 {
+		if err := c.updateSlabMetrics(ch); err != nil {
+			return err
+		}
+	}
+	if len(c.taskq) > 0 {
+		if err := c.updateTaskqMetrics(ch); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (c *splCollector) updateSlabMetrics(ch chan<- metric) error {
+	caches, err := c.client.Slabs()
+	if err != nil {
+		return err
+	}
+	for _, cache := range caches {
+		if !matchAny(c.caches, cache[`name`]) {
+			continue
+		}
+		if err = c.push(ch, &splSlabProperties, splSlabPrefix, c.slab, cache, cache[`name`]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (c *splCollector) updateTaskqMetrics(ch chan<- metric) error {
+	taskqs, err := c.client.Taskqs()
+	if err != nil {
+		return err
+	}
+	for _, taskq := range taskqs {
+		if err = c.push(ch, &splTaskqProperties, splTaskqPrefix, c.taskq, taskq, taskq[`name`], taskq[`id`]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (c *splCollector) push(ch chan<- metric, store *propertyStore, prefix string, props []string, values map[string]string, labelValues ...string) error {
+	for _, k := range props {
+		v, ok := values[k]
+		if !ok {
+			// Caches backed by the Linux slab allocator only report some columns.
+			continue
+		}
+		prop, err := store.find(k)
+		if err != nil {
+			c.log.Warn(propertyUnsupportedMsg, `help`, helpIssue, `collector`, `spl`, `property`, prefix+k, `err`, err)
+		}
+		if err = prop.push(ch, v, labelValues...); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func newSPLCollector(l *slog.Logger, c zfs.Client, props []string) (Collector, error) {
+	collector := &splCollector{log: l, client: c, caches: strings.Split(*splCaches, `,`)}
+	for _, k := range props {
+		if name, ok := strings.CutPrefix(k, splSlabPrefix); ok {
+			collector.slab = append(collector.slab, name)
+		} else if name, ok := strings.CutPrefix(k, splTaskqPrefix); ok {
+			collector.taskq = append(collector.taskq, name)
+		} else {
+			return nil, fmt.Errorf("unknown property '%s' for the spl collector, expected a '%s' or '%s' prefix", k, splSlabPrefix, splTaskqPrefix)
+		}
+	}
+
+	return collector, nil
+}
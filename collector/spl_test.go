@@ -0,0 +1,93 @@
+package collector
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/waitingsong/zfs_exporter/v3/zfs"
+	"github.com/waitingsong/zfs_exporter/v3/zfs/mock_zfs"
+)
+
+func TestSPLMetrics(t *testing.T) {
+	testCases := []struct {
+		name           string
+		propsRequested []string
+		caches         []string
+		metricNames    []string
+		slabResults    []map[string]string
+		taskqResults   []map[string]string
+		metricResults  string
+	}{
+		{
+			name:           `slab allow-list`,
+			propsRequested: []string{`slab_size`, `slab_obj_alloc`},
+			caches:         []string{`dnode_t`, `zio_data_buf_*`},
+			metricNames:    []string{`zfs_spl_kmem_cache_size_bytes`, `zfs_spl_kmem_cache_objects_allocated`},
+			slabResults: []map[string]string{
+				{`name`: `spl_vn_cache`, `size`: `0`, `obj_alloc`: `0`},
+				{`name`: `zio_data_buf_131072`, `size`: `14716764160`, `obj_alloc`: `72800`},
+				{`name`: `dnode_t`, `obj_alloc`: `53768`},
+			},
+			metricResults: `# HELP zfs_spl_kmem_cache_objects_allocated Number of objects allocated from the SPL kmem cache.
+# TYPE zfs_spl_kmem_cache_objects_allocated gauge
+zfs_spl_kmem_cache_objects_allocated{cache="dnode_t"} 53768
+zfs_spl_kmem_cache_objects_allocated{cache="zio_data_buf_131072"} 72800
+# HELP zfs_spl_kmem_cache_size_bytes Size in bytes of the slabs allocated to the SPL kmem cache.
+# TYPE zfs_spl_kmem_cache_size_bytes gauge
+zfs_spl_kmem_cache_size_bytes{cache="zio_data_buf_131072"} 1.471676416e+10
+`,
+		},
+		{
+			name:           `taskqs`,
+			propsRequested: []string{`taskq_act`, `taskq_pend`, `taskq_delay`},
+			metricNames:    []string{`zfs_spl_taskq_active_tasks`, `zfs_spl_taskq_pending_tasks`, `zfs_spl_taskq_delayed_tasks`},
+			taskqResults: []map[string]string{
+				{`name`: `spl_delay_taskq`, `id`: `0`, `act`: `0`, `pend`: `0`, `delay`: `1`},
+				{`name`: `z_wr_iss`, `id`: `0`, `act`: `2`, `pend`: `5`, `delay`: `0`},
+			},
+			metricResults: `# HELP zfs_spl_taskq_active_tasks Number of tasks being executed by the SPL taskq.
+# TYPE zfs_spl_taskq_active_tasks gauge
+zfs_spl_taskq_active_tasks{id="0",taskq="spl_delay_taskq"} 0
+zfs_spl_taskq_active_tasks{id="0",taskq="z_wr_iss"} 2
+# HELP zfs_spl_taskq_delayed_tasks Number of tasks scheduled on the SPL taskq for a later time.
+# TYPE zfs_spl_taskq_delayed_tasks gauge
+zfs_spl_taskq_delayed_tasks{id="0",taskq="spl_delay_taskq"} 1
+zfs_spl_taskq_delayed_tasks{id="0",taskq="z_wr_iss"} 0
+# HELP zfs_spl_taskq_pending_tasks Number of tasks queued on the SPL taskq, waiting for a thread.
+# TYPE zfs_spl_taskq_pending_tasks gauge
+zfs_spl_taskq_pending_tasks{id="0",taskq="spl_delay_taskq"} 0
+zfs_spl_taskq_pending_tasks{id="0",taskq="z_wr_iss"} 5
+`,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			ctrl, ctx := gomock.WithContext(context.Background(), t)
+			zfsClient := mock_zfs.NewMockClient(ctrl)
+			zfsClient.EXPECT().PoolNames().Return([]string{`testpool`}, nil).Times(1)
+			if tc.slabResults != nil {
+				zfsClient.EXPECT().Slabs().Return(tc.slabResults, nil).Times(1)
+			}
+			if tc.taskqResults != nil {
+				zfsClient.EXPECT().Taskqs().Return(tc.taskqResults, nil).Times(1)
+			}
+
+			collector := newTestZFS(t, zfsClient, `spl`, strings.Join(tc.propsRequested, `,`), func(l *slog.Logger, c zfs.Client, props []string) (Collector, error) {
+				collector, err := newSPLCollector(l, c, props)
+				if err != nil {
+					return nil, err
+				}
+				collector.(*splCollector).caches = tc.caches
+				return collector, nil
+			})
+
+			collectAndCompare(t, ctx, collector, tc.metricResults, tc.metricNames)
+		})
+	}
+}
@@ -0,0 +1,258 @@
+package collector
+
+import (
+	"log/slog"
+	"unicode/utf8"
+
+	"github.com/alecthomas/kingpin/v2"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/waitingsong/zfs_exporter/v3/zfs"
+)
+
+const (
+	operationStateHelp = `[0: none, 1: active, 2: suspended, 3: completed, 4: canceled, 5: unsupported]`
+)
+
+var (
+	statusErrorFiles      = kingpin.Flag(`collector.status.error-files`, `Report a zfs_pool_permanent_error_info series per file with permanent errors, labelled by dataset and path (default: false)`).Default(`false`).Bool()
+	statusErrorPathLength = kingpin.Flag(`collector.status.error-path-length`, `Maximum length in characters of the path label of zfs_pool_permanent_error_info, longer paths being truncated (default: 256)`).Default(`256`).Int()
+
+	statusTrimState = newProperty(
+		subsystemVdev,
+		`trim_state`,
+		`State of the last manual or automatic TRIM of the vdev `+operationStateHelp+`.`,
+		transformNumeric,
+		vdevLabels...,
+	)
+	statusTrimProgress = newProperty(
+		subsystemVdev,
+		`trim_progress_ratio`,
+		`Completed fraction of the last TRIM of the vdev.`,
+		transformNumeric,
+		vdevLabels...,
+	)
+	statusInitializeState = newProperty(
+		subsystemVdev,
+		`initialize_state`,
+		`State of the last initialization of the vdev `+operationStateHelp+`.`,
+		transformNumeric,
+		vdevLabels...,
+	)
+	statusInitializeProgress = newProperty(
+		subsystemVdev,
+		`initialize_progress_ratio`,
+		`Completed fraction of the last initialization of the vdev.`,
+		transformNumeric,
+		vdevLabels...,
+	)
+	statusRemovalState = newProperty(
+		subsystemPool,
+		`removal_state`,
+		`State of the last device removal from the pool `+operationStateHelp+`.`,
+		transformNumeric,
+		vdevLabels...,
+	)
+	statusRemovalCopied = newProperty(
+		subsystemPool,
+		`removal_copied_bytes`,
+		`Amount of data in bytes evacuated by the last device removal from the pool.`,
+		transformNumeric,
+		vdevLabels...,
+	)
+	statusRemovalTotal = newProperty(
+		subsystemPool,
+		`removal_total_bytes`,
+		`Amount of data in bytes to evacuate by the active device removal from the pool.`,
+		transformNumeric,
+		vdevLabels...,
+	)
+	statusRemovalMapping = newProperty(
+		subsystemPool,
+		`removal_mapping_memory_bytes`,
+		`Amount of memory in bytes used by the mappings of devices removed from the pool.`,
+		transformNumeric,
+		poolLabels...,
+	)
+	statusCheckpointExists = newProperty(
+		subsystemPool,
+		`checkpoint_exists`,
+		`Whether the pool has a checkpoint, including one being discarded [0: no, 1: yes].`,
+		transformNumeric,
+		poolLabels...,
+	)
+	statusCheckpointDiscarding = newProperty(
+		subsystemPool,
+		`checkpoint_discarding`,
+		`Whether the checkpoint of the pool is being discarded [0: no, 1: yes].`,
+		transformNumeric,
+		poolLabels...,
+	)
+	statusCheckpointSpace = newProperty(
+		subsystemPool,
+		`checkpoint_bytes`,
+		`Amount of space in bytes consumed by the checkpoint of the pool.`,
+		transformNumeric,
+		poolLabels...,
+	)
+	statusPermanentErrors = newProperty(
+		subsystemPool,
+		`permanent_errors`,
+		`Number of files and objects with permanent data errors in the pool, which the pool health does not reflect.`,
+		transformNumeric,
+		poolLabels...,
+	)
+	statusPermanentErrorInfo = newProperty(
+		subsystemPool,
+		`permanent_error_info`,
+		`File or object with permanent data errors, by dataset and path. The dataset of a file reported by its path is empty if not mounted, and the path of an object without one is its object number.`,
+		transformNumeric,
+		`pool`, `dataset`, `path`,
+	)
+	statusMessageInfo = newProperty(
+		subsystemPool,
+		`status_message_info`,
+		`Code of the message explaining the status of the pool and the action to take (e.g. ZFS-8000-8A), only reported for pools with an issue.`,
+		transformNumeric,
+		`pool`, `code`,
+	)
+)
+
+func init() {
+	registerCollector(`status`, defaultDisabled, ``, newStatusCollector)
+}
+
+// statusCollector reports the progress of long-running pool maintenance from `zpool status`, along with the
+// permanent errors of the pool
+type statusCollector struct {
+	log        *slog.Logger
+	client     zfs.Client
+	errorFiles bool
+	pathLength int
+}
+
+func (c *statusCollector) describe(ch chan<- *prometheus.Desc) {
+	for _, m := range []property{
+		statusTrimState, statusTrimProgress, statusInitializeState, statusInitializeProgress,
+		statusRemovalState, statusRemovalCopied, statusRemovalTotal, statusRemovalMapping,
+		statusCheckpointExists, statusCheckpointDiscarding, statusCheckpointSpace,
+		statusPermanentErrors, statusPermanentErrorInfo, statusMessageInfo,
+	} {
+		ch <- m.desc
+	}
+}
+
+func (c *statusCollector) update(ch chan<- metric, pools []string, excludes regexpCollection) error {
+	for _, pool := range pools {
+		status, err := c.client.Pool(pool).Status(c.errorFiles)
+		if err != nil {
+			return err
+		}
+		if err = c.updateVdevMetrics(ch, pool, status); err != nil {
+			return err
+		}
+		if err = c.updateRemovalMetrics(ch, pool, status); err != nil {
+			return err
+		}
+		c.updateCheckpointMetrics(ch, pool, status)
+		c.updateErrorMetrics(ch, pool, status)
+	}
+
+	return nil
+}
+
+func (c *statusCollector) updateVdevMetrics(ch chan<- metric, pool string, status zfs.Status) error {
+	for _, vdev := range status.Vdevs {
+		for _, op := range []struct {
+			state    property
+			progress property
+			value    zfs.VdevOperation
+		}{
+			{state: statusTrimState, progress: statusTrimProgress, value: vdev.Trim},
+			{state: statusInitializeState, progress: statusInitializeProgress, value: vdev.Initialize},
+		} {
+			// Operations are only reported for leaf vdevs.
+			if op.value.State == `` {
+				continue
+			}
+			state, err := transformOperationState(string(op.value.State))
+			if err != nil {
+				return err
+			}
+			op.state.pushValue(ch, state, pool, vdev.Name)
+			op.progress.pushValue(ch, op.value.Progress, pool, vdev.Name)
+		}
+	}
+
+	return nil
+}
+
+func (c *statusCollector) updateRemovalMetrics(ch chan<- metric, pool string, status zfs.Status) error {
+	removal := status.Removal
+	if removal == nil {
+		return nil
+	}
+	if removal.Vdev != `` {
+		state, err := transformOperationState(string(removal.State))
+		if err != nil {
+			return err
+		}
+		statusRemovalState.pushValue(ch, state, pool, removal.Vdev)
+		statusRemovalCopied.pushValue(ch, float64(removal.Copied), pool, removal.Vdev)
+		if removal.State == zfs.OperationActive {
+			statusRemovalTotal.pushValue(ch, float64(removal.Total), pool, removal.Vdev)
+		}
+	}
+	statusRemovalMapping.pushValue(ch, float64(removal.MappingMemory), pool)
+
+	return nil
+}
+
+func (c *statusCollector) updateCheckpointMetrics(ch chan<- metric, pool string, status zfs.Status) {
+	var exists, discarding, space float64
+	if status.Checkpoint != nil {
+		exists = 1
+		if status.Checkpoint.Discarding {
+			discarding = 1
+		}
+		space = float64(status.Checkpoint.Space)
+	}
+	statusCheckpointExists.pushValue(ch, exists, pool)
+	statusCheckpointDiscarding.pushValue(ch, discarding, pool)
+	statusCheckpointSpace.pushValue(ch, space, pool)
+}
+
+func (c *statusCollector) updateErrorMetrics(ch chan<- metric, pool string, status zfs.Status) {
+	if status.MessageCode != `` {
+		statusMessageInfo.pushValue(ch, 1, pool, status.MessageCode)
+	}
+	if status.Errors == nil {
+		return
+	}
+	statusPermanentErrors.pushValue(ch, float64(status.Errors.Count), pool)
+	if !c.errorFiles {
+		return
+	}
+	// Truncated paths may no longer be unique.
+	seen := make(map[zfs.PermanentError]struct{}, len(status.Errors.Files))
+	for _, file := range status.Errors.Files {
+		file.Path = truncatePath(file.Path, c.pathLength)
+		if _, ok := seen[file]; ok {
+			continue
+		}
+		seen[file] = struct{}{}
+		statusPermanentErrorInfo.pushValue(ch, 1, pool, file.Dataset, file.Path)
+	}
+}
+
+// truncatePath shortens the path to at most length characters, 0 or less for no limit
+func truncatePath(path string, length int) string {
+	if length <= 0 || utf8.RuneCountInString(path) <= length {
+		return path
+	}
+
+	return string([]rune(path)[:length])
+}
+
+func newStatusCollector(l *slog.Logger, c zfs.Client, props []string) (Collector, error) {
+	return &statusCollector{log: l, client: c, errorFiles: *statusErrorFiles, pathLength: *statusErrorPathLength}, nil
+}
@@ -0,0 +1,150 @@
+package collector
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/waitingsong/zfs_exporter/v3/zfs"
+	"github.com/waitingsong/zfs_exporter/v3/zfs/mock_zfs"
+)
+
+func TestStatusMetrics(t *testing.T) {
+	const result = `# HELP zfs_pool_checkpoint_bytes Amount of space in bytes consumed by the checkpoint of the pool.
+# TYPE zfs_pool_checkpoint_bytes gauge
+zfs_pool_checkpoint_bytes{pool="rpool"} 0
+zfs_pool_checkpoint_bytes{pool="tank"} 2.68435456e+08
+# HELP zfs_pool_checkpoint_discarding Whether the checkpoint of the pool is being discarded [0: no, 1: yes].
+# TYPE zfs_pool_checkpoint_discarding gauge
+zfs_pool_checkpoint_discarding{pool="rpool"} 0
+zfs_pool_checkpoint_discarding{pool="tank"} 0
+# HELP zfs_pool_checkpoint_exists Whether the pool has a checkpoint, including one being discarded [0: no, 1: yes].
+# TYPE zfs_pool_checkpoint_exists gauge
+zfs_pool_checkpoint_exists{pool="rpool"} 0
+zfs_pool_checkpoint_exists{pool="tank"} 1
+# HELP zfs_pool_removal_copied_bytes Amount of data in bytes evacuated by the last device removal from the pool.
+# TYPE zfs_pool_removal_copied_bytes gauge
+zfs_pool_removal_copied_bytes{pool="tank",vdev="sdd"} 1.073741824e+09
+# HELP zfs_pool_removal_mapping_memory_bytes Amount of memory in bytes used by the mappings of devices removed from the pool.
+# TYPE zfs_pool_removal_mapping_memory_bytes gauge
+zfs_pool_removal_mapping_memory_bytes{pool="tank"} 98816
+# HELP zfs_pool_removal_state State of the last device removal from the pool [0: none, 1: active, 2: suspended, 3: completed, 4: canceled, 5: unsupported].
+# TYPE zfs_pool_removal_state gauge
+zfs_pool_removal_state{pool="tank",vdev="sdd"} 1
+# HELP zfs_pool_removal_total_bytes Amount of data in bytes to evacuate by the active device removal from the pool.
+# TYPE zfs_pool_removal_total_bytes gauge
+zfs_pool_removal_total_bytes{pool="tank",vdev="sdd"} 4.294967296e+09
+# HELP zfs_vdev_initialize_progress_ratio Completed fraction of the last initialization of the vdev.
+# TYPE zfs_vdev_initialize_progress_ratio gauge
+zfs_vdev_initialize_progress_ratio{pool="tank",vdev="sda"} 0.5
+zfs_vdev_initialize_progress_ratio{pool="tank",vdev="sdb"} 0
+# HELP zfs_vdev_initialize_state State of the last initialization of the vdev [0: none, 1: active, 2: suspended, 3: completed, 4: canceled, 5: unsupported].
+# TYPE zfs_vdev_initialize_state gauge
+zfs_vdev_initialize_state{pool="tank",vdev="sda"} 2
+zfs_vdev_initialize_state{pool="tank",vdev="sdb"} 0
+# HELP zfs_vdev_trim_progress_ratio Completed fraction of the last TRIM of the vdev.
+# TYPE zfs_vdev_trim_progress_ratio gauge
+zfs_vdev_trim_progress_ratio{pool="rpool",vdev="nvme0n1"} 1
+zfs_vdev_trim_progress_ratio{pool="tank",vdev="sda"} 0.12
+zfs_vdev_trim_progress_ratio{pool="tank",vdev="sdb"} 0
+# HELP zfs_vdev_trim_state State of the last manual or automatic TRIM of the vdev [0: none, 1: active, 2: suspended, 3: completed, 4: canceled, 5: unsupported].
+# TYPE zfs_vdev_trim_state gauge
+zfs_vdev_trim_state{pool="rpool",vdev="nvme0n1"} 3
+zfs_vdev_trim_state{pool="tank",vdev="sda"} 1
+zfs_vdev_trim_state{pool="tank",vdev="sdb"} 5
+`
+
+	ctrl, ctx := gomock.WithContext(context.Background(), t)
+	zfsClient := mock_zfs.NewMockClient(ctrl)
+	zfsClient.EXPECT().PoolNames().Return([]string{`rpool`, `tank`}, nil).Times(1)
+	for pool, status := range map[string]zfs.Status{
+		`rpool`: {
+			Vdevs: []zfs.VdevStatus{
+				{Name: `rpool`, State: zfs.PoolOnline},
+				{Name: `nvme0n1`, State: zfs.PoolOnline, Trim: zfs.VdevOperation{State: zfs.OperationCompleted, Progress: 1}},
+			},
+		},
+		`tank`: {
+			Vdevs: []zfs.VdevStatus{
+				{Name: `tank`, State: zfs.PoolOnline},
+				{Name: `mirror-0`, State: zfs.PoolOnline},
+				{Name: `sda`, State: zfs.PoolOnline, Trim: zfs.VdevOperation{State: zfs.OperationActive, Progress: 0.12}, Initialize: zfs.VdevOperation{State: zfs.OperationSuspended, Progress: 0.5}},
+				{Name: `sdb`, State: zfs.PoolOnline, Trim: zfs.VdevOperation{State: zfs.OperationUnsupported}, Initialize: zfs.VdevOperation{State: zfs.OperationNone}},
+			},
+			Removal:    &zfs.RemovalStatus{Vdev: `sdd`, State: zfs.OperationActive, Copied: 1 << 30, Total: 4 << 30, MappingMemory: 98816},
+			Checkpoint: &zfs.CheckpointStatus{Space: 268435456},
+		},
+	} {
+		zfsPool := mock_zfs.NewMockPool(ctrl)
+		zfsPool.EXPECT().Status(false).Return(status, nil).Times(1)
+		zfsClient.EXPECT().Pool(pool).Return(zfsPool).Times(1)
+	}
+
+	collector := newTestZFS(t, zfsClient, `status`, ``, newStatusCollector)
+
+	metricNames := []string{
+		`zfs_pool_checkpoint_bytes`,
+		`zfs_pool_checkpoint_discarding`,
+		`zfs_pool_checkpoint_exists`,
+		`zfs_pool_removal_copied_bytes`,
+		`zfs_pool_removal_mapping_memory_bytes`,
+		`zfs_pool_removal_state`,
+		`zfs_pool_removal_total_bytes`,
+		`zfs_vdev_initialize_progress_ratio`,
+		`zfs_vdev_initialize_state`,
+		`zfs_vdev_trim_progress_ratio`,
+		`zfs_vdev_trim_state`,
+	}
+	collectAndCompare(t, ctx, collector, result, metricNames)
+}
+
+func TestStatusErrorMetrics(t *testing.T) {
+	const result = `# HELP zfs_pool_permanent_error_info File or object with permanent data errors, by dataset and path. The dataset of a file reported by its path is empty if not mounted, and the path of an object without one is its object number.
+# TYPE zfs_pool_permanent_error_info gauge
+zfs_pool_permanent_error_info{dataset="<metadata>",path="<0x3d>",pool="tank"} 1
+zfs_pool_permanent_error_info{dataset="tank/backup",path="/tank/backup/202",pool="tank"} 1
+zfs_pool_permanent_error_info{dataset="tank/backup",path="/tank/backup/my ",pool="tank"} 1
+# HELP zfs_pool_permanent_errors Number of files and objects with permanent data errors in the pool, which the pool health does not reflect.
+# TYPE zfs_pool_permanent_errors gauge
+zfs_pool_permanent_errors{pool="rpool"} 0
+zfs_pool_permanent_errors{pool="tank"} 4
+# HELP zfs_pool_status_message_info Code of the message explaining the status of the pool and the action to take (e.g. ZFS-8000-8A), only reported for pools with an issue.
+# TYPE zfs_pool_status_message_info gauge
+zfs_pool_status_message_info{code="ZFS-8000-8A",pool="tank"} 1
+`
+
+	ctrl, ctx := gomock.WithContext(context.Background(), t)
+	zfsClient := mock_zfs.NewMockClient(ctrl)
+	zfsClient.EXPECT().PoolNames().Return([]string{`rpool`, `tank`}, nil).Times(1)
+	for pool, status := range map[string]zfs.Status{
+		`rpool`: {Errors: &zfs.ErrorsStatus{}},
+		`tank`: {
+			MessageCode: `ZFS-8000-8A`,
+			Errors: &zfs.ErrorsStatus{
+				Count: 4,
+				Files: []zfs.PermanentError{
+					{Dataset: `tank/backup`, Path: `/tank/backup/2024-06-15.tar`},
+					{Dataset: `tank/backup`, Path: `/tank/backup/2024-06-16.tar`},
+					{Dataset: `tank/backup`, Path: `/tank/backup/my dir/notes.txt`},
+					{Dataset: `<metadata>`, Path: `<0x3d>`},
+				},
+			},
+		},
+	} {
+		zfsPool := mock_zfs.NewMockPool(ctrl)
+		zfsPool.EXPECT().Status(true).Return(status, nil).Times(1)
+		zfsClient.EXPECT().Pool(pool).Return(zfsPool).Times(1)
+	}
+
+	collector := newTestZFS(t, zfsClient, `status`, ``, func(l *slog.Logger, c zfs.Client, props []string) (Collector, error) {
+		return &statusCollector{log: l, client: c, errorFiles: true, pathLength: 16}, nil
+	})
+
+	metricNames := []string{
+		`zfs_pool_permanent_error_info`,
+		`zfs_pool_permanent_errors`,
+		`zfs_pool_status_message_info`,
+	}
+	collectAndCompare(t, ctx, collector, result, metricNames)
+}
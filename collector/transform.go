@@ -19,6 +19,25 @@ const (
 	poolSuspended
 )
 
+type operationStateCode int
+
+const (
+	operationNone operationStateCode = iota
+	operationActive
+	operationSuspended
+	operationCompleted
+	operationCanceled
+	operationUnsupported
+)
+
+type featureStateCode int
+
+const (
+	featureDisabled featureStateCode = iota
+	featureEnabled
+	featureActive
+)
+
 func transformNumeric(value string) (float64, error) {
 	if value == `-` || value == `none` {
 		return 0, nil
@@ -50,6 +69,44 @@ func transformHealthCode(status string) (float64, error) {
 	return float64(result), nil
 }
 
+func transformOperationState(state string) (float64, error) {
+	var result operationStateCode
+	switch zfs.OperationState(state) {
+	case zfs.OperationNone:
+		result = operationNone
+	case zfs.OperationActive:
+		result = operationActive
+	case zfs.OperationSuspended:
+		result = operationSuspended
+	case zfs.OperationCompleted:
+		result = operationCompleted
+	case zfs.OperationCanceled:
+		result = operationCanceled
+	case zfs.OperationUnsupported:
+		result = operationUnsupported
+	default:
+		return -1, fmt.Errorf(`unknown operation state: %s`, state)
+	}
+
+	return float64(result), nil
+}
+
+func transformFeatureState(state string) (float64, error) {
+	var result featureStateCode
+	switch state {
+	case `disabled`:
+		result = featureDisabled
+	case `enabled`:
+		result = featureEnabled
+	case `active`:
+		result = featureActive
+	default:
+		return -1, fmt.Errorf(`unknown feature state: %s`, state)
+	}
+
+	return float64(result), nil
+}
+
 func transformBool(value string) (float64, error) {
 	switch value {
 	case `on`, `yes`, `enabled`, `active`:
@@ -168,3 +225,15 @@ func transformPrimaryCache(value string) (float64, error) {
 
 	return -1, fmt.Errorf(`unknown primarycache: %s`, value)
 }
+
+// transformDedupQuota transforms the dedup_table_quota property, which is either a size, `auto` to limit the tables
+// to the size of the dedup vdevs, or `none`
+func transformDedupQuota(value string) (float64, error) {
+	if value == `auto` {
+		return -1, nil
+	}
+	if value == `none` {
+		return 0, nil
+	}
+	return strconv.ParseFloat(value, 64)
+}
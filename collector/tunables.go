@@ -0,0 +1,89 @@
+package collector
+
+import (
+	"errors"
+	"io/fs"
+	"log/slog"
+	"strconv"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/waitingsong/zfs_exporter/v3/zfs"
+)
+
+const (
+	defaultTunableProps = `zfs_arc_max,zfs_arc_min,zfs_arc_meta_limit_percent,zfs_dirty_data_max,zfs_dirty_data_max_percent,zfs_txg_timeout,zfs_vdev_async_write_max_active,zfs_vdev_sync_write_max_active,zfs_prefetch_disable,l2arc_write_max,zfs_vdev_raidz_impl,zfs_fletcher_4_impl`
+)
+
+var (
+	tunableModules = []string{`zfs`, `spl`}
+	tunableLabels  = []string{`module`, `parameter`}
+
+	tunableValueDescName = prometheus.BuildFQName(namespace, subsystemTunable, `value`)
+	tunableValueDesc     = prometheus.NewDesc(
+		tunableValueDescName,
+		`Value of a numeric kernel module parameter.`,
+		tunableLabels,
+		nil,
+	)
+	tunableInfoDescName = prometheus.BuildFQName(namespace, subsystemTunable, `info`)
+	tunableInfoDesc     = prometheus.NewDesc(
+		tunableInfoDescName,
+		`Value of a string kernel module parameter, as a label.`,
+		[]string{`module`, `parameter`, `value`},
+		nil,
+	)
+)
+
+func init() {
+	registerCollector(`tunables`, defaultDisabled, defaultTunableProps, newTunablesCollector)
+}
+
+// tunablesCollector reports the kernel module parameters matching the requested properties, which may contain '*'
+// wildcards
+type tunablesCollector struct {
+	log    *slog.Logger
+	client zfs.Client
+	props  []string
+}
+
+func (c *tunablesCollector) describe(ch chan<- *prometheus.Desc) {
+	ch <- tunableValueDesc
+	ch <- tunableInfoDesc
+}
+
+func (c *tunablesCollector) update(ch chan<- metric, pools []string, excludes regexpCollection) error {
+	for _, module := range tunableModules {
+		values, err := c.client.Tunables(module, c.props)
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				// The module may not be loaded, or may be built into another (e.g. the SPL on FreeBSD).
+				c.log.Debug("Kernel module parameters unavailable", "module", module, "err", err)
+				continue
+			}
+			return err
+		}
+		for name, v := range values {
+			c.push(ch, module, name, v)
+		}
+	}
+
+	return nil
+}
+
+func (c *tunablesCollector) push(ch chan<- metric, module, name, value string) {
+	if v, err := strconv.ParseFloat(value, 64); err == nil {
+		ch <- metric{
+			name:       expandMetricName(tunableValueDescName, module, name),
+			prometheus: prometheus.MustNewConstMetric(tunableValueDesc, prometheus.GaugeValue, v, module, name),
+		}
+		return
+	}
+	ch <- metric{
+		name:       expandMetricName(tunableInfoDescName, module, name),
+		prometheus: prometheus.MustNewConstMetric(tunableInfoDesc, prometheus.GaugeValue, 1, module, name, value),
+	}
+}
+
+func newTunablesCollector(l *slog.Logger, c zfs.Client, props []string) (Collector, error) {
+	return &tunablesCollector{log: l, client: c, props: props}, nil
+}
@@ -0,0 +1,77 @@
+package collector
+
+import (
+	"context"
+	"io/fs"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/waitingsong/zfs_exporter/v3/zfs/mock_zfs"
+)
+
+func TestTunablesMetrics(t *testing.T) {
+	testCases := []struct {
+		name           string
+		propsRequested []string
+		metricNames    []string
+		zfsResults     map[string]string
+		splResults     map[string]string
+		metricResults  string
+	}{
+		{
+			name:           `numeric and string parameters`,
+			propsRequested: []string{`zfs_arc_*`, `zfs_vdev_raidz_impl`, `spl_kmem_cache_max_size`},
+			metricNames:    []string{`zfs_tunable_value`, `zfs_tunable_info`},
+			zfsResults: map[string]string{
+				`zfs_arc_max`:         `8589934592`,
+				`zfs_arc_min`:         `0`,
+				`zfs_vdev_raidz_impl`: `cycle [fastest] original scalar sse2 ssse3 avx2`,
+			},
+			splResults: map[string]string{
+				`spl_kmem_cache_max_size`: `32`,
+			},
+			metricResults: `# HELP zfs_tunable_info Value of a string kernel module parameter, as a label.
+# TYPE zfs_tunable_info gauge
+zfs_tunable_info{module="zfs",parameter="zfs_vdev_raidz_impl",value="cycle [fastest] original scalar sse2 ssse3 avx2"} 1
+# HELP zfs_tunable_value Value of a numeric kernel module parameter.
+# TYPE zfs_tunable_value gauge
+zfs_tunable_value{module="spl",parameter="spl_kmem_cache_max_size"} 32
+zfs_tunable_value{module="zfs",parameter="zfs_arc_max"} 8.589934592e+09
+zfs_tunable_value{module="zfs",parameter="zfs_arc_min"} 0
+`,
+		},
+		{
+			name:           `missing module`,
+			propsRequested: []string{`zfs_txg_timeout`},
+			metricNames:    []string{`zfs_tunable_value`},
+			zfsResults: map[string]string{
+				`zfs_txg_timeout`: `5`,
+			},
+			metricResults: `# HELP zfs_tunable_value Value of a numeric kernel module parameter.
+# TYPE zfs_tunable_value gauge
+zfs_tunable_value{module="zfs",parameter="zfs_txg_timeout"} 5
+`,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			ctrl, ctx := gomock.WithContext(context.Background(), t)
+			zfsClient := mock_zfs.NewMockClient(ctrl)
+			zfsClient.EXPECT().PoolNames().Return([]string{`testpool`}, nil).Times(1)
+			zfsClient.EXPECT().Tunables(`zfs`, tc.propsRequested).Return(tc.zfsResults, nil).Times(1)
+			if tc.splResults != nil {
+				zfsClient.EXPECT().Tunables(`spl`, tc.propsRequested).Return(tc.splResults, nil).Times(1)
+			} else {
+				zfsClient.EXPECT().Tunables(`spl`, tc.propsRequested).Return(nil, fs.ErrNotExist).Times(1)
+			}
+
+			collector := newTestZFS(t, zfsClient, `tunables`, strings.Join(tc.propsRequested, `,`), newTunablesCollector)
+
+			collectAndCompare(t, ctx, collector, tc.metricResults, tc.metricNames)
+		})
+	}
+}
@@ -0,0 +1,334 @@
+package collector
+
+import (
+	"fmt"
+	"log/slog"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/waitingsong/zfs_exporter/v3/zfs"
+)
+
+const (
+	defaultTXGProps = `dmu_tx_assigned,dmu_tx_delay,dmu_tx_error,dmu_tx_suspended,dmu_tx_memory_reclaim,dmu_tx_dirty_throttle,dmu_tx_dirty_delay,dmu_tx_dirty_over_max,dmu_tx_wrlog_over_max`
+
+	txgStateCommitted = `C`
+)
+
+var (
+	txgByteBuckets = prometheus.ExponentialBuckets(1<<20, 2, 14)
+	txgOpBuckets   = prometheus.ExponentialBuckets(1, 4, 12)
+
+	// txgHistograms maps columns of the txgs kstat to histograms of committed transaction groups
+	txgHistograms = []struct {
+		column  string
+		metric  histogramMetric
+		buckets []float64
+		scale   float64
+	}{
+		{
+			column:  `stime`,
+			metric:  newHistogramMetric(subsystemTXG, `sync_duration_seconds`, `Duration in seconds of the sync phase of transaction groups committed since the exporter started.`, poolLabels...),
+			buckets: prometheus.ExponentialBuckets(0.001, 2, 16),
+			scale:   1e-9,
+		},
+		{
+			column:  `ndirty`,
+			metric:  newHistogramMetric(subsystemTXG, `dirty_bytes`, `Amount of dirty data in bytes of transaction groups committed since the exporter started.`, poolLabels...),
+			buckets: txgByteBuckets,
+			scale:   1,
+		},
+		{
+			column:  `nread`,
+			metric:  newHistogramMetric(subsystemTXG, `read_bytes`, `Amount of data in bytes read while syncing transaction groups committed since the exporter started.`, poolLabels...),
+			buckets: txgByteBuckets,
+			scale:   1,
+		},
+		{
+			column:  `nwritten`,
+			metric:  newHistogramMetric(subsystemTXG, `written_bytes`, `Amount of data in bytes written while syncing transaction groups committed since the exporter started.`, poolLabels...),
+			buckets: txgByteBuckets,
+			scale:   1,
+		},
+		{
+			column:  `reads`,
+			metric:  newHistogramMetric(subsystemTXG, `reads`, `Number of read operations while syncing transaction groups committed since the exporter started.`, poolLabels...),
+			buckets: txgOpBuckets,
+			scale:   1,
+		},
+		{
+			column:  `writes`,
+			metric:  newHistogramMetric(subsystemTXG, `writes`, `Number of write operations while syncing transaction groups committed since the exporter started.`, poolLabels...),
+			buckets: txgOpBuckets,
+			scale:   1,
+		},
+	}
+	txgAssignMetric = newHistogramMetric(subsystemTXG, `assign_duration_seconds`, `Duration in seconds of transaction assignments to a transaction group, since the pool was imported. As the kstat only holds bucket counts, the sum is estimated from bucket upper bounds and overstates the actual durations.`, poolLabels...)
+
+	// dmuTxProperties are read from the global dmu_tx kstat, as OpenZFS only counts the write throttle host-wide
+	dmuTxProperties = propertyStore{
+		defaultSubsystem: subsystemDMUTx,
+		store: map[string]property{
+			`dmu_tx_assigned`: newProperty(
+				subsystemDMUTx,
+				`assigned_total`,
+				`Number of transactions successfully assigned to a transaction group.`,
+				transformNumeric,
+			).withValueType(prometheus.CounterValue),
+			`dmu_tx_delay`: newProperty(
+				subsystemDMUTx,
+				`delay_total`,
+				`Number of transaction assignments which were delayed.`,
+				transformNumeric,
+			).withValueType(prometheus.CounterValue),
+			`dmu_tx_error`: newProperty(
+				subsystemDMUTx,
+				`error_total`,
+				`Number of transaction assignments which failed with an error.`,
+				transformNumeric,
+			).withValueType(prometheus.CounterValue),
+			`dmu_tx_suspended`: newProperty(
+				subsystemDMUTx,
+				`suspended_total`,
+				`Number of transaction assignments which waited on a suspended pool.`,
+				transformNumeric,
+			).withValueType(prometheus.CounterValue),
+			`dmu_tx_group`: newProperty(
+				subsystemDMUTx,
+				`group_total`,
+				`Number of transaction assignments which waited for the next transaction group to open.`,
+				transformNumeric,
+			).withValueType(prometheus.CounterValue),
+			`dmu_tx_memory_reserve`: newProperty(
+				subsystemDMUTx,
+				`memory_reserve_total`,
+				`Number of transaction assignments which waited for an ARC memory reservation.`,
+				transformNumeric,
+			).withValueType(prometheus.CounterValue),
+			`dmu_tx_memory_reclaim`: newProperty(
+				subsystemDMUTx,
+				`memory_reclaim_total`,
+				`Number of transaction assignments which waited for ARC memory to be reclaimed.`,
+				transformNumeric,
+			).withValueType(prometheus.CounterValue),
+			`dmu_tx_dirty_throttle`: newProperty(
+				subsystemDMUTx,
+				`dirty_throttle_total`,
+				`Number of transaction assignments which were throttled, as dirty data reached zfs_dirty_data_max.`,
+				transformNumeric,
+			).withValueType(prometheus.CounterValue),
+			`dmu_tx_dirty_delay`: newProperty(
+				subsystemDMUTx,
+				`dirty_delay_total`,
+				`Number of transaction assignments which were delayed by the write throttle, as dirty data exceeded zfs_delay_min_dirty_percent.`,
+				transformNumeric,
+			).withValueType(prometheus.CounterValue),
+			`dmu_tx_dirty_over_max`: newProperty(
+				subsystemDMUTx,
+				`dirty_over_max_total`,
+				`Number of transaction assignments which waited, as dirty data exceeded zfs_dirty_data_max.`,
+				transformNumeric,
+			).withValueType(prometheus.CounterValue),
+			`dmu_tx_dirty_frees_delay`: newProperty(
+				subsystemDMUTx,
+				`dirty_frees_delay_total`,
+				`Number of transaction assignments which were delayed by the throttle on pending frees.`,
+				transformNumeric,
+			).withValueType(prometheus.CounterValue),
+			`dmu_tx_wrlog_over_max`: newProperty(
+				subsystemDMUTx,
+				`wrlog_over_max_total`,
+				`Number of transaction assignments which waited, as the write log exceeded zfs_wrlog_data_max.`,
+				transformNumeric,
+			).withValueType(prometheus.CounterValue),
+			`dmu_tx_quota`: newProperty(
+				subsystemDMUTx,
+				`quota_total`,
+				`Number of transaction assignments which failed due to a quota.`,
+				transformNumeric,
+			).withValueType(prometheus.CounterValue),
+		},
+	}
+)
+
+func init() {
+	registerCollector(`txg`, defaultDisabled, defaultTXGProps, newTXGCollector)
+}
+
+// txgHistory accumulates committed transaction groups across runs, as the txgs kstat only holds recent history
+type txgHistory struct {
+	lastTXG    uint64
+	histograms []*histogram
+}
+
+func newTXGHistory() *txgHistory {
+	h := &txgHistory{histograms: make([]*histogram, len(txgHistograms))}
+	for i, v := range txgHistograms {
+		h.histograms[i] = newHistogram(v.buckets)
+	}
+
+	return h
+}
+
+type txgCollector struct {
+	log     *slog.Logger
+	client  zfs.Client
+	props   []string
+	history map[string]*txgHistory
+}
+
+func (c *txgCollector) describe(ch chan<- *prometheus.Desc) {
+	for _, h := range txgHistograms {
+		ch <- h.metric.desc
+	}
+	ch <- txgAssignMetric.desc
+	for _, k := range c.props {
+		prop, err := dmuTxProperties.find(k)
+		if err != nil {
+			c.log.Warn(propertyUnsupportedMsg, `help`, helpIssue, `collector`, `txg`, `property`, k, `err`, err)
+			continue
+		}
+		ch <- prop.desc
+	}
+}
+
+func (c *txgCollector) update(ch chan<- metric, pools []string, excludes regexpCollection) error {
+	for _, pool := range pools {
+		if err := c.updateTXGMetrics(ch, pool); err != nil {
+			return err
+		}
+		if err := c.updateAssignMetrics(ch, pool); err != nil {
+			return err
+		}
+	}
+	// Drop the history of pools which are gone, a pool imported again under the same name starts over.
+	for pool := range c.history {
+		if !slices.Contains(pools, pool) {
+			delete(c.history, pool)
+		}
+	}
+
+	return c.updateDMUTxMetrics(ch)
+}
+
+func (c *txgCollector) updateTXGMetrics(ch chan<- metric, pool string) error {
+	rows, err := c.client.KstatTable(pool + `/txgs`)
+	if err != nil {
+		return err
+	}
+
+	history, ok := c.history[pool]
+	if !ok {
+		history = newTXGHistory()
+		c.history[pool] = history
+	}
+
+	var maxTXG uint64
+	for _, row := range rows {
+		txg, err := strconv.ParseUint(row[`txg`], 10, 64)
+		if err != nil {
+			return err
+		}
+		maxTXG = max(maxTXG, txg)
+	}
+	if maxTXG < history.lastTXG {
+		// The pool has been re-created, start over.
+		history = newTXGHistory()
+		c.history[pool] = history
+	}
+
+	lastTXG := history.lastTXG
+	for _, row := range rows {
+		txg, _ := strconv.ParseUint(row[`txg`], 10, 64)
+		if row[`state`] != txgStateCommitted || txg <= lastTXG {
+			continue
+		}
+		for i, h := range txgHistograms {
+			v, err := strconv.ParseFloat(row[h.column], 64)
+			if err != nil {
+				return err
+			}
+			history.histograms[i].observe(v * h.scale)
+		}
+		history.lastTXG = max(history.lastTXG, txg)
+	}
+
+	for i, h := range txgHistograms {
+		h.metric.push(ch, history.histograms[i], pool)
+	}
+
+	return nil
+}
+
+func (c *txgCollector) updateAssignMetrics(ch chan<- metric, pool string) error {
+	values, err := c.client.Kstat(pool + `/dmu_tx_assign`)
+	if err != nil {
+		return err
+	}
+
+	// Each bucket counts the assignments which took at most the named duration, and longer than the previous bucket.
+	upperBounds := make([]float64, 0, len(values))
+	counts := make(map[float64]uint64, len(values))
+	for k, v := range values {
+		ns, found := strings.CutSuffix(k, ` ns`)
+		if !found {
+			return fmt.Errorf("unknown dmu_tx_assign bucket '%s'", k)
+		}
+		upper, err := strconv.ParseFloat(ns, 64)
+		if err != nil {
+			return err
+		}
+		count, err := strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			return err
+		}
+		upper *= 1e-9
+		upperBounds = append(upperBounds, upper)
+		counts[upper] = count
+	}
+	sort.Float64s(upperBounds)
+
+	h := newHistogram(upperBounds)
+	for i, upper := range upperBounds {
+		h.counts[i] = counts[upper]
+		h.count += counts[upper]
+		h.sum += float64(counts[upper]) * upper
+	}
+	txgAssignMetric.push(ch, h, pool)
+
+	return nil
+}
+
+// updateDMUTxMetrics reports the write throttle counters, which carry no pool label as they are only kept host-wide
+func (c *txgCollector) updateDMUTxMetrics(ch chan<- metric) error {
+	if len(c.props) == 0 {
+		return nil
+	}
+	values, err := c.client.Kstat(`dmu_tx`)
+	if err != nil {
+		return err
+	}
+	for _, k := range c.props {
+		v, ok := values[k]
+		if !ok {
+			// Not all kstat fields are available in all versions of OpenZFS.
+			continue
+		}
+		prop, err := dmuTxProperties.find(k)
+		if err != nil {
+			c.log.Warn(propertyUnsupportedMsg, `help`, helpIssue, `collector`, `txg`, `property`, k, `err`, err)
+		}
+		if err = prop.push(ch, v); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func newTXGCollector(l *slog.Logger, c zfs.Client, props []string) (Collector, error) {
+	return &txgCollector{log: l, client: c, props: props, history: make(map[string]*txgHistory)}, nil
+}
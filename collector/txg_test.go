@@ -0,0 +1,120 @@
+package collector
+
+import (
+	"context"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/waitingsong/zfs_exporter/v3/zfs/mock_zfs"
+)
+
+func txgRow(txg, state, ndirty, writes, stime string) map[string]string {
+	return map[string]string{
+		`txg`:      txg,
+		`state`:    state,
+		`ndirty`:   ndirty,
+		`nread`:    `0`,
+		`nwritten`: `0`,
+		`reads`:    `0`,
+		`writes`:   writes,
+		`stime`:    stime,
+	}
+}
+
+func TestTXGMetrics(t *testing.T) {
+	const assignResults = `# HELP zfs_dmu_tx_dirty_delay_total Number of transaction assignments which were delayed by the write throttle, as dirty data exceeded zfs_delay_min_dirty_percent.
+# TYPE zfs_dmu_tx_dirty_delay_total counter
+zfs_dmu_tx_dirty_delay_total 21
+# HELP zfs_txg_assign_duration_seconds Duration in seconds of transaction assignments to a transaction group, since the pool was imported. As the kstat only holds bucket counts, the sum is estimated from bucket upper bounds and overstates the actual durations.
+# TYPE zfs_txg_assign_duration_seconds histogram
+zfs_txg_assign_duration_seconds_bucket{pool="testpool",le="2.56e-07"} 0
+zfs_txg_assign_duration_seconds_bucket{pool="testpool",le="5.12e-07"} 3
+zfs_txg_assign_duration_seconds_bucket{pool="testpool",le="1.024e-06"} 13
+zfs_txg_assign_duration_seconds_bucket{pool="testpool",le="2.048e-06"} 14
+zfs_txg_assign_duration_seconds_bucket{pool="testpool",le="+Inf"} 14
+zfs_txg_assign_duration_seconds_sum{pool="testpool"} 1.3824e-05
+zfs_txg_assign_duration_seconds_count{pool="testpool"} 14
+`
+	runs := []struct {
+		rows      []map[string]string
+		wantCount uint64
+		wantSum   float64
+	}{
+		{
+			rows: []map[string]string{
+				txgRow(`100`, `C`, `1048576`, `10`, `1000000`),
+				txgRow(`101`, `C`, `2097152`, `20`, `2000000`),
+				txgRow(`102`, `S`, `4194304`, `0`, `0`),
+				txgRow(`103`, `O`, `0`, `0`, `0`),
+			},
+			wantCount: 2,
+			wantSum:   30,
+		},
+		{
+			// Transaction groups already observed are not counted again.
+			rows: []map[string]string{
+				txgRow(`101`, `C`, `2097152`, `20`, `2000000`),
+				txgRow(`102`, `C`, `4194304`, `40`, `3000000`),
+				txgRow(`103`, `S`, `0`, `0`, `0`),
+				txgRow(`104`, `O`, `0`, `0`, `0`),
+			},
+			wantCount: 3,
+			wantSum:   70,
+		},
+	}
+
+	ctrl, ctx := gomock.WithContext(context.Background(), t)
+	zfsClient := mock_zfs.NewMockClient(ctrl)
+	zfsClient.EXPECT().PoolNames().Return([]string{`testpool`}, nil).Times(len(runs) + 1)
+	zfsClient.EXPECT().Kstat(`testpool/dmu_tx_assign`).Return(map[string]string{`256 ns`: `0`, `512 ns`: `3`, `1024 ns`: `10`, `2048 ns`: `1`}, nil).Times(len(runs) + 1)
+	zfsClient.EXPECT().Kstat(`dmu_tx`).Return(map[string]string{`dmu_tx_dirty_delay`: `21`}, nil).Times(len(runs) + 1)
+
+	collector := newTestZFS(t, zfsClient, `txg`, `dmu_tx_dirty_delay`, newTXGCollector)
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(collector)
+
+	for _, run := range runs {
+		zfsClient.EXPECT().KstatTable(`testpool/txgs`).Return(run.rows, nil).Times(1)
+		families, err := registry.Gather()
+		if err != nil {
+			t.Fatal(err)
+		}
+		found := false
+		for _, family := range families {
+			if family.GetName() != `zfs_txg_writes` {
+				continue
+			}
+			found = true
+			h := family.GetMetric()[0].GetHistogram()
+			if h.GetSampleCount() != run.wantCount || h.GetSampleSum() != run.wantSum {
+				t.Errorf(`got count %d and sum %f, want count %d and sum %f`, h.GetSampleCount(), h.GetSampleSum(), run.wantCount, run.wantSum)
+			}
+		}
+		if !found {
+			t.Error(`missing zfs_txg_writes histogram`)
+		}
+	}
+
+	zfsClient.EXPECT().KstatTable(`testpool/txgs`).Return(runs[len(runs)-1].rows, nil).Times(1)
+	collectAndCompare(t, ctx, collector, assignResults, []string{`zfs_txg_assign_duration_seconds`, `zfs_dmu_tx_dirty_delay_total`})
+}
+
+func TestTXGHistoryPruned(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	zfsClient := mock_zfs.NewMockClient(ctrl)
+	zfsClient.EXPECT().KstatTable(`testpool/txgs`).Return([]map[string]string{txgRow(`100`, `C`, `1048576`, `10`, `1000000`)}, nil).Times(1)
+	zfsClient.EXPECT().Kstat(`testpool/dmu_tx_assign`).Return(map[string]string{`256 ns`: `1`}, nil).Times(1)
+
+	collector := &txgCollector{log: logger, client: zfsClient, history: map[string]*txgHistory{`gone`: newTXGHistory()}}
+	ch := make(chan metric, 100)
+	if err := collector.update(ch, []string{`testpool`}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := collector.history[`gone`]; ok {
+		t.Error(`history of the exported pool was kept`)
+	}
+	if _, ok := collector.history[`testpool`]; !ok {
+		t.Error(`missing history of testpool`)
+	}
+}
@@ -0,0 +1,131 @@
+package collector
+
+import (
+	"log/slog"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/waitingsong/zfs_exporter/v3/zfs"
+)
+
+const (
+	defaultVdevProps = `allocated,capacity,expandsize,fragmentation,free,size`
+)
+
+var (
+	vdevSpaceLabels = []string{`pool`, `vdev`, `class`}
+	vdevProperties  = propertyStore{
+		defaultSubsystem: subsystemVdev,
+		defaultLabels:    vdevSpaceLabels,
+		store: map[string]property{
+			`allocated`: newProperty(
+				subsystemVdev,
+				`allocated_bytes`,
+				`Amount of storage in bytes allocated on the top-level vdev.`,
+				transformNumeric,
+				vdevSpaceLabels...,
+			),
+			`capacity`: newProperty(
+				subsystemVdev,
+				`capacity_ratio`,
+				`Ratio of the top-level vdev space used.`,
+				transformPercentage,
+				vdevSpaceLabels...,
+			),
+			`checkpoint`: newProperty(
+				subsystemVdev,
+				`checkpoint_bytes`,
+				`Amount of space in bytes held by the pool checkpoint on the top-level vdev.`,
+				transformNumeric,
+				vdevSpaceLabels...,
+			),
+			`expandsize`: newProperty(
+				subsystemVdev,
+				`expand_size_bytes`,
+				`Amount of uninitialized space within the top-level vdev that can be used to increase its capacity.`,
+				transformNumeric,
+				vdevSpaceLabels...,
+			),
+			`fragmentation`: newProperty(
+				subsystemVdev,
+				`fragmentation_ratio`,
+				`The fragmentation ratio of the free space of the top-level vdev.`,
+				transformPercentage,
+				vdevSpaceLabels...,
+			),
+			`free`: newProperty(
+				subsystemVdev,
+				`free_bytes`,
+				`Amount of free space in bytes on the top-level vdev.`,
+				transformNumeric,
+				vdevSpaceLabels...,
+			),
+			`health`: newProperty(
+				subsystemVdev,
+				`health`,
+				`Health status code for the top-level vdev [0: ONLINE, 1: DEGRADED, 2: FAULTED, 3: OFFLINE, 4: UNAVAIL, 5: REMOVED, 6: SUSPENDED].`,
+				transformHealthCode,
+				vdevSpaceLabels...,
+			),
+			`size`: newProperty(
+				subsystemVdev,
+				`size_bytes`,
+				`Total size in bytes of the top-level vdev.`,
+				transformNumeric,
+				vdevSpaceLabels...,
+			),
+		},
+	}
+)
+
+func init() {
+	registerCollector(`vdev`, defaultDisabled, defaultVdevProps, newVdevCollector, withPropertyStore(&vdevProperties))
+}
+
+// vdevCollector reports the space accounting of top-level vdevs from `zpool list -v`, so that an allocation class
+// (e.g. a special vdev) filling up is noticed before the pool as a whole
+type vdevCollector struct {
+	log    *slog.Logger
+	client zfs.Client
+	props  []string
+}
+
+func (c *vdevCollector) describe(ch chan<- *prometheus.Desc) {
+	for _, k := range c.props {
+		prop, err := vdevProperties.find(k)
+		if err != nil {
+			c.log.Warn(propertyUnsupportedMsg, `help`, helpIssue, `collector`, `vdev`, `property`, k, `err`, err)
+			continue
+		}
+		ch <- prop.desc
+	}
+}
+
+func (c *vdevCollector) update(ch chan<- metric, pools []string, excludes regexpCollection) error {
+	for _, pool := range pools {
+		vdevs, err := c.client.Pool(pool).VdevSpace()
+		if err != nil {
+			return err
+		}
+		for _, vdev := range vdevs {
+			for _, k := range c.props {
+				v, ok := vdev.Properties[k]
+				if !ok {
+					continue
+				}
+				prop, err := vdevProperties.find(k)
+				if err != nil {
+					c.log.Warn(propertyUnsupportedMsg, `help`, helpIssue, `collector`, `vdev`, `property`, k, `err`, err)
+				}
+				if err = prop.push(ch, v, pool, vdev.Name, string(vdev.Class)); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+func newVdevCollector(l *slog.Logger, c zfs.Client, props []string) (Collector, error) {
+	return &vdevCollector{log: l, client: c, props: props}, nil
+}
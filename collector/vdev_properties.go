@@ -0,0 +1,205 @@
+package collector
+
+import (
+	"log/slog"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/waitingsong/zfs_exporter/v3/zfs"
+)
+
+const (
+	defaultVdevPropertiesProps = `allocating,removing,read_errors,write_errors,checksum_errors,checksum_n,checksum_t,io_n,io_t`
+)
+
+var (
+	vdevPropertiesStore = propertyStore{
+		defaultSubsystem: subsystemVdev,
+		defaultLabels:    vdevLabels,
+		store: map[string]property{
+			`allocating`: newProperty(
+				subsystemVdev,
+				`allocating`,
+				`Whether new data may be allocated on the top-level vdev [0: off, 1: on].`,
+				transformBool,
+				vdevLabels...,
+			).withMinVersion(2, 2),
+			`removing`: newProperty(
+				subsystemVdev,
+				`removing`,
+				`Whether the vdev is being removed from the pool [0: off, 1: on].`,
+				transformBool,
+				vdevLabels...,
+			).withMinVersion(2, 2),
+			`failfast`: newProperty(
+				subsystemVdev,
+				`failfast`,
+				`Whether I/O to the vdev fails fast, rather than being retried by the lower layers [0: off, 1: on].`,
+				transformBool,
+				vdevLabels...,
+			).withTier(tierStatic).withMinVersion(2, 2),
+			`read_errors`: newProperty(
+				subsystemVdev,
+				`read_errors_total`,
+				`Number of read errors of the vdev, since the errors were last cleared.`,
+				transformNumeric,
+				vdevLabels...,
+			).withValueType(prometheus.CounterValue).withMinVersion(2, 2),
+			`write_errors`: newProperty(
+				subsystemVdev,
+				`write_errors_total`,
+				`Number of write errors of the vdev, since the errors were last cleared.`,
+				transformNumeric,
+				vdevLabels...,
+			).withValueType(prometheus.CounterValue).withMinVersion(2, 2),
+			`checksum_errors`: newProperty(
+				subsystemVdev,
+				`checksum_errors_total`,
+				`Number of checksum errors of the vdev, since the errors were last cleared.`,
+				transformNumeric,
+				vdevLabels...,
+			).withValueType(prometheus.CounterValue).withMinVersion(2, 2),
+			`initialize_errors`: newProperty(
+				subsystemVdev,
+				`initialize_errors_total`,
+				`Number of errors while initializing the vdev.`,
+				transformNumeric,
+				vdevLabels...,
+			).withValueType(prometheus.CounterValue).withMinVersion(2, 2),
+			`checksum_n`: newProperty(
+				subsystemVdev,
+				`checksum_n`,
+				`Number of checksum errors within checksum_t seconds after which ZED faults the vdev.`,
+				transformNumeric,
+				vdevLabels...,
+			).withTier(tierStatic).withMinVersion(2, 2),
+			`checksum_t`: newProperty(
+				subsystemVdev,
+				`checksum_t_seconds`,
+				`Period in seconds over which checksum errors are counted towards checksum_n.`,
+				transformNumeric,
+				vdevLabels...,
+			).withTier(tierStatic).withMinVersion(2, 2),
+			`io_n`: newProperty(
+				subsystemVdev,
+				`io_n`,
+				`Number of I/O errors within io_t seconds after which ZED faults the vdev.`,
+				transformNumeric,
+				vdevLabels...,
+			).withTier(tierStatic).withMinVersion(2, 2),
+			`io_t`: newProperty(
+				subsystemVdev,
+				`io_t_seconds`,
+				`Period in seconds over which I/O errors are counted towards io_n.`,
+				transformNumeric,
+				vdevLabels...,
+			).withTier(tierStatic).withMinVersion(2, 2),
+			`slow_io_n`: newProperty(
+				subsystemVdev,
+				`slow_io_n`,
+				`Number of slow I/Os within slow_io_t seconds after which ZED degrades the vdev.`,
+				transformNumeric,
+				vdevLabels...,
+			).withTier(tierStatic).withMinVersion(2, 3),
+			`slow_io_t`: newProperty(
+				subsystemVdev,
+				`slow_io_t_seconds`,
+				`Period in seconds over which slow I/Os are counted towards slow_io_n.`,
+				transformNumeric,
+				vdevLabels...,
+			).withTier(tierStatic).withMinVersion(2, 3),
+		},
+	}
+)
+
+func init() {
+	registerCollector(`vdev-properties`, defaultDisabled, defaultVdevPropertiesProps, newVdevPropertiesCollector, withPropertyStore(&vdevPropertiesStore))
+}
+
+// vdevPropertiesCollector reports the properties of every vdev of a pool, including its root vdev. Properties which do
+// not apply to a vdev (e.g. allocating on a leaf) or are unset are reported as `-`, and skipped.
+type vdevPropertiesCollector struct {
+	log    *slog.Logger
+	client zfs.Client
+	props  propertyTiers
+	static *propertyCache
+}
+
+func (c *vdevPropertiesCollector) expire(pool string) {
+	c.static.expire(pool)
+}
+
+func (c *vdevPropertiesCollector) describe(ch chan<- *prometheus.Desc) {
+	for _, k := range c.props.all {
+		prop, err := vdevPropertiesStore.find(k)
+		if err != nil {
+			c.log.Warn(propertyUnsupportedMsg, `help`, helpIssue, `collector`, `vdev-properties`, `property`, k, `err`, err)
+			continue
+		}
+		ch <- prop.desc
+	}
+}
+
+func (c *vdevPropertiesCollector) update(ch chan<- metric, pools []string, excludes regexpCollection) error {
+	for _, pool := range pools {
+		if err := c.updateVdevMetrics(ch, pool); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (c *vdevPropertiesCollector) updateVdevMetrics(ch chan<- metric, pool string) error {
+	refresh := c.static.due(pool, *staticPropertiesInterval)
+	query := c.props.all
+	if !refresh {
+		query = c.props.volatile
+	}
+
+	values := make(map[string]map[string]string)
+	if len(query) > 0 {
+		vdevs, err := c.client.Pool(pool).VdevProperties(query...)
+		if err != nil {
+			return err
+		}
+		for _, vdev := range vdevs {
+			values[vdev.VdevName()] = vdev.Properties()
+		}
+	}
+	if refresh {
+		static := make(map[string]map[string]string, len(values))
+		for name, v := range values {
+			static[name] = c.props.staticValues(v)
+		}
+		c.static.store(pool, static)
+	} else {
+		for name, v := range c.static.load(pool) {
+			values[name] = mergeProperties(values[name], v)
+		}
+	}
+
+	for name, props := range values {
+		for k, v := range props {
+			if v == `-` {
+				continue
+			}
+			prop, err := vdevPropertiesStore.find(k)
+			if err != nil {
+				c.log.Warn(propertyUnsupportedMsg, `help`, helpIssue, `collector`, `vdev-properties`, `property`, k, `err`, err)
+			}
+			if err = prop.push(ch, v, pool, name); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func newVdevPropertiesCollector(l *slog.Logger, c zfs.Client, props []string) (Collector, error) {
+	tiers, err := vdevPropertiesStore.tiers(props)
+	if err != nil {
+		return nil, err
+	}
+	return &vdevPropertiesCollector{log: l, client: c, props: tiers, static: newPropertyCache()}, nil
+}
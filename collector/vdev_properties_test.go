@@ -0,0 +1,54 @@
+package collector
+
+import (
+	"context"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/waitingsong/zfs_exporter/v3/zfs"
+	"github.com/waitingsong/zfs_exporter/v3/zfs/mock_zfs"
+)
+
+func TestVdevPropertiesMetrics(t *testing.T) {
+	const result = `# HELP zfs_vdev_allocating Whether new data may be allocated on the top-level vdev [0: off, 1: on].
+# TYPE zfs_vdev_allocating gauge
+zfs_vdev_allocating{pool="tank",vdev="mirror-0"} 1
+zfs_vdev_allocating{pool="tank",vdev="root-0"} 1
+# HELP zfs_vdev_checksum_errors_total Number of checksum errors of the vdev, since the errors were last cleared.
+# TYPE zfs_vdev_checksum_errors_total counter
+zfs_vdev_checksum_errors_total{pool="tank",vdev="mirror-0"} 0
+zfs_vdev_checksum_errors_total{pool="tank",vdev="root-0"} 0
+zfs_vdev_checksum_errors_total{pool="tank",vdev="sda"} 3
+# HELP zfs_vdev_checksum_n Number of checksum errors within checksum_t seconds after which ZED faults the vdev.
+# TYPE zfs_vdev_checksum_n gauge
+zfs_vdev_checksum_n{pool="tank",vdev="sda"} 10
+`
+
+	ctrl, ctx := gomock.WithContext(context.Background(), t)
+	zfsClient := mock_zfs.NewMockClient(ctrl)
+	zfsClient.EXPECT().PoolNames().Return([]string{`tank`}, nil).Times(1)
+	// Properties which do not apply to a vdev, or are unset, are skipped.
+	var vdevs []zfs.VdevProperties
+	for name, props := range map[string]map[string]string{
+		`root-0`:   {`allocating`: `on`, `checksum_errors`: `0`, `checksum_n`: `-`},
+		`mirror-0`: {`allocating`: `on`, `checksum_errors`: `0`, `checksum_n`: `-`},
+		`sda`:      {`allocating`: `-`, `checksum_errors`: `3`, `checksum_n`: `10`},
+	} {
+		vdev := mock_zfs.NewMockVdevProperties(ctrl)
+		vdev.EXPECT().VdevName().Return(name).Times(1)
+		vdev.EXPECT().Properties().Return(props).Times(1)
+		vdevs = append(vdevs, vdev)
+	}
+	zfsPool := mock_zfs.NewMockPool(ctrl)
+	zfsPool.EXPECT().VdevProperties(`allocating`, `checksum_errors`, `checksum_n`).Return(vdevs, nil).Times(1)
+	zfsClient.EXPECT().Pool(`tank`).Return(zfsPool).Times(1)
+
+	collector := newTestZFS(t, zfsClient, `vdev-properties`, `allocating,checksum_errors,checksum_n`, newVdevPropertiesCollector)
+
+	metricNames := []string{
+		`zfs_vdev_allocating`,
+		`zfs_vdev_checksum_errors_total`,
+		`zfs_vdev_checksum_n`,
+	}
+	collectAndCompare(t, ctx, collector, result, metricNames)
+}
@@ -0,0 +1,49 @@
+package collector
+
+import (
+	"context"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/waitingsong/zfs_exporter/v3/zfs"
+	"github.com/waitingsong/zfs_exporter/v3/zfs/mock_zfs"
+)
+
+func TestVdevMetrics(t *testing.T) {
+	const result = `# HELP zfs_vdev_allocated_bytes Amount of storage in bytes allocated on the top-level vdev.
+# TYPE zfs_vdev_allocated_bytes gauge
+zfs_vdev_allocated_bytes{class="log",pool="tank",vdev="nvme2n1p1"} 1.572864e+06
+zfs_vdev_allocated_bytes{class="normal",pool="tank",vdev="raidz1-0"} 2.934006521856e+12
+zfs_vdev_allocated_bytes{class="special",pool="tank",vdev="mirror-1"} 3.008192512e+09
+# HELP zfs_vdev_capacity_ratio Ratio of the top-level vdev space used.
+# TYPE zfs_vdev_capacity_ratio gauge
+zfs_vdev_capacity_ratio{class="log",pool="tank",vdev="nvme2n1p1"} 0
+zfs_vdev_capacity_ratio{class="normal",pool="tank",vdev="raidz1-0"} 0.48
+zfs_vdev_capacity_ratio{class="special",pool="tank",vdev="mirror-1"} 0.54
+# HELP zfs_vdev_expand_size_bytes Amount of uninitialized space within the top-level vdev that can be used to increase its capacity.
+# TYPE zfs_vdev_expand_size_bytes gauge
+zfs_vdev_expand_size_bytes{class="log",pool="tank",vdev="nvme2n1p1"} 1.073741824e+09
+zfs_vdev_expand_size_bytes{class="normal",pool="tank",vdev="raidz1-0"} 0
+zfs_vdev_expand_size_bytes{class="special",pool="tank",vdev="mirror-1"} 0
+`
+
+	ctrl, ctx := gomock.WithContext(context.Background(), t)
+	zfsClient := mock_zfs.NewMockClient(ctrl)
+	zfsClient.EXPECT().PoolNames().Return([]string{`tank`}, nil).Times(1)
+	zfsPool := mock_zfs.NewMockPool(ctrl)
+	zfsPool.EXPECT().VdevSpace().Return([]zfs.VdevSpace{
+		{Name: `raidz1-0`, Class: zfs.AllocationNormal, Properties: map[string]string{`size`: `5993995911168`, `allocated`: `2934006521856`, `expandsize`: `-`, `capacity`: `48`}},
+		{Name: `mirror-1`, Class: zfs.AllocationSpecial, Properties: map[string]string{`size`: `5536530432`, `allocated`: `3008192512`, `expandsize`: `-`, `capacity`: `54`}},
+		{Name: `nvme2n1p1`, Class: zfs.AllocationLog, Properties: map[string]string{`size`: `16642998272`, `allocated`: `1572864`, `expandsize`: `1073741824`, `capacity`: `0`}},
+	}, nil).Times(1)
+	zfsClient.EXPECT().Pool(`tank`).Return(zfsPool).Times(1)
+
+	collector := newTestZFS(t, zfsClient, `vdev`, `allocated,capacity,expandsize`, newVdevCollector)
+
+	metricNames := []string{
+		`zfs_vdev_allocated_bytes`,
+		`zfs_vdev_capacity_ratio`,
+		`zfs_vdev_expand_size_bytes`,
+	}
+	collectAndCompare(t, ctx, collector, result, metricNames)
+}
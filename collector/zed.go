@@ -0,0 +1,167 @@
+package collector
+
+import (
+	"bufio"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// zedMaxPayload is the maximum size of the environment posted by a zedlet
+	zedMaxPayload = 64 << 10
+)
+
+var (
+	zedEventsTotal = newProperty(
+		subsystemZED,
+		`events_total`,
+		`Number of ZFS events posted by zedlets since the exporter started, by class, pool and vdev path. Events unrelated to a pool or vdev have an empty label.`,
+		transformNumeric,
+		`class`, `pool`, `vdev`,
+	).withValueType(prometheus.CounterValue)
+	zedLastTimestamp = newProperty(
+		subsystemZED,
+		`last_event_timestamp_seconds`,
+		`Unix timestamp of the last ZFS event of the class posted by a zedlet.`,
+		transformNumeric,
+		`class`,
+	)
+
+	// zedHealthSubclasses are the event subclasses signalling a change to the health of a pool or its vdevs
+	zedHealthSubclasses = []string{
+		`io_failure`, `pool_import`, `resilver_finish`, `statechange`,
+		`vdev_attach`, `vdev_clear`, `vdev_remove`, `vdev_spare`,
+	}
+)
+
+// zedEvents counts the events posted by zedlets
+type zedEvents struct {
+	counts map[eventKey]uint64
+	last   map[string]time.Time
+	sync.Mutex
+}
+
+func (e *zedEvents) add(key eventKey, t time.Time) {
+	e.Lock()
+	defer e.Unlock()
+	e.counts[key]++
+	if t.After(e.last[key.class]) {
+		e.last[key.class] = t
+	}
+}
+
+func newZEDEvents() *zedEvents {
+	return &zedEvents{
+		counts: make(map[eventKey]uint64),
+		last:   make(map[string]time.Time),
+	}
+}
+
+// parseZEDEnvironment parses the environment of a zedlet, as `KEY=VALUE` lines, keeping the `ZEVENT_` variables
+func parseZEDEnvironment(r io.Reader) (map[string]string, error) {
+	env := make(map[string]string)
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(scanner.Text(), `=`)
+		if ok && strings.HasPrefix(key, `ZEVENT_`) {
+			env[key] = value
+		}
+	}
+
+	return env, scanner.Err()
+}
+
+// ZEDHandler returns a handler accepting the environment of a zedlet, posted as `KEY=VALUE` lines (i.e. the output of
+// `env`), such as the sample zedlet in contrib/zed. Events are counted by their ZEVENT_CLASS, ZEVENT_POOL and
+// ZEVENT_VDEV_PATH, and a change to the health of a pool drops its cached metrics. Requires ZFSConfig.ZED.
+func (c *ZFS) ZEDHandler() http.Handler {
+	return http.HandlerFunc(c.handleZED)
+}
+
+func (c *ZFS) handleZED(w http.ResponseWriter, r *http.Request) {
+	if c.zed == nil {
+		http.NotFound(w, r)
+		return
+	}
+	if r.Method != http.MethodPost {
+		w.Header().Set(`Allow`, http.MethodPost)
+		http.Error(w, `Method not allowed`, http.StatusMethodNotAllowed)
+		return
+	}
+	env, err := parseZEDEnvironment(io.LimitReader(r.Body, zedMaxPayload))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	class := env[`ZEVENT_CLASS`]
+	if class == `` {
+		http.Error(w, `Missing ZEVENT_CLASS`, http.StatusBadRequest)
+		return
+	}
+	pool := env[`ZEVENT_POOL`]
+
+	t := time.Now()
+	if secs, err := strconv.ParseInt(env[`ZEVENT_TIME_SECS`], 10, 64); err == nil {
+		nsecs, _ := strconv.ParseInt(env[`ZEVENT_TIME_NSECS`], 10, 64)
+		t = time.Unix(secs, nsecs)
+	}
+	c.zed.add(eventKey{class: class, pool: pool, vdev: env[`ZEVENT_VDEV_PATH`]}, t)
+
+	subclass := env[`ZEVENT_SUBCLASS`]
+	if subclass == `` {
+		subclass = class[strings.LastIndex(class, `.`)+1:]
+	}
+	if pool != `` && slices.Contains(zedHealthSubclasses, subclass) {
+		c.logger.Info("Refreshing pool upon health change", "pool", pool, "class", class)
+		c.invalidate(pool)
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// invalidate drops the cached metrics of the pool, so that the next scrape refreshes it: static properties are
+// expired, and scheduled collectors run regardless of their interval
+func (c *ZFS) invalidate(pool string) {
+	c.cache.invalidate(pool)
+
+	c.schedulesMu.Lock()
+	for _, s := range c.schedules {
+		s.Lock()
+		s.cache.invalidate(pool)
+		s.lastRun = time.Time{}
+		s.Unlock()
+	}
+	c.schedulesMu.Unlock()
+
+	c.instancesMu.Lock()
+	defer c.instancesMu.Unlock()
+	for _, collector := range c.instances {
+		if e, ok := collector.(expiringCollector); ok {
+			e.expire(pool)
+		}
+	}
+}
+
+// publishZEDMetrics reports the events posted by zedlets, for the collected pools only unless they are unknown
+func (c *ZFS) publishZEDMetrics(ch chan<- metric, pools []string, poolErr error) {
+	if c.zed == nil {
+		return
+	}
+	c.zed.Lock()
+	defer c.zed.Unlock()
+	for key, count := range c.zed.counts {
+		if poolErr == nil && key.pool != `` && !slices.Contains(pools, key.pool) {
+			continue
+		}
+		zedEventsTotal.pushValue(ch, float64(count), key.class, key.pool, key.vdev)
+	}
+	for class, t := range c.zed.last {
+		zedLastTimestamp.pushValue(ch, float64(t.UnixNano())/1e9, class)
+	}
+}
@@ -0,0 +1,113 @@
+package collector
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/waitingsong/zfs_exporter/v3/zfs/mock_zfs"
+)
+
+func postZED(t *testing.T, c *ZFS, method, body string) int {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	c.ZEDHandler().ServeHTTP(rec, httptest.NewRequest(method, `/zed`, strings.NewReader(body)))
+	return rec.Code
+}
+
+func TestZEDMetrics(t *testing.T) {
+	const result = `# HELP zfs_zed_events_total Number of ZFS events posted by zedlets since the exporter started, by class, pool and vdev path. Events unrelated to a pool or vdev have an empty label.
+# TYPE zfs_zed_events_total counter
+zfs_zed_events_total{class="ereport.fs.zfs.checksum",pool="tank",vdev="/dev/sda1"} 2
+zfs_zed_events_total{class="sysevent.fs.zfs.history_event",pool="",vdev=""} 1
+# HELP zfs_zed_last_event_timestamp_seconds Unix timestamp of the last ZFS event of the class posted by a zedlet.
+# TYPE zfs_zed_last_event_timestamp_seconds gauge
+zfs_zed_last_event_timestamp_seconds{class="ereport.fs.zfs.checksum"} 1.7185044525e+09
+zfs_zed_last_event_timestamp_seconds{class="ereport.fs.zfs.io"} 1.7185041e+09
+zfs_zed_last_event_timestamp_seconds{class="sysevent.fs.zfs.history_event"} 1.7185042e+09
+`
+
+	ctrl, ctx := gomock.WithContext(context.Background(), t)
+	zfsClient := mock_zfs.NewMockClient(ctrl)
+	zfsClient.EXPECT().PoolNames().Return([]string{`tank`}, nil).Times(1)
+
+	config := defaultConfig(zfsClient)
+	config.ZED = true
+	collector, err := NewZFS(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	collector.Collectors = map[string]State{}
+
+	payloads := []string{
+		"ZEVENT_CLASS=ereport.fs.zfs.checksum\nZEVENT_POOL=tank\nZEVENT_VDEV_PATH=/dev/sda1\nZEVENT_TIME_SECS=1718504452\nZEVENT_TIME_NSECS=0\nPATH=/usr/bin\n",
+		"ZEVENT_CLASS=ereport.fs.zfs.checksum\nZEVENT_POOL=tank\nZEVENT_VDEV_PATH=/dev/sda1\nZEVENT_TIME_SECS=1718504452\nZEVENT_TIME_NSECS=500000000\n",
+		// Events of pools which are not collected are not counted, although their class is timestamped.
+		"ZEVENT_CLASS=ereport.fs.zfs.io\nZEVENT_POOL=backup\nZEVENT_TIME_SECS=1718504100\n",
+		"ZEVENT_CLASS=sysevent.fs.zfs.history_event\nZEVENT_TIME_SECS=1718504200\n",
+	}
+	for _, payload := range payloads {
+		if code := postZED(t, collector, http.MethodPost, payload); code != http.StatusNoContent {
+			t.Fatalf(`got status %d, want %d`, code, http.StatusNoContent)
+		}
+	}
+	if code := postZED(t, collector, http.MethodGet, ``); code != http.StatusMethodNotAllowed {
+		t.Errorf(`got status %d, want %d`, code, http.StatusMethodNotAllowed)
+	}
+	if code := postZED(t, collector, http.MethodPost, "ZEVENT_POOL=tank\n"); code != http.StatusBadRequest {
+		t.Errorf(`got status %d, want %d`, code, http.StatusBadRequest)
+	}
+
+	collectAndCompare(t, ctx, collector, result, []string{`zfs_zed_events_total`, `zfs_zed_last_event_timestamp_seconds`})
+}
+
+func TestZEDHealthChange(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	zfsClient := mock_zfs.NewMockClient(ctrl)
+	config := defaultConfig(zfsClient)
+	config.ZED = true
+	collector, err := NewZFS(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	health := newProperty(subsystemPool, `health`, `Health status code for the pool.`, transformHealthCode, `pool`)
+	for _, pool := range []string{`tank`, `backup`} {
+		collector.cache.add(metric{
+			name:       expandMetricName(health.name, pool),
+			prometheus: prometheus.MustNewConstMetric(health.desc, prometheus.GaugeValue, 0, pool),
+		})
+	}
+	s := collector.schedule(`scheduled`)
+	s.lastRun = time.Now()
+	pool := &poolCollector{log: logger, client: zfsClient, static: newPropertyCache()}
+	pool.static.store(`tank`, nil)
+	collector.instances[`pool`] = pool
+
+	// Counting an event not affecting health leaves the cache untouched.
+	if code := postZED(t, collector, http.MethodPost, "ZEVENT_CLASS=ereport.fs.zfs.checksum\nZEVENT_POOL=tank\n"); code != http.StatusNoContent {
+		t.Fatalf(`got status %d, want %d`, code, http.StatusNoContent)
+	}
+	if got := len(collector.cache.index()); got != 2 {
+		t.Fatalf(`got %d cached metrics, want 2`, got)
+	}
+
+	if code := postZED(t, collector, http.MethodPost, "ZEVENT_CLASS=resource.fs.zfs.statechange\nZEVENT_SUBCLASS=statechange\nZEVENT_POOL=tank\n"); code != http.StatusNoContent {
+		t.Fatalf(`got status %d, want %d`, code, http.StatusNoContent)
+	}
+	index := collector.cache.index()
+	if _, ok := index[expandMetricName(health.name, `tank`)]; ok || len(index) != 1 {
+		t.Errorf(`got cached metrics %v, want only those of backup`, index)
+	}
+	if !s.lastRun.IsZero() {
+		t.Error(`expected scheduled collectors to run on the next scrape`)
+	}
+	if !pool.static.due(`tank`, time.Hour) {
+		t.Error(`expected static properties of tank to be refreshed on the next run`)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"log/slog"
 	"regexp"
 	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -33,6 +34,11 @@ type ZFSConfig struct {
 	Excludes       []string
 	Logger         *slog.Logger
 	ZFSClient      zfs.Client
+	// Environment is the result of zfs.Probe, used to report version metrics and disable unknown properties. The
+	// environment is treated as unknown if nil.
+	Environment *zfs.Environment
+	// ZED enables the counters of events posted to the ZEDHandler
+	ZED bool
 }
 
 // ZFS collector
@@ -46,6 +52,21 @@ type ZFS struct {
 	ready          chan struct{}
 	logger         *slog.Logger
 	excludes       regexpCollection
+	schedulesMu    sync.Mutex
+	schedules      map[string]*schedule
+	instancesMu    sync.Mutex
+	instances      map[string]Collector
+	environment    *zfs.Environment
+	zed            *zedEvents
+}
+
+// schedule tracks the background runs of a scheduled collector
+type schedule struct {
+	sync.Mutex
+	cache       *metricCache
+	running     bool
+	lastRun     time.Time
+	lastSuccess time.Time
 }
 
 // Describe implements the prometheus.Collector interface.
@@ -55,12 +76,27 @@ func (c *ZFS) Describe(ch chan<- *prometheus.Desc) {
 		ch <- scrapeSuccessDesc
 	}
 
-	for _, state := range c.Collectors {
+	if c.environment != nil {
+		ch <- versionInfoDesc
+		ch <- binaryAvailableDesc
+	}
+
+	if c.zed != nil {
+		ch <- zedEventsTotal.desc
+		ch <- zedLastTimestamp.desc
+	}
+
+	describedLastSuccess := false
+	for name, state := range c.Collectors {
 		if !*state.Enabled {
 			continue
 		}
+		if state.scheduled() && !describedLastSuccess && !c.disableMetrics {
+			ch <- scrapeLastSuccessDesc
+			describedLastSuccess = true
+		}
 
-		collector, err := state.factory(c.logger, c.client, strings.Split(*state.Properties, `,`))
+		collector, err := c.instance(name, state)
 		if err != nil {
 			continue
 		}
@@ -81,9 +117,9 @@ func (c *ZFS) Collect(ch chan<- prometheus.Metric) {
 
 	cache := newMetricCache()
 	proxy := make(chan metric)
-	// Synchronize on collector completion.
+	// Synchronize on collector completion, along with the metrics published by the exporter itself.
 	wg := sync.WaitGroup{}
-	wg.Add(len(c.Collectors))
+	wg.Add(len(c.Collectors) + 1)
 	// Synchonize after timeout event, ensuring no writers are still active when we return control.
 	timeout := make(chan struct{})
 	finalized := make(chan struct{})
@@ -119,7 +155,10 @@ func (c *ZFS) Collect(ch chan<- prometheus.Metric) {
 		c.ready <- struct{}{}
 	}()
 
+	c.publishEnvironmentMetrics(proxy)
 	pools, poolErr := c.getPools(c.Pools)
+	c.publishZEDMetrics(proxy, pools, poolErr)
+	wg.Done()
 
 	for name, state := range c.Collectors {
 		if !*state.Enabled {
@@ -133,7 +172,15 @@ func (c *ZFS) Collect(ch chan<- prometheus.Metric) {
 			continue
 		}
 
-		collector, err := state.factory(c.logger, c.client, strings.Split(*state.Properties, `,`))
+		if state.scheduled() {
+			go func(name string, state State) {
+				c.collectScheduled(name, state, proxy, pools)
+				wg.Done()
+			}(name, state)
+			continue
+		}
+
+		collector, err := c.instance(name, state)
 		if err != nil {
 			c.logger.Error("Error instantiating collector", "collector", name, "err", err)
 			wg.Done()
@@ -210,6 +257,132 @@ func (c *ZFS) execute(ctx context.Context, name string, collector Collector, ch
 	c.publishCollectorMetrics(ctx, name, err, duration, ch)
 }
 
+// collectScheduled triggers a background run of a scheduled collector when its interval has elapsed, and sends the
+// results of the last run from its cache.
+func (c *ZFS) collectScheduled(name string, state State, ch chan<- metric, pools []string) {
+	s := c.schedule(name)
+	s.Lock()
+	if !s.running && time.Since(s.lastRun) >= *state.Interval {
+		s.running = true
+		s.lastRun = time.Now()
+		go c.runScheduled(name, state, s, pools)
+	}
+	cache := s.cache
+	lastSuccess := s.lastSuccess
+	s.Unlock()
+
+	cache.RLock()
+	defer cache.RUnlock()
+	for key, m := range cache.cache {
+		ch <- metric{name: key, prometheus: m}
+	}
+	if !lastSuccess.IsZero() && !c.disableMetrics {
+		ch <- metric{
+			name:       expandMetricName(scrapeLastSuccessDescName, name),
+			prometheus: prometheus.MustNewConstMetric(scrapeLastSuccessDesc, prometheus.GaugeValue, float64(lastSuccess.Unix()), name),
+		}
+	}
+}
+
+// runScheduled executes a scheduled collector outside of the scrape, replacing its cached results upon success.
+// Upon failure, the results of the last successful run are retained.
+func (c *ZFS) runScheduled(name string, state State, s *schedule, pools []string) {
+	cache := newMetricCache()
+	proxy := make(chan metric)
+	done := make(chan struct{})
+	go func() {
+		for m := range proxy {
+			cache.add(m)
+		}
+		close(done)
+	}()
+
+	begin := time.Now()
+	collector, err := c.instance(name, state)
+	if err == nil {
+		err = collector.update(proxy, pools, c.excludes)
+	}
+	c.publishCollectorMetrics(context.Background(), name, err, time.Since(begin), proxy)
+	close(proxy)
+	<-done
+
+	s.Lock()
+	defer s.Unlock()
+	if err != nil {
+		s.cache.merge(cache)
+	} else {
+		s.cache = cache
+		s.lastSuccess = time.Now()
+	}
+	s.running = false
+}
+
+// instance returns the collector registered under name, instantiating it upon first use. Instances are retained
+// between scrapes, so that collectors may maintain state across runs.
+func (c *ZFS) instance(name string, state State) (Collector, error) {
+	c.instancesMu.Lock()
+	defer c.instancesMu.Unlock()
+	if collector, ok := c.instances[name]; ok {
+		return collector, nil
+	}
+	props := strings.Split(*state.Properties, `,`)
+	if state.store != nil && c.environment != nil {
+		var unsupported []string
+		props, unsupported = state.store.supported(props, c.environment.Version())
+		if len(unsupported) > 0 {
+			c.logger.Warn("Disabling properties unknown to the installed OpenZFS version", "collector", name, "version", c.environment.Version(), "properties", strings.Join(unsupported, `,`))
+		}
+	}
+	collector, err := state.factory(c.logger, c.client, props)
+	if err != nil {
+		return nil, err
+	}
+	c.instances[name] = collector
+
+	return collector, nil
+}
+
+func (c *ZFS) schedule(name string) *schedule {
+	c.schedulesMu.Lock()
+	defer c.schedulesMu.Unlock()
+	s, ok := c.schedules[name]
+	if !ok {
+		s = &schedule{cache: newMetricCache()}
+		c.schedules[name] = s
+	}
+
+	return s
+}
+
+// publishEnvironmentMetrics reports the OpenZFS versions and required binaries found at startup
+func (c *ZFS) publishEnvironmentMetrics(ch chan<- metric) {
+	if c.environment == nil {
+		return
+	}
+	env := c.environment
+	ch <- metric{
+		name: versionInfoDescName,
+		prometheus: prometheus.MustNewConstMetric(
+			versionInfoDesc,
+			prometheus.GaugeValue,
+			1,
+			env.Kmod.Raw,
+			env.Userland.Raw,
+			strconv.FormatBool(env.Mismatch()),
+		),
+	}
+	for _, name := range zfs.RequiredBinaries {
+		available := 0.0
+		if env.Binaries[name] {
+			available = 1
+		}
+		ch <- metric{
+			name:       expandMetricName(binaryAvailableDescName, name),
+			prometheus: prometheus.MustNewConstMetric(binaryAvailableDesc, prometheus.GaugeValue, available, name),
+		}
+	}
+}
+
 func (c *ZFS) publishCollectorMetrics(ctx context.Context, name string, err error, duration time.Duration, ch chan<- metric) {
 	var success float64
 
@@ -236,11 +409,11 @@ func (c *ZFS) publishCollectorMetrics(ctx context.Context, name string, err erro
 		return
 	}
 	ch <- metric{
-		name:       scrapeDurationDescName,
+		name:       expandMetricName(scrapeDurationDescName, name),
 		prometheus: prometheus.MustNewConstMetric(scrapeDurationDesc, prometheus.GaugeValue, duration.Seconds(), name),
 	}
 	ch <- metric{
-		name:       scrapeSuccessDescName,
+		name:       expandMetricName(scrapeSuccessDescName, name),
 		prometheus: prometheus.MustNewConstMetric(scrapeSuccessDesc, prometheus.GaugeValue, success, name),
 	}
 }
@@ -255,6 +428,10 @@ func NewZFS(config ZFSConfig) (*ZFS, error) {
 	}
 	ready := make(chan struct{}, 1)
 	ready <- struct{}{}
+	var zed *zedEvents
+	if config.ZED {
+		zed = newZEDEvents()
+	}
 	return &ZFS{
 		disableMetrics: config.DisableMetrics,
 		client:         config.ZFSClient,
@@ -265,5 +442,9 @@ func NewZFS(config ZFSConfig) (*ZFS, error) {
 		cache:          newMetricCache(),
 		ready:          ready,
 		logger:         config.Logger,
+		schedules:      make(map[string]*schedule),
+		instances:      make(map[string]Collector),
+		environment:    config.Environment,
+		zed:            zed,
 	}, nil
 }
@@ -3,9 +3,14 @@ package collector
 import (
 	"context"
 	"fmt"
+	"log/slog"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/golang/mock/gomock"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/waitingsong/zfs_exporter/v3/zfs"
 	"github.com/waitingsong/zfs_exporter/v3/zfs/mock_zfs"
 )
 
@@ -41,3 +46,126 @@ zfs_scrape_collector_success{collector="pool"} 0
 		t.Fatal(err)
 	}
 }
+
+type scheduledTestCollector struct {
+	runs *atomic.Int32
+}
+
+var scheduledTestDesc = prometheus.NewDesc(`zfs_scheduled_test_runs`, `Number of runs of the scheduled test collector.`, nil, nil)
+
+func (c *scheduledTestCollector) describe(ch chan<- *prometheus.Desc) {
+	ch <- scheduledTestDesc
+}
+
+func (c *scheduledTestCollector) update(ch chan<- metric, pools []string, excludes regexpCollection) error {
+	runs := c.runs.Add(1)
+	ch <- metric{
+		name:       `zfs_scheduled_test_runs`,
+		prometheus: prometheus.MustNewConstMetric(scheduledTestDesc, prometheus.GaugeValue, float64(runs)),
+	}
+
+	return nil
+}
+
+func TestZFSCollectScheduled(t *testing.T) {
+	const result = `# HELP zfs_scheduled_test_runs Number of runs of the scheduled test collector.
+# TYPE zfs_scheduled_test_runs gauge
+zfs_scheduled_test_runs 1
+`
+
+	ctrl, ctx := gomock.WithContext(context.Background(), t)
+	zfsClient := mock_zfs.NewMockClient(ctrl)
+	zfsClient.EXPECT().PoolNames().Return([]string{`testpool`}, nil).AnyTimes()
+
+	runs := &atomic.Int32{}
+	interval := time.Hour
+	collector, err := NewZFS(defaultConfig(zfsClient))
+	if err != nil {
+		t.Fatal(err)
+	}
+	collector.Collectors = map[string]State{
+		`scheduled`: {
+			Name:       `scheduled`,
+			Enabled:    boolPointer(true),
+			Properties: stringPointer(``),
+			Interval:   &interval,
+			factory: func(l *slog.Logger, c zfs.Client, properties []string) (Collector, error) {
+				return &scheduledTestCollector{runs: runs}, nil
+			},
+		},
+	}
+
+	// The first scrape triggers a background run, and has no cached results to serve yet.
+	if err = callCollector(ctx, collector, []byte(``), []string{`zfs_scheduled_test_runs`}); err != nil {
+		t.Fatal(err)
+	}
+	s := collector.schedule(`scheduled`)
+	for {
+		s.Lock()
+		done := !s.lastSuccess.IsZero()
+		s.Unlock()
+		if done {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	// Subsequent scrapes within the interval serve the cached results without running the collector again. The last
+	// success timestamp is omitted along with the other scrape metrics, as they are disabled.
+	for i := 0; i < 2; i++ {
+		if err = callCollector(ctx, collector, []byte(result), []string{`zfs_scheduled_test_runs`, `zfs_scrape_collector_last_success_timestamp_seconds`}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := runs.Load(); got != 1 {
+		t.Fatalf(`got %d runs, want 1`, got)
+	}
+}
+
+func TestZFSCollectEnvironment(t *testing.T) {
+	const result = `# HELP zfs_binary_available Whether a command required by the exporter was found on PATH at startup [0: missing, 1: available].
+# TYPE zfs_binary_available gauge
+zfs_binary_available{binary="zfs"} 1
+zfs_binary_available{binary="zpool"} 0
+# HELP zfs_pool_size_bytes Total size in bytes of the storage pool.
+# TYPE zfs_pool_size_bytes gauge
+zfs_pool_size_bytes{pool="testpool"} 1024
+# HELP zfs_version_info OpenZFS kernel module and userland versions, and whether they differ [mismatch: true, false]. Versions which could not be determined are empty.
+# TYPE zfs_version_info gauge
+zfs_version_info{kmod="0.7.13-1",mismatch="true",userland="zfs-0.7.12-1"} 1
+`
+
+	ctrl, ctx := gomock.WithContext(context.Background(), t)
+	zfsClient := mock_zfs.NewMockClient(ctrl)
+	zfsClient.EXPECT().PoolNames().Return([]string{`testpool`}, nil).Times(1)
+	zfsPoolProperties := mock_zfs.NewMockPoolProperties(ctrl)
+	zfsPoolProperties.EXPECT().Properties().Return(map[string]string{`size`: `1024`}).Times(1)
+	zfsPool := mock_zfs.NewMockPool(ctrl)
+	// autotrim is unknown to OpenZFS 0.7, and must not be queried.
+	zfsPool.EXPECT().Properties([]string{`size`}).Return(zfsPoolProperties, nil).Times(1)
+	zfsClient.EXPECT().Pool(`testpool`).Return(zfsPool).Times(1)
+
+	config := defaultConfig(zfsClient)
+	config.Environment = &zfs.Environment{
+		Kmod:     zfs.Version{Major: 0, Minor: 7, Patch: 13, Raw: `0.7.13-1`},
+		Userland: zfs.Version{Major: 0, Minor: 7, Patch: 12, Raw: `zfs-0.7.12-1`},
+		Binaries: map[string]bool{`zfs`: true},
+	}
+	collector, err := NewZFS(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	collector.Collectors = map[string]State{
+		`pool`: {
+			Name:       "pool",
+			Enabled:    boolPointer(true),
+			Properties: stringPointer(`size,autotrim`),
+			factory:    newPoolCollector,
+			store:      &poolProperties,
+		},
+	}
+
+	if err = callCollector(ctx, collector, []byte(result), []string{`zfs_binary_available`, `zfs_pool_size_bytes`, `zfs_pool_autotrim`, `zfs_version_info`}); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,189 @@
+package collector
+
+import (
+	"log/slog"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/waitingsong/zfs_exporter/v3/zfs"
+)
+
+const (
+	defaultZILProps = `zil_commit_count,zil_commit_writer_count,zil_itx_count,zil_itx_indirect_count,zil_itx_indirect_bytes,zil_itx_copied_count,zil_itx_copied_bytes,zil_itx_needcopy_count,zil_itx_needcopy_bytes,zil_itx_metaslab_normal_count,zil_itx_metaslab_normal_bytes,zil_itx_metaslab_slog_count,zil_itx_metaslab_slog_bytes`
+)
+
+var (
+	zilProperties = propertyStore{
+		defaultSubsystem: subsystemZIL,
+		store: map[string]property{
+			`zil_commit_count`: newProperty(
+				subsystemZIL,
+				`commits_total`,
+				`Number of ZIL commits, requested by fsync(2), O_SYNC/O_DSYNC writes or sync=always datasets.`,
+				transformNumeric,
+			).withValueType(prometheus.CounterValue),
+			`zil_commit_writer_count`: newProperty(
+				subsystemZIL,
+				`commit_writers_total`,
+				`Number of ZIL commits which issued log writes, rather than waiting on a concurrent commit.`,
+				transformNumeric,
+			).withValueType(prometheus.CounterValue),
+			`zil_commit_error_count`: newProperty(
+				subsystemZIL,
+				`commit_errors_total`,
+				`Number of ZIL commits which failed, falling back to waiting on a transaction group sync.`,
+				transformNumeric,
+			).withValueType(prometheus.CounterValue),
+			`zil_commit_stall_count`: newProperty(
+				subsystemZIL,
+				`commit_stalls_total`,
+				`Number of ZIL commits which stalled waiting on a transaction group sync, due to a log block allocation failure.`,
+				transformNumeric,
+			).withValueType(prometheus.CounterValue),
+			`zil_commit_suspend_count`: newProperty(
+				subsystemZIL,
+				`commit_suspends_total`,
+				`Number of ZIL commits which waited on a transaction group sync, as the ZIL was suspended.`,
+				transformNumeric,
+			).withValueType(prometheus.CounterValue),
+			`zil_itx_count`: newProperty(
+				subsystemZIL,
+				`itxs_total`,
+				`Number of intent log transactions (itxs) created.`,
+				transformNumeric,
+			).withValueType(prometheus.CounterValue),
+			`zil_itx_indirect_count`: newProperty(
+				subsystemZIL,
+				`itx_indirect_total`,
+				`Number of write itxs whose data was written directly to the pool, and referenced from the log (WR_INDIRECT).`,
+				transformNumeric,
+			).withValueType(prometheus.CounterValue),
+			`zil_itx_indirect_bytes`: newProperty(
+				subsystemZIL,
+				`itx_indirect_bytes_total`,
+				`Amount of data in bytes of write itxs written directly to the pool, and referenced from the log (WR_INDIRECT).`,
+				transformNumeric,
+			).withValueType(prometheus.CounterValue),
+			`zil_itx_copied_count`: newProperty(
+				subsystemZIL,
+				`itx_copied_total`,
+				`Number of write itxs whose data was copied into the itx when created (WR_COPIED).`,
+				transformNumeric,
+			).withValueType(prometheus.CounterValue),
+			`zil_itx_copied_bytes`: newProperty(
+				subsystemZIL,
+				`itx_copied_bytes_total`,
+				`Amount of data in bytes of write itxs copied into the itx when created (WR_COPIED).`,
+				transformNumeric,
+			).withValueType(prometheus.CounterValue),
+			`zil_itx_needcopy_count`: newProperty(
+				subsystemZIL,
+				`itx_needcopy_total`,
+				`Number of write itxs whose data was copied into the log block when committed (WR_NEED_COPY).`,
+				transformNumeric,
+			).withValueType(prometheus.CounterValue),
+			`zil_itx_needcopy_bytes`: newProperty(
+				subsystemZIL,
+				`itx_needcopy_bytes_total`,
+				`Amount of data in bytes of write itxs copied into the log block when committed (WR_NEED_COPY).`,
+				transformNumeric,
+			).withValueType(prometheus.CounterValue),
+			`zil_itx_metaslab_normal_count`: newProperty(
+				subsystemZIL,
+				`normal_writes_total`,
+				`Number of log blocks written to the main pool devices.`,
+				transformNumeric,
+			).withValueType(prometheus.CounterValue),
+			`zil_itx_metaslab_normal_bytes`: newProperty(
+				subsystemZIL,
+				`normal_write_bytes_total`,
+				`Amount of log data in bytes written to the main pool devices.`,
+				transformNumeric,
+			).withValueType(prometheus.CounterValue),
+			`zil_itx_metaslab_normal_write`: newProperty(
+				subsystemZIL,
+				`normal_written_bytes_total`,
+				`Amount of bytes written for log blocks on the main pool devices, including block padding.`,
+				transformNumeric,
+			).withValueType(prometheus.CounterValue),
+			`zil_itx_metaslab_normal_alloc`: newProperty(
+				subsystemZIL,
+				`normal_allocated_bytes_total`,
+				`Amount of space in bytes allocated for log blocks on the main pool devices.`,
+				transformNumeric,
+			).withValueType(prometheus.CounterValue),
+			`zil_itx_metaslab_slog_count`: newProperty(
+				subsystemZIL,
+				`slog_writes_total`,
+				`Number of log blocks written to separate intent log (SLOG) devices.`,
+				transformNumeric,
+			).withValueType(prometheus.CounterValue),
+			`zil_itx_metaslab_slog_bytes`: newProperty(
+				subsystemZIL,
+				`slog_write_bytes_total`,
+				`Amount of log data in bytes written to separate intent log (SLOG) devices.`,
+				transformNumeric,
+			).withValueType(prometheus.CounterValue),
+			`zil_itx_metaslab_slog_write`: newProperty(
+				subsystemZIL,
+				`slog_written_bytes_total`,
+				`Amount of bytes written for log blocks on separate intent log (SLOG) devices, including block padding.`,
+				transformNumeric,
+			).withValueType(prometheus.CounterValue),
+			`zil_itx_metaslab_slog_alloc`: newProperty(
+				subsystemZIL,
+				`slog_allocated_bytes_total`,
+				`Amount of space in bytes allocated for log blocks on separate intent log (SLOG) devices.`,
+				transformNumeric,
+			).withValueType(prometheus.CounterValue),
+		},
+	}
+)
+
+func init() {
+	registerCollector(`zil`, defaultDisabled, defaultZILProps, newZILCollector)
+}
+
+type zilCollector struct {
+	log    *slog.Logger
+	client zfs.Client
+	props  []string
+}
+
+func (c *zilCollector) describe(ch chan<- *prometheus.Desc) {
+	for _, k := range c.props {
+		prop, err := zilProperties.find(k)
+		if err != nil {
+			c.log.Warn(propertyUnsupportedMsg, `help`, helpIssue, `collector`, `zil`, `property`, k, `err`, err)
+			continue
+		}
+		ch <- prop.desc
+	}
+}
+
+func (c *zilCollector) update(ch chan<- metric, pools []string, excludes regexpCollection) error {
+	values, err := c.client.Kstat(`zil`)
+	if err != nil {
+		return err
+	}
+
+	for _, k := range c.props {
+		v, ok := values[k]
+		if !ok {
+			// Not all kstat fields are available in all versions of OpenZFS.
+			continue
+		}
+		prop, err := zilProperties.find(k)
+		if err != nil {
+			c.log.Warn(propertyUnsupportedMsg, `help`, helpIssue, `collector`, `zil`, `property`, k, `err`, err)
+		}
+		if err = prop.push(ch, v); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func newZILCollector(l *slog.Logger, c zfs.Client, props []string) (Collector, error) {
+	return &zilCollector{log: l, client: c, props: props}, nil
+}
@@ -0,0 +1,80 @@
+package collector
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/waitingsong/zfs_exporter/v3/zfs/mock_zfs"
+)
+
+func TestZILMetrics(t *testing.T) {
+	testCases := []struct {
+		name           string
+		propsRequested []string
+		metricNames    []string
+		kstatResults   map[string]string
+		metricResults  string
+	}{
+		{
+			name:           `commits and itxs`,
+			propsRequested: []string{`zil_commit_count`, `zil_itx_count`, `zil_itx_indirect_bytes`, `zil_itx_copied_bytes`, `zil_itx_needcopy_bytes`},
+			metricNames:    []string{`zfs_zil_commits_total`, `zfs_zil_itxs_total`, `zfs_zil_itx_indirect_bytes_total`, `zfs_zil_itx_copied_bytes_total`, `zfs_zil_itx_needcopy_bytes_total`},
+			kstatResults: map[string]string{
+				`zil_commit_count`:       `1843`,
+				`zil_itx_count`:          `29345`,
+				`zil_itx_indirect_bytes`: `1310720`,
+				`zil_itx_copied_bytes`:   `118607872`,
+				`zil_itx_needcopy_bytes`: `5476352`,
+			},
+			metricResults: `# HELP zfs_zil_commits_total Number of ZIL commits, requested by fsync(2), O_SYNC/O_DSYNC writes or sync=always datasets.
+# TYPE zfs_zil_commits_total counter
+zfs_zil_commits_total 1843
+# HELP zfs_zil_itx_copied_bytes_total Amount of data in bytes of write itxs copied into the itx when created (WR_COPIED).
+# TYPE zfs_zil_itx_copied_bytes_total counter
+zfs_zil_itx_copied_bytes_total 1.18607872e+08
+# HELP zfs_zil_itx_indirect_bytes_total Amount of data in bytes of write itxs written directly to the pool, and referenced from the log (WR_INDIRECT).
+# TYPE zfs_zil_itx_indirect_bytes_total counter
+zfs_zil_itx_indirect_bytes_total 1.31072e+06
+# HELP zfs_zil_itx_needcopy_bytes_total Amount of data in bytes of write itxs copied into the log block when committed (WR_NEED_COPY).
+# TYPE zfs_zil_itx_needcopy_bytes_total counter
+zfs_zil_itx_needcopy_bytes_total 5.476352e+06
+# HELP zfs_zil_itxs_total Number of intent log transactions (itxs) created.
+# TYPE zfs_zil_itxs_total counter
+zfs_zil_itxs_total 29345
+`,
+		},
+		{
+			name:           `normal and slog writes, missing fields`,
+			propsRequested: []string{`zil_itx_metaslab_normal_bytes`, `zil_itx_metaslab_slog_bytes`, `zil_itx_metaslab_slog_alloc`},
+			metricNames:    []string{`zfs_zil_normal_write_bytes_total`, `zfs_zil_slog_write_bytes_total`, `zfs_zil_slog_allocated_bytes_total`},
+			kstatResults: map[string]string{
+				`zil_itx_metaslab_normal_bytes`: `98304000`,
+				`zil_itx_metaslab_slog_bytes`:   `26214400`,
+			},
+			metricResults: `# HELP zfs_zil_normal_write_bytes_total Amount of log data in bytes written to the main pool devices.
+# TYPE zfs_zil_normal_write_bytes_total counter
+zfs_zil_normal_write_bytes_total 9.8304e+07
+# HELP zfs_zil_slog_write_bytes_total Amount of log data in bytes written to separate intent log (SLOG) devices.
+# TYPE zfs_zil_slog_write_bytes_total counter
+zfs_zil_slog_write_bytes_total 2.62144e+07
+`,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			ctrl, ctx := gomock.WithContext(context.Background(), t)
+			zfsClient := mock_zfs.NewMockClient(ctrl)
+			zfsClient.EXPECT().PoolNames().Return([]string{`testpool`}, nil).Times(1)
+			zfsClient.EXPECT().Kstat(`zil`).Return(tc.kstatResults, nil).Times(1)
+
+			collector := newTestZFS(t, zfsClient, `zil`, strings.Join(tc.propsRequested, `,`), newZILCollector)
+
+			collectAndCompare(t, ctx, collector, tc.metricResults, tc.metricNames)
+		})
+	}
+}
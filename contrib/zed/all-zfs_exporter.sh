@@ -0,0 +1,30 @@
+#!/bin/sh
+#
+# Forward every ZFS event to zfs_exporter, started with --zed.listen-address.
+#
+# Install into the zedlet directory (e.g. /etc/zfs/zed.d) as an executable owned by root, and restart zed. The
+# exporter is reached through the Unix socket ZED_ZFS_EXPORTER_SOCKET (default: /run/zfs_exporter/zed.sock), or
+# ZED_ZFS_EXPORTER_URL (e.g. http://127.0.0.1:9135/zed) if set in zed.rc.
+#
+# Exit codes:
+#   0: event forwarded
+#   1: forwarding failed
+#   2: curl not found
+
+[ -f "${ZED_ZEDLET_DIR}/zed.rc" ] && . "${ZED_ZEDLET_DIR}/zed.rc"
+. "${ZED_ZEDLET_DIR}/zed-functions.sh"
+
+: "${ZED_ZFS_EXPORTER_SOCKET:=/run/zfs_exporter/zed.sock}"
+
+zed_check_cmd "curl" || exit 2
+
+if [ -n "${ZED_ZFS_EXPORTER_URL}" ]; then
+	env | grep '^ZEVENT_' | curl -fsS -m 5 --data-binary @- "${ZED_ZFS_EXPORTER_URL}"
+else
+	env | grep '^ZEVENT_' | curl -fsS -m 5 --unix-socket "${ZED_ZFS_EXPORTER_SOCKET}" --data-binary @- http://localhost/zed
+fi || {
+	zed_log_err "failed to forward ${ZEVENT_CLASS} event ${ZEVENT_EID} to zfs_exporter"
+	exit 1
+}
+
+exit 0
@@ -0,0 +1,86 @@
+package zfs
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+const (
+	compatibilityOff    = `off`
+	compatibilityLegacy = `legacy`
+)
+
+// compatibilityDirs are searched in order for the feature set files named by the compatibility property of a pool
+var compatibilityDirs = []string{`/etc/zfs/compatibility.d`, `/usr/share/zfs/compatibility.d`}
+
+// readCompatibilityFeatures returns the features allowed by the compatibility property of a pool, i.e. those listed in
+// every feature set file it names, the first file found in dirs being used. Nil is returned when features are not
+// restricted (`off`, or unset by releases before OpenZFS 2.1), and no features for `legacy`.
+func readCompatibilityFeatures(dirs []string, compatibility string) ([]string, error) {
+	switch compatibility {
+	case ``, `-`, compatibilityOff:
+		return nil, nil
+	case compatibilityLegacy:
+		return []string{}, nil
+	}
+
+	var allowed []string
+	for i, name := range strings.Split(compatibility, `,`) {
+		features, err := readFeatureSet(dirs, name)
+		if err != nil {
+			return nil, err
+		}
+		if i == 0 {
+			allowed = features
+			continue
+		}
+		allowed = slices.DeleteFunc(allowed, func(f string) bool { return !slices.Contains(features, f) })
+	}
+
+	return allowed, nil
+}
+
+// readFeatureSet reads the features listed in a feature set file, separated by whitespace or commas, with `#` starting
+// a comment
+func readFeatureSet(dirs []string, name string) ([]string, error) {
+	var (
+		f   *os.File
+		err error
+	)
+	if filepath.IsAbs(name) {
+		f, err = os.Open(name)
+	} else {
+		for _, dir := range dirs {
+			if f, err = os.Open(filepath.Join(dir, name)); err == nil || !os.IsNotExist(err) {
+				break
+			}
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading feature set '%s': %w", name, err)
+	}
+	defer f.Close()
+
+	var features []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line, _, _ := strings.Cut(scanner.Text(), `#`)
+		features = append(features, strings.FieldsFunc(line, func(r rune) bool {
+			return r == ',' || r == ' ' || r == '\t'
+		})...)
+	}
+	if err = scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading feature set '%s': %w", name, err)
+	}
+
+	return features, nil
+}
+
+// compatibilityFeatures returns the features allowed by the compatibility property of a pool
+func compatibilityFeatures(compatibility string) ([]string, error) {
+	return readCompatibilityFeatures(compatibilityDirs, compatibility)
+}
@@ -0,0 +1,76 @@
+package zfs
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestReadCompatibilityFeatures(t *testing.T) {
+	dirs := []string{`testdata/compatibility.d/etc`, `testdata/compatibility.d/share`}
+	absolute, err := filepath.Abs(`testdata/compatibility.d/share/grub2`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testCases := []struct {
+		name          string
+		compatibility string
+		want          []string
+		wantErr       bool
+	}{
+		{
+			name:          `off`,
+			compatibility: `off`,
+		},
+		{
+			name:          `unset`,
+			compatibility: ``,
+		},
+		{
+			name:          `legacy`,
+			compatibility: `legacy`,
+			want:          []string{},
+		},
+		{
+			name:          `single`,
+			compatibility: `openzfs-2.1-linux`,
+			want:          []string{`allocation_classes`, `async_destroy`, `bookmark_v2`, `bookmarks`, `device_removal`, `embedded_data`},
+		},
+		{
+			// Files in the first directory override those in later directories.
+			name:          `intersection`,
+			compatibility: `openzfs-2.1-linux,grub2`,
+			want:          []string{`async_destroy`, `embedded_data`},
+		},
+		{
+			name:          `absolute`,
+			compatibility: `openzfs-2.1-linux,` + absolute,
+			want:          []string{`async_destroy`, `bookmarks`, `embedded_data`},
+		},
+		{
+			name:          `missing`,
+			compatibility: `openzfs-2.1-linux,unknown`,
+			wantErr:       true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := readCompatibilityFeatures(dirs, tc.compatibility)
+			if tc.wantErr {
+				if !errors.Is(err, os.ErrNotExist) {
+					t.Fatalf(`got error %v, want not exist`, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf(`got features %q, want %q`, got, tc.want)
+			}
+		})
+	}
+}
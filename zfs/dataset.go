@@ -19,6 +19,7 @@ const (
 type datasetsImpl struct {
 	pool string
 	kind DatasetKind
+	exec *executor
 }
 
 func (d datasetsImpl) Pool() string {
@@ -31,7 +32,7 @@ func (d datasetsImpl) Kind() DatasetKind {
 
 func (d datasetsImpl) Properties(props ...string) ([]DatasetProperties, error) {
 	handler := newDatasetHandler()
-	if err := execute(d.pool, handler, `zfs`, `get`, `-Hprt`, string(d.kind), `-o`, `name,property,value`, strings.Join(props, `,`)); err != nil {
+	if err := d.exec.execute(d.pool, handler, `zfs`, `get`, `-Hprt`, string(d.kind), `-o`, `name,property,value`, strings.Join(props, `,`)); err != nil {
 		return nil, err
 	}
 	return handler.datasets(), nil
@@ -84,10 +85,11 @@ func newDatasetPropertiesImpl(name string) *datasetPropertiesImpl {
 	}
 }
 
-func newDatasetsImpl(pool string, kind DatasetKind) datasetsImpl {
+func newDatasetsImpl(pool string, kind DatasetKind, exec *executor) datasetsImpl {
 	return datasetsImpl{
 		pool: pool,
 		kind: kind,
+		exec: exec,
 	}
 }
 
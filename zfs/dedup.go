@@ -0,0 +1,118 @@
+package zfs
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	// dedupSummaryRegexp matches the summary of the DDT, whose sizes are the average size of an entry. OpenZFS 2.3 or
+	// newer may follow it with the amount of the DDT cached in the ARC.
+	dedupSummaryRegexp = regexp.MustCompile(`^\s*dedup: DDT entries (\d+), size (\S+) on disk, (\S+) in core`)
+	dedupNoneRegexp    = regexp.MustCompile(`^\s*dedup: no DDT entries`)
+)
+
+// DedupStats holds the statistics of the deduplication table (DDT) of a pool
+type DedupStats struct {
+	// Entries is the number of DDT entries, i.e. of unique blocks written with dedup enabled
+	Entries uint64
+	// DiskEntrySize is the average size in bytes of an entry on disk
+	DiskEntrySize uint64
+	// CoreEntrySize is the average size in bytes of an entry in memory
+	CoreEntrySize uint64
+	// Histogram holds the entries by reference count, in ascending order
+	Histogram []DedupBucket
+}
+
+// DedupBucket holds the DDT entries whose reference count falls within a power of two
+type DedupBucket struct {
+	// RefCount is the lowest reference count of the bucket, counting up to twice as much exclusive
+	RefCount uint64
+	// Allocated holds the blocks stored on disk, one per entry
+	Allocated DedupBlocks
+	// Referenced holds the blocks referenced by the pool, counting each reference to an entry
+	Referenced DedupBlocks
+}
+
+// DedupBlocks holds the number and sizes in bytes of deduplicated blocks. The CLI reports them rounded to three
+// significant digits.
+type DedupBlocks struct {
+	Blocks  uint64
+	Logical uint64
+	// Physical is the size after compression
+	Physical uint64
+	// Allocated is the size on disk, including parity and padding
+	Allocated uint64
+}
+
+// parseDedupStats parses the DDT summary and histogram following the status of the pool in `zpool status -D`. Sizes
+// and counts are always formatted by the CLI (e.g. `1.03M`), except for the summary.
+func parseDedupStats(r io.Reader) (DedupStats, error) {
+	stats := DedupStats{}
+	found := false
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !found {
+			if dedupNoneRegexp.MatchString(line) {
+				found = true
+			} else if m := dedupSummaryRegexp.FindStringSubmatch(line); m != nil {
+				found = true
+				var err error
+				if stats.Entries, err = strconv.ParseUint(m[1], 10, 64); err != nil {
+					return stats, fmt.Errorf("%w: invalid DDT entries '%s'", ErrInvalidOutput, m[1])
+				}
+				if stats.DiskEntrySize, err = parseNicenum(m[2]); err != nil {
+					return stats, err
+				}
+				if stats.CoreEntrySize, err = parseNicenum(m[3]); err != nil {
+					return stats, err
+				}
+			}
+			continue
+		}
+
+		// Skip the table headers and the total, which is the sum of the buckets.
+		fields := strings.Fields(line)
+		if len(fields) != 9 || fields[0] == `refcnt` || fields[0] == `Total` || strings.HasPrefix(fields[0], `-`) {
+			continue
+		}
+		values := make([]uint64, len(fields))
+		for i, v := range fields {
+			var err error
+			if values[i], err = parseNicenum(v); err != nil {
+				return stats, err
+			}
+		}
+		stats.Histogram = append(stats.Histogram, DedupBucket{
+			RefCount:   values[0],
+			Allocated:  DedupBlocks{Blocks: values[1], Logical: values[2], Physical: values[3], Allocated: values[4]},
+			Referenced: DedupBlocks{Blocks: values[5], Logical: values[6], Physical: values[7], Allocated: values[8]},
+		})
+	}
+	if err := scanner.Err(); err != nil {
+		return stats, err
+	}
+	if !found {
+		return stats, fmt.Errorf("%w: missing DDT summary", ErrInvalidOutput)
+	}
+
+	return stats, nil
+}
+
+// DedupStats reads the statistics of the deduplication table of the pool from `zpool status -D`, which may be slow
+// on pools with a large table
+func (p poolImpl) DedupStats() (DedupStats, error) {
+	var stats DedupStats
+	err := p.exec.run(p.name, func(r io.Reader) error {
+		var err error
+		stats, err = parseDedupStats(r)
+		return err
+	}, `zpool`, `status`, `-Dp`, p.name)
+
+	return stats, err
+}
@@ -0,0 +1,61 @@
+package zfs
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseDedupStats(t *testing.T) {
+	stats, err := parseDedupStats(openFixture(t, `zpool_status_dedup.txt`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Entries != 1234567 || stats.DiskEntrySize != 289 || stats.CoreEntrySize != 186 {
+		t.Errorf(`got summary %+v`, stats)
+	}
+
+	// The total is skipped.
+	want := []DedupBucket{
+		{
+			RefCount:   1,
+			Allocated:  DedupBlocks{Blocks: 1080033, Logical: 131 << 30, Physical: 128 << 30, Allocated: 128 << 30},
+			Referenced: DedupBlocks{Blocks: 1080033, Logical: 131 << 30, Physical: 128 << 30, Allocated: 128 << 30},
+		},
+		{
+			RefCount:   2,
+			Allocated:  DedupBlocks{Blocks: 153 << 10, Logical: 20508468838, Physical: 19971597926, Allocated: 19971597926},
+			Referenced: DedupBlocks{Blocks: 330 << 10, Logical: 44345537331, Physical: 43164421324, Allocated: 43164421324},
+		},
+		{
+			RefCount:   4,
+			Allocated:  DedupBlocks{Blocks: 3491, Logical: 436 << 20, Physical: 425 << 20, Allocated: 425 << 20},
+			Referenced: DedupBlocks{Blocks: 15462, Logical: 2029372047, Physical: 1975684956, Allocated: 1975684956},
+		},
+		{
+			RefCount:   1 << 10,
+			Allocated:  DedupBlocks{Blocks: 2, Logical: 256 << 10, Physical: 256 << 10, Allocated: 256 << 10},
+			Referenced: DedupBlocks{Blocks: 2560, Logical: 320 << 20, Physical: 320 << 20, Allocated: 320 << 20},
+		},
+	}
+	if len(stats.Histogram) != len(want) {
+		t.Fatalf(`got %d buckets, want %d`, len(stats.Histogram), len(want))
+	}
+	for i, v := range want {
+		if stats.Histogram[i] != v {
+			t.Errorf(`got bucket %+v, want %+v`, stats.Histogram[i], v)
+		}
+	}
+}
+
+func TestParseDedupStatsEmpty(t *testing.T) {
+	stats, err := parseDedupStats(strings.NewReader("  pool: tank\n state: ONLINE\n\n dedup: no DDT entries\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Entries != 0 || len(stats.Histogram) != 0 {
+		t.Errorf(`got %+v, want no entries`, stats)
+	}
+	if _, err = parseDedupStats(strings.NewReader("  pool: tank\n state: ONLINE\n")); err == nil {
+		t.Error(`expected error parsing status without DDT summary`)
+	}
+}
@@ -0,0 +1,118 @@
+package zfs
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// hostIDPath holds the hostid used when the spl_hostid module parameter is unset
+const hostIDPath = `/etc/hostid`
+
+// RequiredBinaries lists the commands the client depends upon
+var RequiredBinaries = []string{`zfs`, `zpool`}
+
+// Environment describes the OpenZFS installation, as found by Probe
+type Environment struct {
+	// Kmod is the version of the loaded kernel module, with an empty Raw value if unknown.
+	Kmod Version
+	// Userland is the version of the `zfs` command, with an empty Raw value if unknown.
+	Userland Version
+	// Binaries reports whether each of the RequiredBinaries was found on PATH.
+	Binaries map[string]bool
+}
+
+// Mismatch reports whether the kernel module and userland versions are both known, and differ
+func (e Environment) Mismatch() bool {
+	return e.Kmod.Raw != `` && e.Userland.Raw != `` && e.Kmod.String() != e.Userland.String()
+}
+
+// Version returns the userland version, which determines the properties understood by `zfs get` and `zpool get`,
+// falling back to the kernel module version. An empty Raw value is returned if neither is known.
+func (e Environment) Version() Version {
+	if e.Userland.Raw != `` {
+		return e.Userland
+	}
+
+	return e.Kmod
+}
+
+// Backend returns the preferred backend for the installed OpenZFS version, BackendText if unknown
+func (e Environment) Backend() Backend {
+	if e.Userland.Raw != `` && e.Userland.AtLeast(2, 3) {
+		return BackendJSON
+	}
+
+	return BackendText
+}
+
+// readKmodVersion reads the version of the zfs kernel module under root
+func readKmodVersion(root string) (Version, error) {
+	b, err := os.ReadFile(filepath.Join(root, `zfs`, `version`))
+	if err != nil {
+		return Version{}, err
+	}
+
+	return ParseVersion(strings.TrimSpace(string(b)))
+}
+
+// Probe inspects the OpenZFS installation. Any part of the Environment which cannot be determined is left empty, with
+// the reasons joined in the returned error.
+func Probe(policy ExecPolicy) (Environment, error) {
+	var errs []error
+	env := Environment{Binaries: make(map[string]bool, len(RequiredBinaries))}
+	for _, name := range RequiredBinaries {
+		_, err := exec.LookPath(name)
+		env.Binaries[name] = err == nil
+	}
+
+	v, err := readKmodVersion(modulePath)
+	if err != nil {
+		errs = append(errs, fmt.Errorf("reading kernel module version: %w", err))
+	}
+	env.Kmod = v
+
+	if env.Binaries[`zfs`] {
+		if v, err = newExecutor(policy).userlandVersion(); err != nil {
+			errs = append(errs, fmt.Errorf("reading userland version: %w", err))
+		}
+		env.Userland = v
+	}
+
+	return env, errors.Join(errs...)
+}
+
+// readHostID returns the hostid of the system, as used by the multihost protection, formatted as 8 hex digits. The
+// spl_hostid module parameter under root takes precedence, otherwise the host byte order value in hostIDFile is used.
+func readHostID(root, hostIDFile string) (string, error) {
+	b, err := os.ReadFile(filepath.Join(root, `spl`, `parameters`, `spl_hostid`))
+	if err == nil {
+		id, err := strconv.ParseUint(strings.TrimSpace(string(b)), 0, 32)
+		if err != nil {
+			return ``, fmt.Errorf("%w: invalid spl_hostid '%s'", ErrInvalidOutput, strings.TrimSpace(string(b)))
+		}
+		if id != 0 {
+			return fmt.Sprintf("%08x", id), nil
+		}
+	}
+
+	b, err = os.ReadFile(hostIDFile)
+	if err != nil {
+		return ``, err
+	}
+	if len(b) < 4 {
+		return ``, fmt.Errorf("%w: invalid hostid file '%s'", ErrInvalidOutput, hostIDFile)
+	}
+
+	return fmt.Sprintf("%08x", binary.NativeEndian.Uint32(b[:4])), nil
+}
+
+// hostID returns the hostid of the system
+func hostID() (string, error) {
+	return readHostID(modulePath, hostIDPath)
+}
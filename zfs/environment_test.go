@@ -0,0 +1,75 @@
+package zfs
+
+import (
+	"encoding/binary"
+	"fmt"
+	"testing"
+)
+
+func TestReadKmodVersion(t *testing.T) {
+	v, err := readKmodVersion(`testdata/sys/module`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v.String() != `2.2.6` || v.Raw != `2.2.6-1ubuntu1` {
+		t.Errorf(`got version %s (%q)`, v, v.Raw)
+	}
+}
+
+func TestEnvironment(t *testing.T) {
+	testCases := []struct {
+		name         string
+		env          Environment
+		wantMismatch bool
+		wantVersion  string
+		wantBackend  Backend
+	}{
+		{
+			name:         `matching`,
+			env:          Environment{Kmod: Version{Major: 2, Minor: 3, Patch: 1, Raw: `2.3.1-1`}, Userland: Version{Major: 2, Minor: 3, Patch: 1, Raw: `zfs-2.3.1-1`}},
+			wantMismatch: false,
+			wantVersion:  `2.3.1`,
+			wantBackend:  BackendJSON,
+		},
+		{
+			name:         `mismatch`,
+			env:          Environment{Kmod: Version{Major: 2, Minor: 1, Patch: 5, Raw: `2.1.5-1`}, Userland: Version{Major: 2, Minor: 2, Patch: 2, Raw: `zfs-2.2.2-1`}},
+			wantMismatch: true,
+			wantVersion:  `2.2.2`,
+			wantBackend:  BackendText,
+		},
+		{
+			name:         `kmod only`,
+			env:          Environment{Kmod: Version{Major: 2, Minor: 3, Patch: 0, Raw: `2.3.0-1`}},
+			wantMismatch: false,
+			wantVersion:  `2.3.0`,
+			wantBackend:  BackendText,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := tc.env.Mismatch(); got != tc.wantMismatch {
+				t.Errorf(`got mismatch %t, want %t`, got, tc.wantMismatch)
+			}
+			if got := tc.env.Version().String(); got != tc.wantVersion {
+				t.Errorf(`got version %s, want %s`, got, tc.wantVersion)
+			}
+			if got := tc.env.Backend(); got != tc.wantBackend {
+				t.Errorf(`got backend %s, want %s`, got, tc.wantBackend)
+			}
+		})
+	}
+}
+
+func TestReadHostID(t *testing.T) {
+	id, err := readHostID(`testdata/sys/module`, `testdata/etc/hostid`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The hostid file is stored in host byte order.
+	want := fmt.Sprintf("%08x", binary.NativeEndian.Uint32([]byte{0xef, 0xbe, 0xad, 0x8a}))
+	if id != want {
+		t.Errorf(`got hostid %q, want %q`, id, want)
+	}
+}
@@ -0,0 +1,113 @@
+package zfs
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	// eventDroppedRegexp matches the notice of `zpool events -f` falling behind the kernel event queue
+	eventDroppedRegexp = regexp.MustCompile(`^dropped (\d+) events`)
+	// eventAttributeRegexp matches a top-level attribute of a verbose event, nested attributes being further indented
+	eventAttributeRegexp = regexp.MustCompile(`^ {8}([a-z0-9_]+) = (.*)$`)
+)
+
+// Event holds a ZFS event, as reported by `zpool events -v`
+type Event struct {
+	// EID is the identifier of the event, increasing for as long as the zfs module is loaded
+	EID   uint64
+	Time  time.Time
+	Class string
+	// Attributes holds the top-level attributes of the event (e.g. `pool` or `vdev_path`), with strings unquoted
+	Attributes map[string]string
+	// Dropped is set instead of the above on events lost as the follower fell behind, holding the number of events
+	Dropped int
+}
+
+// EventHandler receives the events of `zpool events`, in the order they were posted
+type EventHandler func(e Event)
+
+// parseEvents parses the output of `zpool events -Hv`, passing each event to the handler. Each event starts with its
+// time and class, followed by an indented attribute per line, and a blank line.
+func parseEvents(r io.Reader, h EventHandler) error {
+	var current *Event
+	flush := func() {
+		if current != nil {
+			h(*current)
+			current = nil
+		}
+	}
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.TrimSpace(line) == `` {
+			flush()
+			continue
+		}
+		if m := eventDroppedRegexp.FindStringSubmatch(line); m != nil {
+			flush()
+			dropped, _ := strconv.Atoi(m[1])
+			h(Event{Dropped: dropped})
+			continue
+		}
+		if !strings.HasPrefix(line, ` `) {
+			flush()
+			fields := strings.Split(line, "\t")
+			if len(fields) != 2 {
+				return fmt.Errorf("%w: invalid event line '%s'", ErrInvalidOutput, line)
+			}
+			current = &Event{Class: fields[1], Attributes: make(map[string]string)}
+			continue
+		}
+		m := eventAttributeRegexp.FindStringSubmatch(line)
+		if m == nil || current == nil {
+			continue
+		}
+		value := m[2]
+		if unquoted, err := strconv.Unquote(value); err == nil {
+			value = unquoted
+		}
+		current.Attributes[m[1]] = value
+		switch m[1] {
+		case `eid`:
+			current.EID, _ = strconv.ParseUint(value, 0, 64)
+		case `time`:
+			current.Time = parseEventTime(value)
+		}
+	}
+	flush()
+
+	return scanner.Err()
+}
+
+// parseEventTime parses the time attribute of an event, holding seconds and nanoseconds in hexadecimal
+func parseEventTime(value string) time.Time {
+	fields := strings.Fields(value)
+	if len(fields) != 2 {
+		return time.Time{}
+	}
+	sec, err := strconv.ParseInt(fields[0], 0, 64)
+	if err != nil {
+		return time.Time{}
+	}
+	nsec, err := strconv.ParseInt(fields[1], 0, 64)
+	if err != nil {
+		return time.Time{}
+	}
+
+	return time.Unix(sec, nsec)
+}
+
+// events follows `zpool events`, starting with the events already queued by the kernel
+func (e *executor) events(ctx context.Context, h EventHandler) error {
+	return e.follow(ctx, func(r io.Reader) error {
+		return parseEvents(r, h)
+	}, `zpool`, `events`, `-H`, `-f`, `-v`)
+}
@@ -0,0 +1,52 @@
+package zfs
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseEvents(t *testing.T) {
+	var events []Event
+	if err := parseEvents(openFixture(t, `zpool_events.txt`), func(e Event) { events = append(events, e) }); err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 4 {
+		t.Fatalf(`got %d events, want 4`, len(events))
+	}
+
+	checksum := events[1]
+	if checksum.Class != `ereport.fs.zfs.checksum` || checksum.EID != 0x1d {
+		t.Errorf(`got class %q and eid %d`, checksum.Class, checksum.EID)
+	}
+	if want := time.Unix(0x666eb99c, 500000000); !checksum.Time.Equal(want) {
+		t.Errorf(`got time %s, want %s`, checksum.Time, want)
+	}
+	want := map[string]string{
+		`pool`:       `tank`,
+		`vdev_path`:  `/dev/sda1`,
+		`bad_ranges`: `0x0 0x20000 `,
+	}
+	for k, v := range want {
+		if checksum.Attributes[k] != v {
+			t.Errorf(`got %s = %q, want %q`, k, checksum.Attributes[k], v)
+		}
+	}
+	// Attributes of nested lists are not reported at the top level.
+	if _, ok := checksum.Attributes[`scheme`]; ok {
+		t.Error(`got unexpected nested attribute scheme`)
+	}
+
+	if events[2].Dropped != 3 || events[2].Class != `` {
+		t.Errorf(`got %+v, want 3 dropped events`, events[2])
+	}
+	if events[3].Class != `resource.fs.zfs.statechange` || events[3].Attributes[`vdev_state`] != `"FAULTED" (0x5)` {
+		t.Errorf(`got %+v`, events[3])
+	}
+}
+
+func TestParseEventsInvalid(t *testing.T) {
+	if err := parseEvents(strings.NewReader("TIME CLASS\n"), func(Event) {}); err == nil {
+		t.Error(`expected error parsing invalid event line`)
+	}
+}
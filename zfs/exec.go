@@ -0,0 +1,121 @@
+package zfs
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"sync"
+)
+
+// IOClass enum of supported I/O scheduling classes for child processes
+type IOClass string
+
+const (
+	// IOClassNone enum entry, leaves the I/O scheduling class untouched
+	IOClassNone IOClass = `none`
+	// IOClassIdle enum entry
+	IOClassIdle IOClass = `idle`
+	// IOClassBestEffort enum entry
+	IOClassBestEffort IOClass = `best-effort`
+)
+
+// ExecPolicy controls how `zfs`/`zpool` child processes are executed
+type ExecPolicy struct {
+	// MaxConcurrency caps the number of commands running at once, 0 for unlimited.
+	MaxConcurrency int
+	// SerializePools allows only a single command per pool to run at once.
+	SerializePools bool
+	// Nice is the niceness adjustment applied to child processes, 0 to disable.
+	Nice int
+	// IOClass is the I/O scheduling class applied to child processes.
+	IOClass IOClass
+	// IOPriority is the priority level within the best-effort I/O class [0: highest, 7: lowest].
+	IOPriority int
+}
+
+// Validate checks that the policy can be applied, so that invalid settings are reported at startup rather than
+// failing every command.
+func (p ExecPolicy) Validate() error {
+	if p.MaxConcurrency < 0 {
+		return fmt.Errorf(`invalid max concurrency %d, must not be negative`, p.MaxConcurrency)
+	}
+	if p.IOPriority < 0 || p.IOPriority > 7 {
+		return fmt.Errorf(`invalid I/O priority %d, must be from 0 to 7`, p.IOPriority)
+	}
+
+	return nil
+}
+
+// wrap prefixes the command with the tools required to apply the scheduling policy.
+func (p ExecPolicy) wrap(name string, args ...string) (string, []string) {
+	cmd := append([]string{name}, args...)
+	switch p.IOClass {
+	case IOClassIdle:
+		cmd = append([]string{`ionice`, `-c`, `3`}, cmd...)
+	case IOClassBestEffort:
+		cmd = append([]string{`ionice`, `-c`, `2`, `-n`, strconv.Itoa(p.IOPriority)}, cmd...)
+	}
+	if p.Nice != 0 {
+		cmd = append([]string{`nice`, `-n`, strconv.Itoa(p.Nice)}, cmd...)
+	}
+
+	return cmd[0], cmd[1:]
+}
+
+// executor applies an ExecPolicy to the commands run by the client
+type executor struct {
+	policy ExecPolicy
+	slots  chan struct{}
+	mu     sync.Mutex
+	pools  map[string]*sync.Mutex
+}
+
+// acquire blocks until the policy allows a command to run against the pool, and returns the matching release func.
+// An empty pool name only applies the global concurrency limit.
+func (e *executor) acquire(pool string) func() {
+	var poolLock *sync.Mutex
+	if e.policy.SerializePools && pool != `` {
+		e.mu.Lock()
+		poolLock = e.pools[pool]
+		if poolLock == nil {
+			poolLock = &sync.Mutex{}
+			e.pools[pool] = poolLock
+		}
+		e.mu.Unlock()
+		poolLock.Lock()
+	}
+	if e.slots != nil {
+		e.slots <- struct{}{}
+	}
+
+	return func() {
+		if e.slots != nil {
+			<-e.slots
+		}
+		if poolLock != nil {
+			poolLock.Unlock()
+		}
+	}
+}
+
+// command builds a child process according to the policy, with a stable locale for parsable output.
+func (e *executor) command(name string, args ...string) *exec.Cmd {
+	name, args = e.policy.wrap(name, args...)
+	c := exec.Command(name, args...)
+	c.Env = append(os.Environ(), `LC_ALL=C`)
+
+	return c
+}
+
+func newExecutor(policy ExecPolicy) *executor {
+	e := &executor{
+		policy: policy,
+		pools:  make(map[string]*sync.Mutex),
+	}
+	if policy.MaxConcurrency > 0 {
+		e.slots = make(chan struct{}, policy.MaxConcurrency)
+	}
+
+	return e
+}
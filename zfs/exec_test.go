@@ -0,0 +1,129 @@
+package zfs
+
+import (
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestExecPolicyWrap(t *testing.T) {
+	testCases := []struct {
+		name     string
+		policy   ExecPolicy
+		wantName string
+		wantArgs []string
+	}{
+		{
+			name:     `default`,
+			policy:   ExecPolicy{},
+			wantName: `zpool`,
+			wantArgs: []string{`list`, `-Ho`, `name`},
+		},
+		{
+			name:     `nice`,
+			policy:   ExecPolicy{Nice: 10},
+			wantName: `nice`,
+			wantArgs: []string{`-n`, `10`, `zpool`, `list`, `-Ho`, `name`},
+		},
+		{
+			name:     `ionice idle`,
+			policy:   ExecPolicy{IOClass: IOClassIdle},
+			wantName: `ionice`,
+			wantArgs: []string{`-c`, `3`, `zpool`, `list`, `-Ho`, `name`},
+		},
+		{
+			name:     `nice and ionice best-effort`,
+			policy:   ExecPolicy{Nice: 19, IOClass: IOClassBestEffort, IOPriority: 7},
+			wantName: `nice`,
+			wantArgs: []string{`-n`, `19`, `ionice`, `-c`, `2`, `-n`, `7`, `zpool`, `list`, `-Ho`, `name`},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			name, args := tc.policy.wrap(`zpool`, `list`, `-Ho`, `name`)
+			if name != tc.wantName {
+				t.Errorf(`got name %q, want %q`, name, tc.wantName)
+			}
+			if !reflect.DeepEqual(args, tc.wantArgs) {
+				t.Errorf(`got args %q, want %q`, args, tc.wantArgs)
+			}
+		})
+	}
+}
+
+func TestExecPolicyValidate(t *testing.T) {
+	testCases := []struct {
+		name    string
+		policy  ExecPolicy
+		wantErr bool
+	}{
+		{name: `default`, policy: ExecPolicy{IOPriority: 7}},
+		{name: `highest priority`, policy: ExecPolicy{IOClass: IOClassBestEffort, IOPriority: 0}},
+		{name: `priority too high`, policy: ExecPolicy{IOClass: IOClassBestEffort, IOPriority: 8}, wantErr: true},
+		{name: `negative priority`, policy: ExecPolicy{IOPriority: -1}, wantErr: true},
+		{name: `negative concurrency`, policy: ExecPolicy{MaxConcurrency: -1}, wantErr: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := tc.policy.Validate(); (err != nil) != tc.wantErr {
+				t.Errorf(`got error %v, want error %v`, err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestExecutorAcquire(t *testing.T) {
+	testCases := []struct {
+		name    string
+		policy  ExecPolicy
+		pools   []string
+		wantMax int32
+	}{
+		{
+			name:    `concurrency limit`,
+			policy:  ExecPolicy{MaxConcurrency: 2},
+			pools:   []string{`a`, `b`, `c`, `d`, `e`, `f`},
+			wantMax: 2,
+		},
+		{
+			name:    `serialized pool`,
+			policy:  ExecPolicy{SerializePools: true},
+			pools:   []string{`a`, `a`, `a`, `a`},
+			wantMax: 1,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			e := newExecutor(tc.policy)
+			var running, peak atomic.Int32
+			var wg sync.WaitGroup
+			for _, pool := range tc.pools {
+				wg.Add(1)
+				go func(pool string) {
+					defer wg.Done()
+					release := e.acquire(pool)
+					defer release()
+					n := running.Add(1)
+					for {
+						p := peak.Load()
+						if n <= p || peak.CompareAndSwap(p, n) {
+							break
+						}
+					}
+					time.Sleep(10 * time.Millisecond)
+					running.Add(-1)
+				}(pool)
+			}
+			wg.Wait()
+
+			if got := peak.Load(); got > tc.wantMax {
+				t.Errorf(`got %d concurrent commands, want at most %d`, got, tc.wantMax)
+			}
+		})
+	}
+}
@@ -0,0 +1,114 @@
+package zfs
+
+import (
+	"bufio"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// historyTimeFormat is the format of the time of history records, in the local time zone
+const historyTimeFormat = `2006-01-02.15:04:05`
+
+var (
+	historyRecordRegexp = regexp.MustCompile(`^(\d{4}-\d{2}-\d{2}\.\d{2}:\d{2}:\d{2}) (.*)$`)
+	// historyUserRegexp matches the suffix of commands in the long format, e.g. `[user 0 (root) on nas:linux]`
+	historyUserRegexp = regexp.MustCompile(`^(.*) \[user \d+ \((.*)\) on ([^:\]]*)(?::[^\]]*)?\]$`)
+	// historyInternalRegexp matches internal events in the long format, e.g. `[txg:8955] scan setup tank (0) func=1
+	// [on nas]`, whose names are a single word unless prefixed by the subsystem (e.g. `scan done` or `vdev attach`)
+	historyInternalRegexp = regexp.MustCompile(`^\[txg:\d+\] ((?:scan|vdev|clone|pool|finish) \S+|\S+) ?(.*?)(?: \[on ([^\]]*)\])?$`)
+)
+
+// HistoryRecord holds a record of the pool history, either a command or an internal event
+type HistoryRecord struct {
+	Time time.Time
+	// Command is the command line of a `zfs` or `zpool` command, empty for internal events
+	Command string
+	// Event is the name of an internal event (e.g. `scan setup` or `destroy`), empty for commands
+	Event string
+	// Detail holds the arguments of an internal event, e.g. the dataset and properties set
+	Detail string
+	// User is the name of the user who ran a command
+	User string
+	// Host is the host on which the command ran or the event happened
+	Host string
+}
+
+// HistoryHandler receives the records of the pool history, oldest first
+type HistoryHandler func(r HistoryRecord)
+
+// HistoryCursor marks the last record read from the pool history, so that following reads skip the records already
+// read. Records are identified by their time, to second precision, and their order within the same second, as the
+// oldest records are dropped once the history is full.
+type HistoryCursor struct {
+	Time time.Time
+	// Records is the number of records read with the same time
+	Records int
+}
+
+// parseHistory parses the output of `zpool history -il`, passing each record following the cursor to the handler and
+// returning the cursor of the last record. Records preceding the cursor are skipped without being parsed, along with
+// the arguments of ioctls, which continue the record on indented lines.
+func parseHistory(r io.Reader, cursor HistoryCursor, h HistoryHandler) (HistoryCursor, error) {
+	skip := cursor.Records
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		m := historyRecordRegexp.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		t, err := time.ParseInLocation(historyTimeFormat, m[1], time.Local)
+		if err != nil {
+			continue
+		}
+		if t.Before(cursor.Time) {
+			continue
+		}
+		if t.Equal(cursor.Time) {
+			if skip > 0 {
+				skip--
+				continue
+			}
+			cursor.Records++
+		} else {
+			cursor = HistoryCursor{Time: t, Records: 1}
+		}
+		h(parseHistoryRecord(t, m[2]))
+	}
+
+	return cursor, scanner.Err()
+}
+
+func parseHistoryRecord(t time.Time, text string) HistoryRecord {
+	record := HistoryRecord{Time: t}
+	if m := historyInternalRegexp.FindStringSubmatch(text); m != nil {
+		record.Event, record.Detail, record.Host = m[1], m[2], m[3]
+		return record
+	}
+	if m := historyUserRegexp.FindStringSubmatch(text); m != nil {
+		record.Command, record.User, record.Host = m[1], m[2], m[3]
+		return record
+	}
+	// Legacy internal events (e.g. `[internal ...]`) and ioctls are reported as events named after their first field.
+	if strings.HasPrefix(text, `[`) || strings.HasPrefix(text, `ioctl `) {
+		record.Event, record.Detail, _ = strings.Cut(strings.TrimPrefix(text, `[`), ` `)
+		return record
+	}
+	record.Command = text
+
+	return record
+}
+
+// History reads the records of the pool history from `zpool history -il` following the cursor, returning the cursor
+// of the last record read
+func (p poolImpl) History(cursor HistoryCursor, h HistoryHandler) (HistoryCursor, error) {
+	err := p.exec.run(p.name, func(r io.Reader) error {
+		var err error
+		cursor, err = parseHistory(r, cursor, h)
+		return err
+	}, `zpool`, `history`, `-il`, p.name)
+
+	return cursor, err
+}
@@ -0,0 +1,67 @@
+package zfs
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseHistory(t *testing.T) {
+	var records []HistoryRecord
+	cursor, err := parseHistory(openFixture(t, `zpool_history.txt`), HistoryCursor{}, func(r HistoryRecord) { records = append(records, r) })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 14 {
+		t.Fatalf(`got %d records, want 14`, len(records))
+	}
+
+	testCases := map[int]HistoryRecord{
+		0:  {Event: `create`, Detail: `pool version 5000; software version zfs-2.2.4-1; uts nas 6.8.0-35-generic #35-Ubuntu SMP x86_64`, Host: `nas`},
+		1:  {Command: `zpool create -o ashift=12 tank mirror /dev/sda /dev/sdb`, User: `root`, Host: `nas`},
+		4:  {Event: `ioctl`, Detail: `snapshot`},
+		6:  {Event: `scan setup`, Detail: `tank (0) func=1 mintxg=0 maxtxg=8955`, Host: `nas`},
+		8:  {Event: `scan done`, Detail: `tank (0) errors=0`, Host: `nas`},
+		12: {Command: `zfs destroy -r tank/old`, User: `alice`, Host: `backup`},
+	}
+	for i, want := range testCases {
+		got := records[i]
+		got.Time = time.Time{}
+		if got != want {
+			t.Errorf(`got record %d %+v, want %+v`, i, got, want)
+		}
+	}
+	wantTime := time.Date(2024, 6, 16, 11, 5, 0, 0, time.Local)
+	if !records[13].Time.Equal(wantTime) {
+		t.Errorf(`got time %s, want %s`, records[13].Time, wantTime)
+	}
+	if cursor.Time != records[13].Time || cursor.Records != 1 {
+		t.Errorf(`got cursor %+v`, cursor)
+	}
+}
+
+func TestParseHistoryCursor(t *testing.T) {
+	// Two records were read in the same second as the destroy, only the upgrade follows them.
+	cursor := HistoryCursor{Time: time.Date(2024, 6, 16, 11, 2, 0, 0, time.Local), Records: 2}
+	var records []HistoryRecord
+	cursor, err := parseHistory(openFixture(t, `zpool_history.txt`), cursor, func(r HistoryRecord) { records = append(records, r) })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 1 || records[0].Command != `zpool upgrade tank` {
+		t.Fatalf(`got records %+v, want the upgrade only`, records)
+	}
+
+	// Records read within the same second as the previous read are counted onto the cursor.
+	cursor = HistoryCursor{Time: time.Date(2024, 6, 16, 11, 2, 0, 0, time.Local), Records: 1}
+	records = nil
+	cursor, err = parseHistory(openFixture(t, `zpool_history.txt`), cursor, func(r HistoryRecord) { records = append(records, r) })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 2 || records[0].Command != `zfs destroy -r tank/old` {
+		t.Fatalf(`got records %+v, want the destroy and upgrade`, records)
+	}
+	if cursor.Records != 1 {
+		t.Errorf(`got cursor %+v`, cursor)
+	}
+}
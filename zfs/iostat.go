@@ -0,0 +1,246 @@
+package zfs
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	// iostatColumns names the columns of `zpool iostat -Hpvlq` on OpenZFS 0.8
+	iostatColumns = []string{
+		`name`, `alloc`, `free`, `read_ops`, `write_ops`, `read_bytes`, `write_bytes`,
+		`total_wait_read`, `total_wait_write`, `disk_wait_read`, `disk_wait_write`,
+		`syncq_wait_read`, `syncq_wait_write`, `asyncq_wait_read`, `asyncq_wait_write`,
+		`scrub_wait`, `trim_wait`,
+		`syncq_read_pend`, `syncq_read_activ`, `syncq_write_pend`, `syncq_write_activ`,
+		`asyncq_read_pend`, `asyncq_read_activ`, `asyncq_write_pend`, `asyncq_write_activ`,
+		`scrubq_read_pend`, `scrubq_read_activ`, `trimq_write_pend`, `trimq_write_activ`,
+	}
+	// iostatRebuildColumns names the columns of `zpool iostat -Hpvlq` on OpenZFS 2.0 or newer, which adds the
+	// sequential rebuild wait and queue
+	iostatRebuildColumns = []string{
+		`name`, `alloc`, `free`, `read_ops`, `write_ops`, `read_bytes`, `write_bytes`,
+		`total_wait_read`, `total_wait_write`, `disk_wait_read`, `disk_wait_write`,
+		`syncq_wait_read`, `syncq_wait_write`, `asyncq_wait_read`, `asyncq_wait_write`,
+		`scrub_wait`, `trim_wait`, `rebuild_wait`,
+		`syncq_read_pend`, `syncq_read_activ`, `syncq_write_pend`, `syncq_write_activ`,
+		`asyncq_read_pend`, `asyncq_read_activ`, `asyncq_write_pend`, `asyncq_write_activ`,
+		`scrubq_read_pend`, `scrubq_read_activ`, `trimq_write_pend`, `trimq_write_activ`,
+		`rebuildq_write_pend`, `rebuildq_write_activ`,
+	}
+)
+
+// IostatHistogramKind enum of the distributions reported by `zpool iostat`
+type IostatHistogramKind string
+
+const (
+	// IostatLatency enum entry, the latency histograms of `zpool iostat -w`
+	IostatLatency IostatHistogramKind = `latency`
+	// IostatRequestSize enum entry, the request size histograms of `zpool iostat -r`
+	IostatRequestSize IostatHistogramKind = `request_size`
+)
+
+var (
+	// iostatHistogramFlags maps each histogram kind to the flag of `zpool iostat` reporting it
+	iostatHistogramFlags = map[IostatHistogramKind]string{
+		IostatLatency:     `-w`,
+		IostatRequestSize: `-r`,
+	}
+	// iostatHistogramColumns names the columns of each histogram kind on OpenZFS 0.8, followed by those on OpenZFS 2.0
+	// or newer, which add the sequential rebuild queue
+	iostatHistogramColumns = map[IostatHistogramKind][2][]string{
+		IostatLatency: {
+			{
+				`total_wait_read`, `total_wait_write`, `disk_wait_read`, `disk_wait_write`,
+				`syncq_wait_read`, `syncq_wait_write`, `asyncq_wait_read`, `asyncq_wait_write`,
+				`scrub_wait`, `trim_wait`,
+			},
+			{
+				`total_wait_read`, `total_wait_write`, `disk_wait_read`, `disk_wait_write`,
+				`syncq_wait_read`, `syncq_wait_write`, `asyncq_wait_read`, `asyncq_wait_write`,
+				`scrub_wait`, `trim_wait`, `rebuild_wait`,
+			},
+		},
+		IostatRequestSize: {
+			{
+				`sync_read_ind`, `sync_read_agg`, `sync_write_ind`, `sync_write_agg`,
+				`async_read_ind`, `async_read_agg`, `async_write_ind`, `async_write_agg`,
+				`scrub_ind`, `scrub_agg`, `trim_ind`, `trim_agg`,
+			},
+			{
+				`sync_read_ind`, `sync_read_agg`, `sync_write_ind`, `sync_write_agg`,
+				`async_read_ind`, `async_read_agg`, `async_write_ind`, `async_write_agg`,
+				`scrub_ind`, `scrub_agg`, `trim_ind`, `trim_agg`, `rebuild_ind`, `rebuild_agg`,
+			},
+		},
+	}
+)
+
+// IostatHistogram holds the distributions of a pool or vdev since the pool was imported
+type IostatHistogram struct {
+	// Vdev is the name of the pool or vdev
+	Vdev string
+	// Labels holds the bucket labels in ascending order: the inclusive upper bound in nanoseconds of latency buckets,
+	// or the lower bound in bytes of request size buckets. The last bucket also counts anything larger.
+	Labels []uint64
+	// Counts holds the bucket counts of each column, in the order of Labels
+	Counts map[string][]uint64
+}
+
+// IostatHandler receives the rows of `zpool iostat`, indexed by column name. The first row of each report is the
+// pool itself, followed by its vdevs. Rates are per second over the interval, wait times are in nanoseconds, and
+// columns reported as `-` (e.g. the wait time of a vdev without I/O) are omitted.
+type IostatHandler func(row map[string]string)
+
+// parseIostat parses the output of `zpool iostat -Hpvlq`, passing each row to the handler
+func parseIostat(r io.Reader, h IostatHandler) error {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.TrimSpace(line) == `` {
+			continue
+		}
+		fields := strings.Split(line, "\t")
+		var columns []string
+		switch len(fields) {
+		case len(iostatColumns):
+			columns = iostatColumns
+		case len(iostatRebuildColumns):
+			columns = iostatRebuildColumns
+		default:
+			return fmt.Errorf("%w: invalid iostat line '%s'", ErrInvalidOutput, line)
+		}
+		row := make(map[string]string, len(columns))
+		for i, v := range fields {
+			if v != `-` {
+				row[columns[i]] = v
+			}
+		}
+		// Allocation class headers (e.g. `logs`) carry no statistics.
+		if len(row) == 1 {
+			continue
+		}
+		h(row)
+	}
+
+	return scanner.Err()
+}
+
+// parseIostatHistograms parses the output of `zpool iostat -Hpv` with the `-w` or `-r` flag, in which the name of each
+// pool or vdev is followed by a line per bucket, holding the bucket label and a count per column
+func parseIostatHistograms(r io.Reader, kind IostatHistogramKind) ([]IostatHistogram, error) {
+	histograms := make([]IostatHistogram, 0)
+	var current *IostatHistogram
+	flush := func() {
+		// Allocation class headers (e.g. `logs`) carry no buckets.
+		if current != nil && len(current.Labels) > 0 {
+			histograms = append(histograms, *current)
+		}
+	}
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.TrimSpace(line) == `` {
+			continue
+		}
+		fields := strings.Split(line, "\t")
+		if len(fields) == 1 {
+			flush()
+			current = &IostatHistogram{Vdev: fields[0], Counts: make(map[string][]uint64)}
+			continue
+		}
+		var columns []string
+		for _, v := range iostatHistogramColumns[kind] {
+			if len(fields) == len(v)+1 {
+				columns = v
+			}
+		}
+		if current == nil || columns == nil {
+			return nil, fmt.Errorf("%w: invalid iostat histogram line '%s'", ErrInvalidOutput, line)
+		}
+		label, err := strconv.ParseUint(fields[0], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid iostat histogram bucket '%s'", ErrInvalidOutput, fields[0])
+		}
+		current.Labels = append(current.Labels, label)
+		for i, column := range columns {
+			count, err := strconv.ParseUint(fields[i+1], 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("%w: invalid iostat histogram count '%s'", ErrInvalidOutput, fields[i+1])
+			}
+			current.Counts[column] = append(current.Counts[column], count)
+		}
+	}
+	flush()
+
+	return histograms, scanner.Err()
+}
+
+// follow runs a long-lived command under the ExecPolicy, passing its output to the parse func until the command exits
+// or the context is cancelled. The command does not count towards the concurrency limit, as it would hold its slot
+// indefinitely.
+func (e *executor) follow(ctx context.Context, parse func(io.Reader) error, cmd string, args ...string) error {
+	c := e.command(cmd, args...)
+	out, err := c.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	stderr, err := c.StderrPipe()
+	if err != nil {
+		return err
+	}
+	if err = c.Start(); err != nil {
+		return fmt.Errorf("Failed to start command '%s': %w", c.String(), err)
+	}
+	stop := context.AfterFunc(ctx, func() {
+		_ = c.Process.Kill()
+	})
+	defer stop()
+
+	if err = parse(out); err != nil {
+		_ = c.Process.Kill()
+		_ = c.Wait()
+		return err
+	}
+	stde, _ := io.ReadAll(stderr)
+	err = c.Wait()
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	if err != nil {
+		return fmt.Errorf("Failed to execute command '%s'; output: '%s' (%w)", c.String(), strings.TrimSpace(string(stde)), err)
+	}
+
+	return fmt.Errorf("Command '%s' exited unexpectedly", c.String())
+}
+
+// iostat follows `zpool iostat` for the pool, reporting every interval, skipping the initial report of statistics
+// since the pool was imported
+func (e *executor) iostat(ctx context.Context, pool string, interval time.Duration, h IostatHandler) error {
+	seconds := strconv.FormatFloat(interval.Seconds(), 'f', -1, 64)
+	return e.follow(ctx, func(r io.Reader) error {
+		return parseIostat(r, h)
+	}, `zpool`, `iostat`, `-Hpvlqy`, pool, seconds)
+}
+
+// iostatHistograms runs `zpool iostat` once for the pool, reporting the distributions since the pool was imported
+func (e *executor) iostatHistograms(pool string, kind IostatHistogramKind) ([]IostatHistogram, error) {
+	flag, ok := iostatHistogramFlags[kind]
+	if !ok {
+		return nil, fmt.Errorf("unknown iostat histogram kind '%s'", kind)
+	}
+	var histograms []IostatHistogram
+	err := e.run(pool, func(r io.Reader) error {
+		var err error
+		histograms, err = parseIostatHistograms(r, kind)
+		return err
+	}, `zpool`, `iostat`, `-Hpv`, flag, pool)
+
+	return histograms, err
+}
@@ -0,0 +1,157 @@
+package zfs
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseIostat(t *testing.T) {
+	var rows []map[string]string
+	err := parseIostat(openFixture(t, `zpool_iostat.txt`), func(row map[string]string) {
+		rows = append(rows, row)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Two reports of the pool, a mirror, two disks and a log device, skipping the allocation class header.
+	if len(rows) != 10 {
+		t.Fatalf(`got %d rows, want 10`, len(rows))
+	}
+
+	testCases := []struct {
+		index   int
+		want    map[string]string
+		missing []string
+	}{
+		{
+			index:   0,
+			want:    map[string]string{`name`: `tank`, `read_ops`: `120`, `write_bytes`: `41943040`, `total_wait_read`: `4829104`, `asyncq_write_activ`: `10`, `rebuildq_write_pend`: `0`},
+			missing: []string{`scrub_wait`, `rebuild_wait`},
+		},
+		{
+			index:   2,
+			want:    map[string]string{`name`: `sda`, `disk_wait_write`: `822310`, `syncq_write_activ`: `0`},
+			missing: []string{`alloc`, `free`},
+		},
+		{
+			index:   4,
+			want:    map[string]string{`name`: `nvme0n1`, `write_ops`: `10`, `total_wait_write`: `182114`},
+			missing: []string{`total_wait_read`},
+		},
+		{
+			index: 5,
+			want:  map[string]string{`name`: `tank`, `read_ops`: `240`},
+		},
+	}
+	for _, tc := range testCases {
+		for k, v := range tc.want {
+			if got := rows[tc.index][k]; got != v {
+				t.Errorf(`row %d: got %s = %q, want %q`, tc.index, k, got, v)
+			}
+		}
+		for _, k := range tc.missing {
+			if _, ok := rows[tc.index][k]; ok {
+				t.Errorf(`row %d: got unexpected column %s`, tc.index, k)
+			}
+		}
+	}
+}
+
+func TestParseIostatLegacyColumns(t *testing.T) {
+	const input = "tank\t1992864825344\t2005400117248\t120\t310\t15728640\t41943040\t4829104\t1230422\t3210311\t845233\t51200\t22811\t1402101\t3011424\t-\t-\t0\t0\t0\t1\t2\t4\t12\t10\t0\t0\t0\t0\n"
+	var rows []map[string]string
+	if err := parseIostat(strings.NewReader(input), func(row map[string]string) { rows = append(rows, row) }); err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 1 || rows[0][`trimq_write_activ`] != `0` || rows[0][`asyncq_write_pend`] != `12` {
+		t.Errorf(`got %v`, rows)
+	}
+	if _, ok := rows[0][`rebuild_wait`]; ok {
+		t.Error(`got unexpected rebuild_wait column`)
+	}
+}
+
+func TestParseIostatInvalid(t *testing.T) {
+	if err := parseIostat(strings.NewReader("tank\t1\t2\n"), func(map[string]string) {}); err == nil {
+		t.Error(`expected error parsing invalid iostat line`)
+	}
+}
+
+func TestParseIostatHistograms(t *testing.T) {
+	testCases := []struct {
+		kind    IostatHistogramKind
+		fixture string
+		vdevs   []string
+		buckets int
+		index   int
+		column  string
+		label   uint64
+		count   uint64
+	}{
+		{
+			kind:    IostatLatency,
+			fixture: `zpool_iostat_latency.txt`,
+			vdevs:   []string{`tank`, `sda`, `nvme0n1`},
+			buckets: 37,
+			index:   13,
+			column:  `total_wait_read`,
+			label:   16383,
+			count:   70,
+		},
+		{
+			kind:    IostatRequestSize,
+			fixture: `zpool_iostat_request_size.txt`,
+			vdevs:   []string{`tank`, `sda`},
+			buckets: 25,
+			index:   17,
+			column:  `async_write_ind`,
+			label:   131072,
+			count:   300,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(string(tc.kind), func(t *testing.T) {
+			histograms, err := parseIostatHistograms(openFixture(t, tc.fixture), tc.kind)
+			if err != nil {
+				t.Fatal(err)
+			}
+			// Allocation class headers are skipped.
+			if len(histograms) != len(tc.vdevs) {
+				t.Fatalf(`got %d histograms, want %d`, len(histograms), len(tc.vdevs))
+			}
+			for i, vdev := range tc.vdevs {
+				if histograms[i].Vdev != vdev {
+					t.Errorf(`got vdev %q, want %q`, histograms[i].Vdev, vdev)
+				}
+				if len(histograms[i].Labels) != tc.buckets {
+					t.Errorf(`%s: got %d buckets, want %d`, vdev, len(histograms[i].Labels), tc.buckets)
+				}
+			}
+			h := histograms[0]
+			if h.Labels[tc.index] != tc.label || h.Counts[tc.column][tc.index] != tc.count {
+				t.Errorf(`got %s bucket %d = %d, want %d = %d`, tc.column, h.Labels[tc.index], h.Counts[tc.column][tc.index], tc.label, tc.count)
+			}
+		})
+	}
+}
+
+func TestParseIostatHistogramsLegacyColumns(t *testing.T) {
+	const input = "tank\n1\t0\t0\t0\t0\t0\t0\t0\t0\t0\t0\n3\t1\t2\t3\t4\t5\t6\t7\t8\t9\t10\n"
+	histograms, err := parseIostatHistograms(strings.NewReader(input), IostatLatency)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(histograms) != 1 || histograms[0].Counts[`trim_wait`][1] != 10 {
+		t.Errorf(`got %v`, histograms)
+	}
+	if _, ok := histograms[0].Counts[`rebuild_wait`]; ok {
+		t.Error(`got unexpected rebuild_wait column`)
+	}
+}
+
+func TestParseIostatHistogramsInvalid(t *testing.T) {
+	if _, err := parseIostatHistograms(strings.NewReader("1\t0\t0\n"), IostatLatency); err == nil {
+		t.Error(`expected error parsing invalid iostat histogram line`)
+	}
+}
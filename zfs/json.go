@@ -0,0 +1,398 @@
+package zfs
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+)
+
+// Backend enum of supported CLI output parsers
+type Backend string
+
+const (
+	// BackendAuto enum entry, selects the JSON backend when supported by the installed OpenZFS version
+	BackendAuto Backend = `auto`
+	// BackendText enum entry, parses tab-separated output
+	BackendText Backend = `text`
+	// BackendJSON enum entry, parses the JSON output introduced in OpenZFS 2.3
+	BackendJSON Backend = `json`
+)
+
+// jsonValue holds a property value, which may be encoded as either a JSON string or number
+type jsonValue string
+
+func (v *jsonValue) UnmarshalJSON(data []byte) error {
+	if len(data) > 0 && data[0] == '"' {
+		var s string
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+		*v = jsonValue(s)
+		return nil
+	}
+	*v = jsonValue(bytes.TrimSpace(data))
+
+	return nil
+}
+
+type jsonProperty struct {
+	Value jsonValue `json:"value"`
+}
+
+type jsonPoolGet struct {
+	Pools map[string]struct {
+		Name       string                  `json:"name"`
+		Properties map[string]jsonProperty `json:"properties"`
+	} `json:"pools"`
+}
+
+type jsonDatasetGet struct {
+	Datasets map[string]struct {
+		Name       string                  `json:"name"`
+		Pool       string                  `json:"pool"`
+		Properties map[string]jsonProperty `json:"properties"`
+	} `json:"datasets"`
+}
+
+// uint parses a numeric value, either exact as with `-p` or formatted by the CLI
+func (v jsonValue) uint() (uint64, error) {
+	if v == `` {
+		return 0, nil
+	}
+
+	return parseNicenum(string(v))
+}
+
+// jsonStatusVdev is a pool or vdev of `zpool status -j`. TRIM and initialization are only reported for leaf vdevs,
+// with `-t` and `-i`.
+type jsonStatusVdev struct {
+	Name         string          `json:"name"`
+	State        string          `json:"state"`
+	Vdevs        jsonStatusVdevs `json:"vdevs"`
+	TrimNotSup   jsonValue       `json:"trim_notsup"`
+	TrimState    string          `json:"trim_state"`
+	Trimmed      jsonValue       `json:"trimmed"`
+	ToTrim       jsonValue       `json:"to_trim"`
+	InitState    string          `json:"init_state"`
+	Initialized  jsonValue       `json:"initialized"`
+	ToInitialize jsonValue       `json:"to_initialize"`
+}
+
+// jsonStatusVdevs holds the vdevs of `zpool status -j` in the order of the configuration, as listed by the text output
+type jsonStatusVdevs []jsonStatusVdev
+
+func (v *jsonStatusVdevs) UnmarshalJSON(data []byte) error {
+	if string(bytes.TrimSpace(data)) == `null` {
+		return nil
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if t, err := dec.Token(); err != nil {
+		return err
+	} else if t != json.Delim('{') {
+		return fmt.Errorf("%w: vdevs are not an object", ErrInvalidOutput)
+	}
+	for dec.More() {
+		// The vdevs are keyed by name, which each vdev also holds.
+		if _, err := dec.Token(); err != nil {
+			return err
+		}
+		var vdev jsonStatusVdev
+		if err := dec.Decode(&vdev); err != nil {
+			return err
+		}
+		*v = append(*v, vdev)
+	}
+
+	return nil
+}
+
+type jsonStatus struct {
+	Pools map[string]struct {
+		MessageID string          `json:"msgid"`
+		Vdevs     jsonStatusVdevs `json:"vdevs"`
+		// Allocation classes, cache and spare devices are listed apart from the vdevs, in the order of the text output.
+		Dedup   jsonStatusVdevs `json:"dedup"`
+		Special jsonStatusVdevs `json:"special"`
+		Logs    jsonStatusVdevs `json:"logs"`
+		L2cache jsonStatusVdevs `json:"l2cache"`
+		Spares  jsonStatusVdevs `json:"spares"`
+		Removal *struct {
+			Vdev          string    `json:"vdev"`
+			State         string    `json:"state"`
+			Copied        jsonValue `json:"copied"`
+			ToCopy        jsonValue `json:"to_copy"`
+			MappingMemory jsonValue `json:"mapping_memory"`
+		} `json:"removal_stats"`
+		Checkpoint *struct {
+			State string    `json:"state"`
+			Space jsonValue `json:"space"`
+		} `json:"checkpoint_stats"`
+		ErrorCount *jsonValue `json:"error_count"`
+		// ErrorList is only reported with `-v`, formatted as listed by the text output
+		ErrorList []string `json:"errlist"`
+	} `json:"pools"`
+}
+
+// parsePoolNamesJSON parses the output of `zpool list -j` into the sorted pool names, as listed by the text output
+func parsePoolNamesJSON(out io.Reader) ([]string, error) {
+	result := jsonPoolGet{}
+	if err := json.NewDecoder(out).Decode(&result); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidOutput, err)
+	}
+	pools := make([]string, 0, len(result.Pools))
+	for name := range result.Pools {
+		pools = append(pools, name)
+	}
+	slices.Sort(pools)
+
+	return pools, nil
+}
+
+// parsePoolJSON parses the output of `zpool get -j` into the handler
+func parsePoolJSON(out io.Reader, pool string, h handler) error {
+	result := jsonPoolGet{}
+	if err := json.NewDecoder(out).Decode(&result); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidOutput, err)
+	}
+	for name, p := range result.Pools {
+		for k, v := range p.Properties {
+			if err := h.processLine(pool, []string{name, k, string(v.Value)}); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// parseDatasetJSON parses the output of `zfs get -j` into the handler
+func parseDatasetJSON(out io.Reader, pool string, h handler) error {
+	result := jsonDatasetGet{}
+	if err := json.NewDecoder(out).Decode(&result); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidOutput, err)
+	}
+	for name, d := range result.Datasets {
+		for k, v := range d.Properties {
+			if err := h.processLine(pool, []string{name, k, string(v.Value)}); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// parseStatusJSON parses the output of `zpool status -jpti`, optionally with `-v`, for a single pool
+func parseStatusJSON(out io.Reader, pool string) (Status, error) {
+	result := jsonStatus{}
+	if err := json.NewDecoder(out).Decode(&result); err != nil {
+		return Status{}, fmt.Errorf("%w: %w", ErrInvalidOutput, err)
+	}
+	p, ok := result.Pools[pool]
+	if !ok {
+		return Status{}, ErrInvalidOutput
+	}
+
+	status := Status{MessageCode: p.MessageID}
+	for _, vdevs := range []jsonStatusVdevs{p.Vdevs, p.Dedup, p.Special, p.Logs, p.L2cache, p.Spares} {
+		if err := appendStatusVdevsJSON(&status, vdevs); err != nil {
+			return status, err
+		}
+	}
+
+	if r := p.Removal; r != nil {
+		removal := &RemovalStatus{Vdev: r.Vdev}
+		switch r.State {
+		case `SCANNING`, `ACTIVE`:
+			removal.State = OperationActive
+		case `FINISHED`:
+			removal.State = OperationCompleted
+		case `CANCELED`:
+			removal.State = OperationCanceled
+		}
+		var err error
+		if removal.Copied, err = r.Copied.uint(); err != nil {
+			return status, err
+		}
+		if removal.MappingMemory, err = r.MappingMemory.uint(); err != nil {
+			return status, err
+		}
+		// As with the text output, the amount to evacuate is only known while the removal is active.
+		if removal.State == OperationActive {
+			if removal.Total, err = r.ToCopy.uint(); err != nil {
+				return status, err
+			}
+		}
+		if removal.State != `` {
+			status.Removal = removal
+		}
+	}
+
+	if c := p.Checkpoint; c != nil {
+		switch c.State {
+		case `DISCARDING`:
+			status.Checkpoint = &CheckpointStatus{Discarding: true}
+		case `EXISTS`:
+			space, err := c.Space.uint()
+			if err != nil {
+				return status, err
+			}
+			status.Checkpoint = &CheckpointStatus{Space: space}
+		}
+	}
+
+	switch {
+	case p.ErrorList != nil:
+		status.Errors = &ErrorsStatus{Files: make([]PermanentError, 0, len(p.ErrorList))}
+		for _, file := range p.ErrorList {
+			parseStatusErrorFile(&status, file)
+		}
+	case p.ErrorCount != nil:
+		count, err := p.ErrorCount.uint()
+		if err != nil {
+			return status, err
+		}
+		status.Errors = &ErrorsStatus{Count: count}
+	}
+
+	return status, nil
+}
+
+// appendStatusVdevsJSON appends the vdevs and their children to the status, depth first as listed by the text output
+func appendStatusVdevsJSON(status *Status, vdevs jsonStatusVdevs) error {
+	for _, v := range vdevs {
+		vdev := VdevStatus{Name: v.Name, State: PoolStatus(v.State)}
+		if len(v.Vdevs) == 0 {
+			var err error
+			if vdev.Trim, err = parseVdevOperationJSON(v.TrimState, v.Trimmed, v.ToTrim); err != nil {
+				return err
+			}
+			if n, _ := v.TrimNotSup.uint(); n != 0 {
+				vdev.Trim = VdevOperation{State: OperationUnsupported}
+			}
+			if vdev.Initialize, err = parseVdevOperationJSON(v.InitState, v.Initialized, v.ToInitialize); err != nil {
+				return err
+			}
+		}
+		status.Vdevs = append(status.Vdevs, vdev)
+		if err := appendStatusVdevsJSON(status, v.Vdevs); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// parseVdevOperationJSON parses the state of TRIM or initialization of a leaf vdev, along with the bytes processed
+func parseVdevOperationJSON(state string, done, total jsonValue) (VdevOperation, error) {
+	op := VdevOperation{}
+	switch state {
+	case ``:
+		return op, nil
+	case `NONE`, `UNTRIMMED`, `UNINITIALIZED`:
+		op.State = OperationNone
+		return op, nil
+	case `ACTIVE`:
+		op.State = OperationActive
+	case `SUSPENDED`:
+		op.State = OperationSuspended
+	case `COMPLETE`:
+		op.State = OperationCompleted
+	case `CANCELED`:
+		op.State = OperationCanceled
+	default:
+		return op, fmt.Errorf("%w: unknown vdev operation state '%s'", ErrInvalidOutput, state)
+	}
+	d, err := done.uint()
+	if err != nil {
+		return op, err
+	}
+	t, err := total.uint()
+	if err != nil {
+		return op, err
+	}
+	if t > 0 {
+		op.Progress = float64(d) / float64(t)
+	}
+
+	return op, nil
+}
+
+type jsonClientImpl struct {
+	clientImpl
+}
+
+func (z jsonClientImpl) PoolNames() ([]string, error) {
+	var pools []string
+	err := z.exec.run(``, func(out io.Reader) (err error) {
+		pools, err = parsePoolNamesJSON(out)
+		return err
+	}, `zpool`, `list`, `-jo`, `name`)
+	if err != nil {
+		return nil, err
+	}
+
+	return pools, nil
+}
+
+func (z jsonClientImpl) Pool(name string) Pool {
+	return jsonPoolImpl{poolImpl: newPoolImpl(name, z.exec)}
+}
+
+func (z jsonClientImpl) Datasets(pool string, kind DatasetKind) Datasets {
+	return jsonDatasetsImpl{datasetsImpl: newDatasetsImpl(pool, kind, z.exec)}
+}
+
+type jsonPoolImpl struct {
+	poolImpl
+}
+
+func (p jsonPoolImpl) Properties(props ...string) (PoolProperties, error) {
+	handler := newPoolPropertiesImpl()
+	err := p.exec.run(p.name, func(out io.Reader) error {
+		return parsePoolJSON(out, p.name, handler)
+	}, `zpool`, `get`, `-jp`, strings.Join(props, `,`), p.name)
+
+	return handler, err
+}
+
+// Status reads the state of the pool and its vdevs from `zpool status -j`, listing the files with permanent errors
+// with errorFiles as the text client does
+func (p jsonPoolImpl) Status(errorFiles bool) (Status, error) {
+	flags := `-jpti`
+	if errorFiles {
+		flags += `v`
+	}
+	var status Status
+	err := p.exec.run(p.name, func(r io.Reader) error {
+		var err error
+		status, err = parseStatusJSON(r, p.name)
+		return err
+	}, `zpool`, `status`, flags, p.name)
+
+	return withErrorDatasets(status, err)
+}
+
+type jsonDatasetsImpl struct {
+	datasetsImpl
+}
+
+func (d jsonDatasetsImpl) Properties(props ...string) ([]DatasetProperties, error) {
+	handler := newDatasetHandler()
+	err := d.exec.run(d.pool, func(out io.Reader) error {
+		return parseDatasetJSON(out, d.pool, handler)
+	}, `zfs`, `get`, `-jprt`, string(d.kind), strings.Join(props, `,`), d.pool)
+	if err != nil {
+		return nil, err
+	}
+
+	return handler.datasets(), nil
+}
+
+// NewJSON instantiates a ZFS Client which parses the JSON output of the CLI, requiring OpenZFS 2.3 or newer
+func NewJSON(policy ExecPolicy) Client {
+	return jsonClientImpl{clientImpl: clientImpl{exec: newExecutor(policy)}}
+}
@@ -0,0 +1,207 @@
+package zfs
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func openFixture(t *testing.T, name string) *os.File {
+	t.Helper()
+	f, err := os.Open(filepath.Join(`testdata`, name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = f.Close()
+	})
+
+	return f
+}
+
+func TestPoolNamesParsers(t *testing.T) {
+	want := []string{`backup`, `tank`}
+	testCases := []struct {
+		name    string
+		fixture string
+		parse   func(io.Reader) ([]string, error)
+	}{
+		{name: `text`, fixture: `zpool_list.txt`, parse: parsePoolNames},
+		{name: `json`, fixture: `zpool_list.json`, parse: parsePoolNamesJSON},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := tc.parse(openFixture(t, tc.fixture))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf(`got %v, want %v`, got, want)
+			}
+		})
+	}
+}
+
+func TestPoolPropertiesParsers(t *testing.T) {
+	want := map[string]string{
+		`allocated`:     `1073741824`,
+		`dedupratio`:    `1.00`,
+		`fragmentation`: `12`,
+		`health`:        `ONLINE`,
+		`size`:          `4294967296`,
+	}
+	testCases := []struct {
+		name    string
+		fixture string
+		parse   func(io.Reader, string, handler) error
+	}{
+		{name: `text`, fixture: `zpool_get.txt`, parse: parseTabular},
+		{name: `json`, fixture: `zpool_get.json`, parse: parsePoolJSON},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			h := newPoolPropertiesImpl()
+			if err := tc.parse(openFixture(t, tc.fixture), `tank`, h); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(h.Properties(), want) {
+				t.Errorf(`got %v, want %v`, h.Properties(), want)
+			}
+		})
+	}
+}
+
+func TestPoolPropertiesParsersWrongPool(t *testing.T) {
+	if err := parseTabular(openFixture(t, `zpool_get.txt`), `other`, newPoolPropertiesImpl()); err != ErrInvalidOutput {
+		t.Errorf(`got error %v, want %v`, err, ErrInvalidOutput)
+	}
+	if err := parsePoolJSON(openFixture(t, `zpool_get.json`), `other`, newPoolPropertiesImpl()); err != ErrInvalidOutput {
+		t.Errorf(`got error %v, want %v`, err, ErrInvalidOutput)
+	}
+}
+
+func TestDatasetPropertiesParsers(t *testing.T) {
+	want := map[string]map[string]string{
+		`tank`: {
+			`used`:        `1073741824`,
+			`compression`: `lz4`,
+			`quota`:       `0`,
+		},
+		`tank/home`: {
+			`used`:        `536870912`,
+			`compression`: `zstd-3`,
+			`quota`:       `1099511627776`,
+		},
+		`tank/home/user with spaces`: {
+			`used`:        `4096`,
+			`compression`: `lz4`,
+			`quota`:       `0`,
+		},
+	}
+	testCases := []struct {
+		name    string
+		fixture string
+		parse   func(io.Reader, string, handler) error
+	}{
+		{name: `text`, fixture: `zfs_get_filesystem.txt`, parse: parseTabular},
+		{name: `json`, fixture: `zfs_get_filesystem.json`, parse: parseDatasetJSON},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			h := newDatasetHandler()
+			if err := tc.parse(openFixture(t, tc.fixture), `tank`, h); err != nil {
+				t.Fatal(err)
+			}
+			got := make(map[string]map[string]string)
+			for _, d := range h.datasets() {
+				got[d.DatasetName()] = d.Properties()
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf(`got %v, want %v`, got, want)
+			}
+		})
+	}
+}
+
+func TestStatusParsers(t *testing.T) {
+	testCases := []struct {
+		name    string
+		fixture string
+	}{
+		{name: `operations`, fixture: `zpool_status`},
+		{name: `errors`, fixture: `zpool_status_errors`},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			want, err := parseStatus(openFixture(t, tc.fixture+`.txt`))
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := parseStatusJSON(openFixture(t, tc.fixture+`.json`), `tank`)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf(`got %+v, want %+v`, got, want)
+			}
+		})
+	}
+}
+
+func TestStatusParserJSONErrorCount(t *testing.T) {
+	// Without `-v`, only the number of permanent errors is known.
+	const input = `{"pools": {"tank": {"name": "tank", "state": "ONLINE", "vdevs": {"tank": {"name": "tank", "state": "ONLINE"}}, "error_count": "4"}}}`
+	status, err := parseStatusJSON(strings.NewReader(input), `tank`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.Errors == nil || status.Errors.Count != 4 || status.Errors.Files != nil {
+		t.Errorf(`got errors %+v, want 4 errors without files`, status.Errors)
+	}
+	if len(status.Vdevs) != 1 || status.Vdevs[0] != (VdevStatus{Name: `tank`, State: PoolOnline}) {
+		t.Errorf(`got vdevs %+v`, status.Vdevs)
+	}
+
+	if _, err = parseStatusJSON(strings.NewReader(input), `other`); err != ErrInvalidOutput {
+		t.Errorf(`got error %v, want %v`, err, ErrInvalidOutput)
+	}
+}
+
+func TestParseVersion(t *testing.T) {
+	testCases := []struct {
+		value   string
+		want    Version
+		atLeast bool
+	}{
+		{value: `zfs-2.3.0-1`, want: Version{Major: 2, Minor: 3, Patch: 0, Raw: `zfs-2.3.0-1`}, atLeast: true},
+		{value: `zfs-kmod-2.2.2-1ubuntu1`, want: Version{Major: 2, Minor: 2, Patch: 2, Raw: `zfs-kmod-2.2.2-1ubuntu1`}},
+		{value: `0.8.6-1`, want: Version{Major: 0, Minor: 8, Patch: 6, Raw: `0.8.6-1`}},
+		{value: `zfs-2.4.0-rc1`, want: Version{Major: 2, Minor: 4, Patch: 0, Raw: `zfs-2.4.0-rc1`}, atLeast: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.value, func(t *testing.T) {
+			got, err := ParseVersion(tc.value)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tc.want {
+				t.Errorf(`got %+v, want %+v`, got, tc.want)
+			}
+			if got.AtLeast(2, 3) != tc.atLeast {
+				t.Errorf(`got AtLeast(2, 3) = %t, want %t`, got.AtLeast(2, 3), tc.atLeast)
+			}
+		})
+	}
+
+	if _, err := ParseVersion(`unknown`); err == nil {
+		t.Error(`expected error parsing invalid version`)
+	}
+}
@@ -0,0 +1,111 @@
+package zfs
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// kstatPath is the root of the ZFS kstats exported by the SPL on Linux
+const kstatPath = `/proc/spl/kstat/zfs`
+
+// readProc opens the procfs file, and passes it to the parse func
+func readProc(path string, parse func(io.Reader) error) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return parse(f)
+}
+
+// readKstat opens the kstat relative to kstatPath, and passes it to the parse func
+func readKstat(name string, parse func(io.Reader) error) error {
+	return readProc(filepath.Join(kstatPath, name), parse)
+}
+
+// ParseNamedKstat parses a named kstat, consisting of a kstat header and `name type data` columns, into a map of
+// values indexed by name, as returned by Client.Kstat
+func ParseNamedKstat(r io.Reader) (map[string]string, error) {
+	values := make(map[string]string)
+	scanner := bufio.NewScanner(r)
+	// Skip the kstat header.
+	if !scanner.Scan() {
+		return nil, fmt.Errorf("%w: missing kstat header", ErrInvalidOutput)
+	}
+	if !scanner.Scan() || !strings.HasPrefix(scanner.Text(), `name`) {
+		return nil, fmt.Errorf("%w: missing kstat column header", ErrInvalidOutput)
+	}
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) < 3 {
+			return nil, fmt.Errorf("%w: invalid kstat line '%s'", ErrInvalidOutput, scanner.Text())
+		}
+		// Names may contain spaces (e.g. `1 ns` in `dmu_tx_assign`), the type and data are always the last columns.
+		values[strings.Join(fields[:len(fields)-2], ` `)] = fields[len(fields)-1]
+	}
+
+	return values, scanner.Err()
+}
+
+// kstat reads the named kstat relative to kstatPath (e.g. `zil` or `<pool>/dmu_tx_assign`)
+func kstat(name string) (map[string]string, error) {
+	var values map[string]string
+	err := readKstat(name, func(r io.Reader) error {
+		var err error
+		values, err = ParseNamedKstat(r)
+		return err
+	})
+
+	return values, err
+}
+
+// parseTableKstat parses a tabular kstat, consisting of a kstat header, a row of column names and rows of values, into
+// a list of rows indexed by column name
+func parseTableKstat(r io.Reader) ([]map[string]string, error) {
+	rows := make([]map[string]string, 0)
+	scanner := bufio.NewScanner(r)
+	if !scanner.Scan() {
+		return nil, fmt.Errorf("%w: missing kstat header", ErrInvalidOutput)
+	}
+	if !scanner.Scan() {
+		return nil, fmt.Errorf("%w: missing kstat column header", ErrInvalidOutput)
+	}
+	columns := strings.Fields(scanner.Text())
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) > len(columns) {
+			return nil, fmt.Errorf("%w: invalid kstat line '%s'", ErrInvalidOutput, scanner.Text())
+		}
+		// Trailing columns may be empty (e.g. the vdev path of a skipped MMP write).
+		row := make(map[string]string, len(columns))
+		for i, v := range fields {
+			row[columns[i]] = v
+		}
+		rows = append(rows, row)
+	}
+
+	return rows, scanner.Err()
+}
+
+// kstatTable reads the tabular kstat relative to kstatPath (e.g. `<pool>/txgs`)
+func kstatTable(name string) ([]map[string]string, error) {
+	var rows []map[string]string
+	err := readKstat(name, func(r io.Reader) error {
+		var err error
+		rows, err = parseTableKstat(r)
+		return err
+	})
+
+	return rows, err
+}
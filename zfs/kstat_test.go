@@ -0,0 +1,161 @@
+package zfs
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseNamedKstat(t *testing.T) {
+	values, err := ParseNamedKstat(openFixture(t, `kstat/zil`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		`zil_commit_count`:            `1843`,
+		`zil_itx_metaslab_slog_bytes`: `26214400`,
+	}
+	for k, v := range want {
+		if values[k] != v {
+			t.Errorf(`got %s = %q, want %q`, k, values[k], v)
+		}
+	}
+	if len(values) != 20 {
+		t.Errorf(`got %d values, want 20`, len(values))
+	}
+}
+
+func TestParseNamedKstatSpacedNames(t *testing.T) {
+	const input = `5 1 0x01 42 2016 4722003447 171858460046451
+name                            type data
+1 ns                            4    0
+512 ns                          4    3
+1024 ns                         4    17
+`
+	values, err := ParseNamedKstat(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if values[`512 ns`] != `3` || values[`1024 ns`] != `17` {
+		t.Errorf(`got %v`, values)
+	}
+}
+
+func TestParseNamedKstatInvalid(t *testing.T) {
+	if _, err := ParseNamedKstat(strings.NewReader("ONLINE\n")); err == nil {
+		t.Error(`expected error parsing invalid kstat`)
+	}
+}
+
+func TestParseTableKstat(t *testing.T) {
+	rows, err := parseTableKstat(openFixture(t, `kstat/tank/txgs`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 5 {
+		t.Fatalf(`got %d rows, want 5`, len(rows))
+	}
+	want := map[string]string{
+		`txg`:      `8955`,
+		`state`:    `C`,
+		`ndirty`:   `3407872`,
+		`nwritten`: `4440064`,
+		`writes`:   `133`,
+		`stime`:    `27531210`,
+	}
+	for k, v := range want {
+		if rows[1][k] != v {
+			t.Errorf(`got %s = %q, want %q`, k, rows[1][k], v)
+		}
+	}
+}
+
+func TestParsePoolState(t *testing.T) {
+	state, err := parsePoolState(openFixture(t, `kstat/tank/state`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state != PoolOnline {
+		t.Errorf(`got %q, want %q`, state, PoolOnline)
+	}
+	if _, err = parsePoolState(strings.NewReader("")); err != ErrInvalidOutput {
+		t.Errorf(`got error %v, want %v`, err, ErrInvalidOutput)
+	}
+}
+
+func TestParseNamedKstatVersions(t *testing.T) {
+	testCases := []struct {
+		version string
+		kstat   string
+		want    map[string]string
+		missing []string
+	}{
+		{
+			version: `0.8.6`,
+			kstat:   `zfetchstats`,
+			want:    map[string]string{`hits`: `10832`, `misses`: `448910`, `max_streams`: `427815`},
+			missing: []string{`future`, `io_issued`},
+		},
+		{
+			version: `2.2.6`,
+			kstat:   `zfetchstats`,
+			want:    map[string]string{`hits`: `3915284`, `future`: `192043`, `io_active`: `3`},
+		},
+		{
+			version: `2.1.15`,
+			kstat:   `dbufstats`,
+			want:    map[string]string{`cache_size_bytes`: `67108864`, `hash_elements_max`: `221877`, `cache_level_0`: `5341`},
+			missing: []string{`hash_table_count`},
+		},
+		{
+			version: `2.2.6`,
+			kstat:   `dbufstats`,
+			want:    map[string]string{`cache_total_evicts`: `5422`, `hash_table_count`: `1048576`, `metadata_cache_overflow`: `0`},
+			missing: []string{`hash_elements_max`},
+		},
+		{
+			version: `0.8.6`,
+			kstat:   `dnodestats`,
+			want:    map[string]string{`dnode_hold_alloc_hits`: `1380457`, `dnode_allocate`: `4189`},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.version+`/`+tc.kstat, func(t *testing.T) {
+			values, err := ParseNamedKstat(openFixture(t, `kstat/`+tc.version+`/`+tc.kstat))
+			if err != nil {
+				t.Fatal(err)
+			}
+			for k, v := range tc.want {
+				if values[k] != v {
+					t.Errorf(`got %s = %q, want %q`, k, values[k], v)
+				}
+			}
+			for _, k := range tc.missing {
+				if _, ok := values[k]; ok {
+					t.Errorf(`got unexpected field %s`, k)
+				}
+			}
+		})
+	}
+}
+
+func TestParseTableKstatMultihost(t *testing.T) {
+	rows, err := parseTableKstat(openFixture(t, `kstat/tank/multihost`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 5 {
+		t.Fatalf(`got %d rows, want 5`, len(rows))
+	}
+	want := map[string]string{
+		`id`:        `20470`,
+		`error`:     `2`,
+		`vdev_guid`: `0`,
+		`vdev_path`: `-`,
+	}
+	for k, v := range want {
+		if rows[2][k] != v {
+			t.Errorf(`got %s = %q, want %q`, k, rows[2][k], v)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package zfs
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// AllocationClass enum of the allocation classes of top-level vdevs
+type AllocationClass string
+
+const (
+	// AllocationNormal enum entry
+	AllocationNormal AllocationClass = `normal`
+	// AllocationSpecial enum entry
+	AllocationSpecial AllocationClass = `special`
+	// AllocationDedup enum entry
+	AllocationDedup AllocationClass = `dedup`
+	// AllocationLog enum entry
+	AllocationLog AllocationClass = `log`
+	// AllocationCache enum entry
+	AllocationCache AllocationClass = `cache`
+)
+
+var (
+	// listColumns names the columns of `zpool list -Hpv` following the vdev name, as pool properties
+	listColumns = []string{`size`, `allocated`, `free`, `checkpoint`, `expandsize`, `fragmentation`, `capacity`, `dedupratio`, `health`}
+	// listClasses maps the headers of `zpool list -v` to the allocation class of the vdevs following them
+	listClasses = map[string]AllocationClass{
+		`dedup`:   AllocationDedup,
+		`special`: AllocationSpecial,
+		`logs`:    AllocationLog,
+		`log`:     AllocationLog,
+		`cache`:   AllocationCache,
+		`spares`:  ``,
+		`spare`:   ``,
+	}
+)
+
+// VdevSpace holds the space accounting of a top-level vdev, indexed by pool property name (e.g. `allocated`)
+type VdevSpace struct {
+	Name       string
+	Class      AllocationClass
+	Properties map[string]string
+}
+
+// parseVdevSpace parses the output of `zpool list -Hpv` for a single pool. The pool is followed by its vdevs, each
+// indented with a tab, and grouped under a header per allocation class. As space is only accounted for top-level vdevs,
+// their leaves are reported without an allocated size, and are skipped along with spares.
+func parseVdevSpace(r io.Reader) ([]VdevSpace, error) {
+	vdevs := make([]VdevSpace, 0)
+	class := AllocationNormal
+	pool := true
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if pool {
+			pool = false
+			continue
+		}
+		fields := strings.Split(strings.TrimPrefix(line, "\t"), "\t")
+		if len(fields) < len(listColumns)+1 {
+			return nil, fmt.Errorf("%w: invalid list line '%s'", ErrInvalidOutput, line)
+		}
+		if c, ok := listClasses[fields[0]]; ok && fields[1] == `-` {
+			class = c
+			continue
+		}
+		if class == `` || fields[2] == `-` {
+			continue
+		}
+		vdev := VdevSpace{Name: fields[0], Class: class, Properties: make(map[string]string, len(listColumns))}
+		for i, column := range listColumns {
+			vdev.Properties[column] = fields[i+1]
+		}
+		vdevs = append(vdevs, vdev)
+	}
+
+	return vdevs, scanner.Err()
+}
+
+// VdevSpace reads the space accounting of the top-level vdevs of the pool from `zpool list -v`
+func (p poolImpl) VdevSpace() ([]VdevSpace, error) {
+	var vdevs []VdevSpace
+	err := p.exec.run(p.name, func(r io.Reader) error {
+		var err error
+		vdevs, err = parseVdevSpace(r)
+		return err
+	}, `zpool`, `list`, `-Hpv`, p.name)
+
+	return vdevs, err
+}
@@ -0,0 +1,37 @@
+package zfs
+
+import (
+	"testing"
+)
+
+func TestParseVdevSpace(t *testing.T) {
+	vdevs, err := parseVdevSpace(openFixture(t, `zpool_list_vdevs.txt`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Leaves, spares and allocation class headers are skipped.
+	want := []struct {
+		name       string
+		class      AllocationClass
+		properties map[string]string
+	}{
+		{name: `raidz1-0`, class: AllocationNormal, properties: map[string]string{`size`: `5993995911168`, `allocated`: `2934006521856`, `fragmentation`: `4`, `capacity`: `48`, `health`: `ONLINE`}},
+		{name: `mirror-1`, class: AllocationSpecial, properties: map[string]string{`free`: `2528337920`, `fragmentation`: `31`, `capacity`: `54`}},
+		{name: `nvme2n1p1`, class: AllocationLog, properties: map[string]string{`allocated`: `1572864`, `expandsize`: `1073741824`, `checkpoint`: `-`}},
+		{name: `nvme2n1p2`, class: AllocationCache, properties: map[string]string{`allocated`: `102247673856`, `capacity`: `95`}},
+	}
+	if len(vdevs) != len(want) {
+		t.Fatalf(`got %d vdevs, want %d`, len(vdevs), len(want))
+	}
+	for i, w := range want {
+		if vdevs[i].Name != w.name || vdevs[i].Class != w.class {
+			t.Errorf(`got vdev %s (%s), want %s (%s)`, vdevs[i].Name, vdevs[i].Class, w.name, w.class)
+		}
+		for k, v := range w.properties {
+			if got := vdevs[i].Properties[k]; got != v {
+				t.Errorf(`%s: got %s = %q, want %q`, w.name, k, got, v)
+			}
+		}
+	}
+}
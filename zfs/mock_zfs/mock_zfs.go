@@ -5,7 +5,9 @@
 package mock_zfs
 
 import (
+	context "context"
 	reflect "reflect"
+	time "time"
 
 	gomock "github.com/golang/mock/gomock"
 	zfs "github.com/waitingsong/zfs_exporter/v3/zfs"
@@ -34,6 +36,21 @@ func (m *MockClient) EXPECT() *MockClientMockRecorder {
 	return m.recorder
 }
 
+// CompatibilityFeatures mocks base method.
+func (m *MockClient) CompatibilityFeatures(compatibility string) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CompatibilityFeatures", compatibility)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CompatibilityFeatures indicates an expected call of CompatibilityFeatures.
+func (mr *MockClientMockRecorder) CompatibilityFeatures(compatibility interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CompatibilityFeatures", reflect.TypeOf((*MockClient)(nil).CompatibilityFeatures), compatibility)
+}
+
 // Datasets mocks base method.
 func (m *MockClient) Datasets(pool string, kind zfs.DatasetKind) zfs.Datasets {
 	m.ctrl.T.Helper()
@@ -48,6 +65,94 @@ func (mr *MockClientMockRecorder) Datasets(pool, kind interface{}) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Datasets", reflect.TypeOf((*MockClient)(nil).Datasets), pool, kind)
 }
 
+// Events mocks base method.
+func (m *MockClient) Events(ctx context.Context, h zfs.EventHandler) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Events", ctx, h)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Events indicates an expected call of Events.
+func (mr *MockClientMockRecorder) Events(ctx, h interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Events", reflect.TypeOf((*MockClient)(nil).Events), ctx, h)
+}
+
+// HostID mocks base method.
+func (m *MockClient) HostID() (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "HostID")
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// HostID indicates an expected call of HostID.
+func (mr *MockClientMockRecorder) HostID() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HostID", reflect.TypeOf((*MockClient)(nil).HostID))
+}
+
+// Iostat mocks base method.
+func (m *MockClient) Iostat(ctx context.Context, pool string, interval time.Duration, h zfs.IostatHandler) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Iostat", ctx, pool, interval, h)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Iostat indicates an expected call of Iostat.
+func (mr *MockClientMockRecorder) Iostat(ctx, pool, interval, h interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Iostat", reflect.TypeOf((*MockClient)(nil).Iostat), ctx, pool, interval, h)
+}
+
+// IostatHistograms mocks base method.
+func (m *MockClient) IostatHistograms(pool string, kind zfs.IostatHistogramKind) ([]zfs.IostatHistogram, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IostatHistograms", pool, kind)
+	ret0, _ := ret[0].([]zfs.IostatHistogram)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// IostatHistograms indicates an expected call of IostatHistograms.
+func (mr *MockClientMockRecorder) IostatHistograms(pool, kind interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IostatHistograms", reflect.TypeOf((*MockClient)(nil).IostatHistograms), pool, kind)
+}
+
+// Kstat mocks base method.
+func (m *MockClient) Kstat(name string) (map[string]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Kstat", name)
+	ret0, _ := ret[0].(map[string]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Kstat indicates an expected call of Kstat.
+func (mr *MockClientMockRecorder) Kstat(name interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Kstat", reflect.TypeOf((*MockClient)(nil).Kstat), name)
+}
+
+// KstatTable mocks base method.
+func (m *MockClient) KstatTable(name string) ([]map[string]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "KstatTable", name)
+	ret0, _ := ret[0].([]map[string]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// KstatTable indicates an expected call of KstatTable.
+func (mr *MockClientMockRecorder) KstatTable(name interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "KstatTable", reflect.TypeOf((*MockClient)(nil).KstatTable), name)
+}
+
 // Pool mocks base method.
 func (m *MockClient) Pool(name string) zfs.Pool {
 	m.ctrl.T.Helper()
@@ -77,6 +182,51 @@ func (mr *MockClientMockRecorder) PoolNames() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PoolNames", reflect.TypeOf((*MockClient)(nil).PoolNames))
 }
 
+// Slabs mocks base method.
+func (m *MockClient) Slabs() ([]map[string]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Slabs")
+	ret0, _ := ret[0].([]map[string]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Slabs indicates an expected call of Slabs.
+func (mr *MockClientMockRecorder) Slabs() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Slabs", reflect.TypeOf((*MockClient)(nil).Slabs))
+}
+
+// Taskqs mocks base method.
+func (m *MockClient) Taskqs() ([]map[string]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Taskqs")
+	ret0, _ := ret[0].([]map[string]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Taskqs indicates an expected call of Taskqs.
+func (mr *MockClientMockRecorder) Taskqs() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Taskqs", reflect.TypeOf((*MockClient)(nil).Taskqs))
+}
+
+// Tunables mocks base method.
+func (m *MockClient) Tunables(module string, patterns []string) (map[string]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Tunables", module, patterns)
+	ret0, _ := ret[0].(map[string]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Tunables indicates an expected call of Tunables.
+func (mr *MockClientMockRecorder) Tunables(module, patterns interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Tunables", reflect.TypeOf((*MockClient)(nil).Tunables), module, patterns)
+}
+
 // MockPool is a mock of Pool interface.
 type MockPool struct {
 	ctrl     *gomock.Controller
@@ -100,6 +250,36 @@ func (m *MockPool) EXPECT() *MockPoolMockRecorder {
 	return m.recorder
 }
 
+// DedupStats mocks base method.
+func (m *MockPool) DedupStats() (zfs.DedupStats, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DedupStats")
+	ret0, _ := ret[0].(zfs.DedupStats)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DedupStats indicates an expected call of DedupStats.
+func (mr *MockPoolMockRecorder) DedupStats() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DedupStats", reflect.TypeOf((*MockPool)(nil).DedupStats))
+}
+
+// History mocks base method.
+func (m *MockPool) History(cursor zfs.HistoryCursor, h zfs.HistoryHandler) (zfs.HistoryCursor, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "History", cursor, h)
+	ret0, _ := ret[0].(zfs.HistoryCursor)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// History indicates an expected call of History.
+func (mr *MockPoolMockRecorder) History(cursor, h interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "History", reflect.TypeOf((*MockPool)(nil).History), cursor, h)
+}
+
 // Name mocks base method.
 func (m *MockPool) Name() string {
 	m.ctrl.T.Helper()
@@ -133,6 +313,70 @@ func (mr *MockPoolMockRecorder) Properties(props ...interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Properties", reflect.TypeOf((*MockPool)(nil).Properties), props...)
 }
 
+// State mocks base method.
+func (m *MockPool) State() (zfs.PoolStatus, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "State")
+	ret0, _ := ret[0].(zfs.PoolStatus)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// State indicates an expected call of State.
+func (mr *MockPoolMockRecorder) State() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "State", reflect.TypeOf((*MockPool)(nil).State))
+}
+
+// Status mocks base method.
+func (m *MockPool) Status(errorFiles bool) (zfs.Status, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Status", errorFiles)
+	ret0, _ := ret[0].(zfs.Status)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Status indicates an expected call of Status.
+func (mr *MockPoolMockRecorder) Status(errorFiles interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Status", reflect.TypeOf((*MockPool)(nil).Status), errorFiles)
+}
+
+// VdevProperties mocks base method.
+func (m *MockPool) VdevProperties(props ...string) ([]zfs.VdevProperties, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{}
+	for _, a := range props {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "VdevProperties", varargs...)
+	ret0, _ := ret[0].([]zfs.VdevProperties)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// VdevProperties indicates an expected call of VdevProperties.
+func (mr *MockPoolMockRecorder) VdevProperties(props ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VdevProperties", reflect.TypeOf((*MockPool)(nil).VdevProperties), props...)
+}
+
+// VdevSpace mocks base method.
+func (m *MockPool) VdevSpace() ([]zfs.VdevSpace, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "VdevSpace")
+	ret0, _ := ret[0].([]zfs.VdevSpace)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// VdevSpace indicates an expected call of VdevSpace.
+func (mr *MockPoolMockRecorder) VdevSpace() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VdevSpace", reflect.TypeOf((*MockPool)(nil).VdevSpace))
+}
+
 // MockPoolProperties is a mock of PoolProperties interface.
 type MockPoolProperties struct {
 	ctrl     *gomock.Controller
@@ -291,6 +535,57 @@ func (mr *MockDatasetPropertiesMockRecorder) Properties() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Properties", reflect.TypeOf((*MockDatasetProperties)(nil).Properties))
 }
 
+// MockVdevProperties is a mock of VdevProperties interface.
+type MockVdevProperties struct {
+	ctrl     *gomock.Controller
+	recorder *MockVdevPropertiesMockRecorder
+}
+
+// MockVdevPropertiesMockRecorder is the mock recorder for MockVdevProperties.
+type MockVdevPropertiesMockRecorder struct {
+	mock *MockVdevProperties
+}
+
+// NewMockVdevProperties creates a new mock instance.
+func NewMockVdevProperties(ctrl *gomock.Controller) *MockVdevProperties {
+	mock := &MockVdevProperties{ctrl: ctrl}
+	mock.recorder = &MockVdevPropertiesMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockVdevProperties) EXPECT() *MockVdevPropertiesMockRecorder {
+	return m.recorder
+}
+
+// Properties mocks base method.
+func (m *MockVdevProperties) Properties() map[string]string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Properties")
+	ret0, _ := ret[0].(map[string]string)
+	return ret0
+}
+
+// Properties indicates an expected call of Properties.
+func (mr *MockVdevPropertiesMockRecorder) Properties() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Properties", reflect.TypeOf((*MockVdevProperties)(nil).Properties))
+}
+
+// VdevName mocks base method.
+func (m *MockVdevProperties) VdevName() string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "VdevName")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// VdevName indicates an expected call of VdevName.
+func (mr *MockVdevPropertiesMockRecorder) VdevName() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VdevName", reflect.TypeOf((*MockVdevProperties)(nil).VdevName))
+}
+
 // Mockhandler is a mock of handler interface.
 type Mockhandler struct {
 	ctrl     *gomock.Controller
@@ -2,9 +2,7 @@ package zfs
 
 import (
 	"bufio"
-	"fmt"
 	"io"
-	"os/exec"
 	"strings"
 )
 
@@ -30,6 +28,7 @@ const (
 
 type poolImpl struct {
 	name string
+	exec *executor
 }
 
 func (p poolImpl) Name() string {
@@ -38,12 +37,38 @@ func (p poolImpl) Name() string {
 
 func (p poolImpl) Properties(props ...string) (PoolProperties, error) {
 	handler := newPoolPropertiesImpl()
-	if err := execute(p.name, handler, `zpool`, `get`, `-Hpo`, `name,property,value`, strings.Join(props, `,`)); err != nil {
+	if err := p.exec.execute(p.name, handler, `zpool`, `get`, `-Hpo`, `name,property,value`, strings.Join(props, `,`)); err != nil {
 		return handler, err
 	}
 	return handler, nil
 }
 
+// State reads the state of the pool from its kstat. Unlike `zpool get`, this does not block on a suspended pool.
+func (p poolImpl) State() (PoolStatus, error) {
+	var state PoolStatus
+	err := readKstat(p.name+`/state`, func(r io.Reader) error {
+		var err error
+		state, err = parsePoolState(r)
+		return err
+	})
+
+	return state, err
+}
+
+// parsePoolState parses the state kstat of a pool
+func parsePoolState(r io.Reader) (PoolStatus, error) {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return ``, err
+	}
+	state := strings.TrimSpace(string(b))
+	if state == `` || strings.ContainsAny(state, " \t\n") {
+		return ``, ErrInvalidOutput
+	}
+
+	return PoolStatus(state), nil
+}
+
 type poolPropertiesImpl struct {
 	properties map[string]string
 }
@@ -62,39 +87,35 @@ func (p *poolPropertiesImpl) processLine(pool string, line []string) error {
 	return nil
 }
 
-// PoolNames returns a list of available pool names
-func poolNames() ([]string, error) {
-	pools := make([]string, 0)
-	cmd := exec.Command(`zpool`, `list`, `-Ho`, `name`)
-	out, err := cmd.StdoutPipe()
-	if err != nil {
-		return nil, err
-	}
-	stderr, err := cmd.StderrPipe()
+// poolNames returns a list of available pool names
+func (e *executor) poolNames() ([]string, error) {
+	var pools []string
+	err := e.run(``, func(out io.Reader) (err error) {
+		pools, err = parsePoolNames(out)
+		return err
+	}, `zpool`, `list`, `-Ho`, `name`)
 	if err != nil {
 		return nil, err
 	}
-	scanner := bufio.NewScanner(out)
 
-	if err = cmd.Start(); err != nil {
-		return nil, fmt.Errorf("Failed to start command '%s': %w", cmd.String(), err)
-	}
+	return pools, nil
+}
 
+// parsePoolNames parses the output of `zpool list -Ho name`
+func parsePoolNames(out io.Reader) ([]string, error) {
+	pools := make([]string, 0)
+	scanner := bufio.NewScanner(out)
 	for scanner.Scan() {
 		pools = append(pools, scanner.Text())
 	}
 
-	stde, _ := io.ReadAll(stderr)
-	if err = cmd.Wait(); err != nil {
-		return nil, fmt.Errorf("Failed to execute command '%s'; output: '%s' (%w)", cmd.String(), strings.TrimSpace(string(stde)), err)
-	}
-
-	return pools, nil
+	return pools, scanner.Err()
 }
 
-func newPoolImpl(name string) poolImpl {
+func newPoolImpl(name string, exec *executor) poolImpl {
 	return poolImpl{
 		name: name,
+		exec: exec,
 	}
 }
 
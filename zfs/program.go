@@ -0,0 +1,160 @@
+package zfs
+
+import (
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// programScript walks the datasets of a pool, returning the requested properties
+//
+//go:embed program.lua
+var programScript string
+
+// programRatioProperties are reported by channel programs as an integer percentage, rather than the `1.00` multiplier
+// reported by `zfs get -p`
+var programRatioProperties = map[string]struct{}{
+	`compressratio`:    {},
+	`refcompressratio`: {},
+}
+
+type jsonProgramResult struct {
+	Return map[string]map[string]jsonValue `json:"return"`
+}
+
+// parseProgramJSON parses the output of the dataset channel program, run with `zfs program -j`, into the handler
+func parseProgramJSON(out io.Reader, pool string, h handler) error {
+	result := jsonProgramResult{}
+	if err := json.NewDecoder(out).Decode(&result); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidOutput, err)
+	}
+	for name, props := range result.Return {
+		for k, v := range props {
+			value := string(v)
+			if _, ok := programRatioProperties[k]; ok {
+				ratio, err := strconv.ParseFloat(value, 64)
+				if err != nil {
+					return fmt.Errorf("%w: %w", ErrInvalidOutput, err)
+				}
+				value = strconv.FormatFloat(ratio/100, 'f', 2, 64)
+			}
+			if err := h.processLine(pool, []string{name, k, value}); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// programUnsupportedErrors are reported by `zfs program` when channel programs cannot run on the pool at all, such as
+// without root privileges, or when walking the pool exceeds the instruction (reported as a timeout) or memory limits.
+// These are not expected to resolve themselves, unlike other failures (e.g. a busy pool or a killed child).
+var programUnsupportedErrors = []string{
+	`Permission denied`,
+	`Invalid instruction or memory limit`,
+	`Memory limit exhausted`,
+	`Return value too large`,
+	`Timed out`,
+}
+
+// programUnsupported reports whether the channel program failed for a reason which is not expected to resolve itself
+func programUnsupported(err error) bool {
+	var cmdErr *commandError
+	if !errors.As(err, &cmdErr) {
+		return false
+	}
+	for _, msg := range programUnsupportedErrors {
+		if strings.Contains(cmdErr.stderr, msg) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// programRunner runs the dataset channel program with the provided arguments, passing its output to the parse func
+type programRunner func(pool string, parse func(io.Reader) error, args ...string) error
+
+// program runs the dataset channel program under the ExecPolicy
+func (e *executor) program(pool string, parse func(io.Reader) error, args ...string) error {
+	return e.runInput(pool, strings.NewReader(programScript), parse, `zfs`, args...)
+}
+
+// executorProvider is implemented by clients which run commands through an executor
+type executorProvider interface {
+	executor() *executor
+}
+
+func (z clientImpl) executor() *executor {
+	return z.exec
+}
+
+type programClientImpl struct {
+	Client
+	log *slog.Logger
+	run programRunner
+	// unsupported holds the pools for which channel programs are unsupported, which always use the fallback client
+	unsupported *sync.Map
+}
+
+func (z programClientImpl) Datasets(pool string, kind DatasetKind) Datasets {
+	return programDatasetsImpl{
+		datasetsImpl: newDatasetsImpl(pool, kind, nil),
+		log:          z.log,
+		run:          z.run,
+		fallback:     z.Client.Datasets(pool, kind),
+		unsupported:  z.unsupported,
+	}
+}
+
+type programDatasetsImpl struct {
+	datasetsImpl
+	log         *slog.Logger
+	run         programRunner
+	fallback    Datasets
+	unsupported *sync.Map
+}
+
+func (d programDatasetsImpl) Properties(props ...string) ([]DatasetProperties, error) {
+	if _, ok := d.unsupported.Load(d.pool); ok {
+		return d.fallback.Properties(props...)
+	}
+
+	handler := newDatasetHandler()
+	args := append([]string{`program`, `-jn`, d.pool, `-`, d.pool, string(d.kind)}, props...)
+	err := d.run(d.pool, func(out io.Reader) error {
+		return parseProgramJSON(out, d.pool, handler)
+	}, args...)
+	if err != nil {
+		if programUnsupported(err) {
+			d.log.Warn("Channel programs unsupported on pool, falling back to the configured backend", "pool", d.pool, "err", err)
+			d.unsupported.Store(d.pool, struct{}{})
+		} else {
+			d.log.Warn("Channel program failed, falling back to the configured backend for this scrape", "pool", d.pool, "err", err)
+		}
+		return d.fallback.Properties(props...)
+	}
+
+	return handler.datasets(), nil
+}
+
+// NewProgram instantiates a ZFS Client which collects dataset properties through a single read-only channel program
+// per pool, rather than `zfs get`. Pools on which channel programs are unsupported use the fallback client from then
+// on, and other failures fall back for the failed query only. All other queries use the fallback client.
+func NewProgram(fallback Client, logger *slog.Logger) Client {
+	var exec *executor
+	if p, ok := fallback.(executorProvider); ok {
+		exec = p.executor()
+	} else {
+		exec = newExecutor(ExecPolicy{})
+	}
+
+	return programClientImpl{Client: fallback, log: logger, run: exec.program, unsupported: &sync.Map{}}
+}
@@ -0,0 +1,42 @@
+-- Read-only channel program collecting properties for all datasets of a kind within a pool.
+-- Usage: zfs program -jn <pool> - <pool> <kind> <property>...
+local argv = ...
+argv = argv["argv"]
+
+local root = argv[1]
+local kind = argv[2]
+local props = {}
+for i = 3, #argv do
+	props[#props + 1] = argv[i]
+end
+
+local results = {}
+
+local function collect(ds)
+	local values = {}
+	for _, prop in ipairs(props) do
+		local ok, value = pcall(zfs.get_prop, ds, prop)
+		if ok and value ~= nil then
+			values[prop] = value
+		end
+	end
+	results[ds] = values
+end
+
+local function walk(ds)
+	if zfs.get_prop(ds, "type") == kind then
+		collect(ds)
+	end
+	if kind == "snapshot" then
+		for snap in zfs.list.snapshots(ds) do
+			collect(snap)
+		end
+	end
+	for child in zfs.list.children(ds) do
+		walk(child)
+	end
+end
+
+walk(root)
+
+return results
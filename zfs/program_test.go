@@ -0,0 +1,149 @@
+package zfs
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+type fallbackDatasets struct {
+	datasetsImpl
+	calls int
+}
+
+func (d *fallbackDatasets) Properties(props ...string) ([]DatasetProperties, error) {
+	d.calls++
+	dataset := newDatasetPropertiesImpl(d.pool)
+	dataset.properties[`used`] = `1024`
+	return []DatasetProperties{dataset}, nil
+}
+
+func TestParseProgramJSON(t *testing.T) {
+	want := map[string]map[string]string{
+		`tank`: {
+			`used`:          `1073741824`,
+			`compression`:   `lz4`,
+			`compressratio`: `1.00`,
+			`quota`:         `0`,
+		},
+		`tank/home`: {
+			`used`:          `536870912`,
+			`compression`:   `zstd-3`,
+			`compressratio`: `2.45`,
+			`quota`:         `1099511627776`,
+		},
+		`tank/home/user with spaces`: {
+			`used`:          `4096`,
+			`compression`:   `lz4`,
+			`compressratio`: `1.00`,
+			`quota`:         `0`,
+		},
+	}
+
+	h := newDatasetHandler()
+	if err := parseProgramJSON(openFixture(t, `zfs_program_filesystem.json`), `tank`, h); err != nil {
+		t.Fatal(err)
+	}
+	got := make(map[string]map[string]string)
+	for _, d := range h.datasets() {
+		got[d.DatasetName()] = d.Properties()
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf(`got %v, want %v`, got, want)
+	}
+
+	if err := parseProgramJSON(openFixture(t, `zfs_program_filesystem.json`), `other`, newDatasetHandler()); err != ErrInvalidOutput {
+		t.Errorf(`got error %v, want %v`, err, ErrInvalidOutput)
+	}
+}
+
+func TestProgramDatasetsFallback(t *testing.T) {
+	const pool = `tank`
+	testCases := []struct {
+		name            string
+		err             error
+		wantFallback    bool
+		wantUnsupported bool
+	}{
+		{
+			name: `success`,
+		},
+		{
+			name:            `permission denied`,
+			err:             &commandError{stderr: "Channel program execution failed:\nPermission denied. Channel programs must be run as root.", err: errors.New(`exit status 1`)},
+			wantFallback:    true,
+			wantUnsupported: true,
+		},
+		{
+			name:            `instruction limit`,
+			err:             &commandError{stderr: "Channel program execution failed:\nTimed out.", err: errors.New(`exit status 1`)},
+			wantFallback:    true,
+			wantUnsupported: true,
+		},
+		{
+			name:         `busy pool`,
+			err:          &commandError{stderr: `cannot open 'tank': pool I/O is currently suspended`, err: errors.New(`exit status 1`)},
+			wantFallback: true,
+		},
+		{
+			name:         `killed`,
+			err:          errors.New(`signal: killed`),
+			wantFallback: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			runs := 0
+			fallback := &fallbackDatasets{datasetsImpl: newDatasetsImpl(pool, DatasetFilesystem, nil)}
+			datasets := programDatasetsImpl{
+				datasetsImpl: newDatasetsImpl(pool, DatasetFilesystem, nil),
+				log:          slog.New(slog.NewTextHandler(io.Discard, nil)),
+				run: func(pool string, parse func(io.Reader) error, args ...string) error {
+					runs++
+					if tc.err != nil {
+						return tc.err
+					}
+					return parse(openFixture(t, `zfs_program_filesystem.json`))
+				},
+				fallback:    fallback,
+				unsupported: &sync.Map{},
+			}
+
+			for i := 1; i <= 2; i++ {
+				result, err := datasets.Properties(`used`)
+				if err != nil {
+					t.Fatal(err)
+				}
+				wantLen := 3
+				if tc.wantFallback {
+					wantLen = 1
+				}
+				if len(result) != wantLen {
+					t.Errorf(`got %d datasets, want %d`, len(result), wantLen)
+				}
+			}
+
+			// Only unsupported pools skip the channel program on later queries.
+			wantRuns, wantCalls := 2, 0
+			if tc.wantFallback {
+				wantCalls = 2
+			}
+			if tc.wantUnsupported {
+				wantRuns = 1
+			}
+			if runs != wantRuns {
+				t.Errorf(`got %d channel program runs, want %d`, runs, wantRuns)
+			}
+			if fallback.calls != wantCalls {
+				t.Errorf(`got %d fallback calls, want %d`, fallback.calls, wantCalls)
+			}
+			if _, ok := datasets.unsupported.Load(pool); ok != tc.wantUnsupported {
+				t.Errorf(`got pool unsupported %t, want %t`, ok, tc.wantUnsupported)
+			}
+		})
+	}
+}
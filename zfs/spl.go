@@ -0,0 +1,147 @@
+package zfs
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+const (
+	// splSlabPath lists the SPL kmem caches
+	splSlabPath = `/proc/spl/kmem/slab`
+	// splTaskqPath lists the SPL taskqs holding active, pending or delayed tasks
+	splTaskqPath = `/proc/spl/taskq`
+)
+
+// slabColumns names the columns of splSlabPath, whose header repeats `total alloc max` for slabs, objects and
+// emergency objects
+var slabColumns = []string{
+	`name`, `flags`, `size`, `alloc`, `slabsize`, `objsize`,
+	`slab_total`, `slab_alloc`, `slab_max`,
+	`obj_total`, `obj_alloc`, `obj_max`,
+	`emerg_dlock`, `emerg_alloc`, `emerg_max`,
+}
+
+// taskqLists names the task lists which may follow a taskq in splTaskqPath
+var taskqLists = []string{`active`, `pend`, `prio`, `delay`, `wait`}
+
+// parseSlabs parses splSlabPath into a list of caches indexed by column name. Columns reported as `-` are omitted,
+// as caches backed by the Linux slab allocator only account for their allocated objects.
+func parseSlabs(r io.Reader) ([]map[string]string, error) {
+	caches := make([]map[string]string, 0)
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || strings.HasPrefix(fields[0], `-`) || fields[0] == `name` {
+			continue
+		}
+		if len(fields) != len(slabColumns) {
+			return nil, fmt.Errorf("%w: invalid slab line '%s'", ErrInvalidOutput, scanner.Text())
+		}
+		cache := make(map[string]string, len(slabColumns))
+		for i, v := range fields {
+			if v != `-` {
+				cache[slabColumns[i]] = v
+			}
+		}
+		caches = append(caches, cache)
+	}
+
+	return caches, scanner.Err()
+}
+
+// parseTaskqs parses splTaskqPath into a list of taskqs indexed by column name. The `name` and `id` columns are split
+// from the taskq instance (e.g. `z_wr_iss/0`), and the number of entries in each task list is reported under the
+// list name. Long lists are truncated by the SPL (spl_taskq_max_show_tasks), so their counts are a lower bound.
+func parseTaskqs(r io.Reader) ([]map[string]string, error) {
+	taskqs := make([]map[string]string, 0)
+	var (
+		columns []string
+		current map[string]string
+		list    string
+		counts  map[string]int
+	)
+	flush := func() {
+		if current == nil {
+			return
+		}
+		for _, l := range taskqLists {
+			current[l] = strconv.Itoa(counts[l])
+		}
+		taskqs = append(taskqs, current)
+	}
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		if fields[0] == `taskq` {
+			columns = fields
+			continue
+		}
+		if line[0] == ' ' || line[0] == '\t' {
+			// Task list, either starting with the list name or continuing the previous list.
+			if current == nil {
+				return nil, fmt.Errorf("%w: task list without taskq '%s'", ErrInvalidOutput, line)
+			}
+			if name, ok := strings.CutSuffix(fields[0], `:`); ok {
+				list = name
+				fields = fields[1:]
+			}
+			for _, f := range fields {
+				// Tasks are printed as `func(arg)`, where symbols may carry a ` [module]` suffix, while the wait list
+				// holds the pids of waiting threads.
+				if f != `(truncated)` && (list == `wait` || strings.HasSuffix(f, `)`)) {
+					counts[list]++
+				}
+			}
+			continue
+		}
+		if columns == nil || len(fields) != len(columns) {
+			return nil, fmt.Errorf("%w: invalid taskq line '%s'", ErrInvalidOutput, line)
+		}
+		flush()
+		current = make(map[string]string, len(columns)+len(taskqLists)+1)
+		for i, v := range fields[1:] {
+			current[columns[i+1]] = v
+		}
+		current[`name`], current[`id`] = fields[0], ``
+		if i := strings.LastIndex(fields[0], `/`); i >= 0 {
+			current[`name`], current[`id`] = fields[0][:i], fields[0][i+1:]
+		}
+		list = ``
+		counts = make(map[string]int, len(taskqLists))
+	}
+	flush()
+
+	return taskqs, scanner.Err()
+}
+
+// slabs reads the SPL kmem caches
+func slabs() ([]map[string]string, error) {
+	var caches []map[string]string
+	err := readProc(splSlabPath, func(r io.Reader) error {
+		var err error
+		caches, err = parseSlabs(r)
+		return err
+	})
+
+	return caches, err
+}
+
+// taskqs reads the SPL taskqs
+func taskqs() ([]map[string]string, error) {
+	var result []map[string]string
+	err := readProc(splTaskqPath, func(r io.Reader) error {
+		var err error
+		result, err = parseTaskqs(r)
+		return err
+	})
+
+	return result, err
+}
@@ -0,0 +1,92 @@
+package zfs
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseSlabs(t *testing.T) {
+	caches, err := parseSlabs(openFixture(t, `spl/kmem/slab`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(caches) != 9 {
+		t.Fatalf(`got %d caches, want 9`, len(caches))
+	}
+	byName := make(map[string]map[string]string)
+	for _, c := range caches {
+		byName[c[`name`]] = c
+	}
+
+	want := map[string]string{
+		`size`:      `14716764160`,
+		`objsize`:   `131072`,
+		`slab_max`:  `6015`,
+		`obj_alloc`: `72800`,
+	}
+	for k, v := range want {
+		if got := byName[`zio_data_buf_131072`][k]; got != v {
+			t.Errorf(`got %s = %q, want %q`, k, got, v)
+		}
+	}
+
+	// Caches backed by the Linux slab allocator omit the columns they do not account for.
+	dnode := byName[`dnode_t`]
+	if dnode[`obj_alloc`] != `53768` {
+		t.Errorf(`got obj_alloc = %q, want "53768"`, dnode[`obj_alloc`])
+	}
+	if _, ok := dnode[`size`]; ok {
+		t.Errorf(`got unexpected size %q`, dnode[`size`])
+	}
+}
+
+func TestParseSlabsInvalid(t *testing.T) {
+	if _, err := parseSlabs(strings.NewReader("zio_cache 0x00080 1290640\n")); err == nil {
+		t.Error(`expected error parsing invalid slab line`)
+	}
+}
+
+func TestParseTaskqs(t *testing.T) {
+	taskqs, err := parseTaskqs(openFixture(t, `spl/taskq`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(taskqs) != 4 {
+		t.Fatalf(`got %d taskqs, want 4`, len(taskqs))
+	}
+
+	testCases := []struct {
+		index int
+		want  map[string]string
+	}{
+		{
+			index: 0,
+			want:  map[string]string{`name`: `spl_delay_taskq`, `id`: `0`, `act`: `0`, `nthr`: `4`, `delay`: `1`, `pend`: `0`},
+		},
+		{
+			index: 1,
+			want:  map[string]string{`name`: `z_wr_iss`, `id`: `0`, `act`: `2`, `active`: `2`, `pend`: `5`, `prio`: `0`, `maxt`: `12`},
+		},
+		{
+			index: 2,
+			want:  map[string]string{`name`: `z_wr_int`, `id`: `3`, `active`: `1`, `prio`: `1`},
+		},
+		{
+			index: 3,
+			want:  map[string]string{`name`: `dp_sync_taskq`, `wait`: `2`, `delay`: `0`},
+		},
+	}
+	for _, tc := range testCases {
+		for k, v := range tc.want {
+			if got := taskqs[tc.index][k]; got != v {
+				t.Errorf(`taskq %d: got %s = %q, want %q`, tc.index, k, got, v)
+			}
+		}
+	}
+}
+
+func TestParseTaskqsInvalid(t *testing.T) {
+	if _, err := parseTaskqs(strings.NewReader("\tpend: zio_execute(0xffff9b2c8f7e1000)\n")); err == nil {
+		t.Error(`expected error parsing task list without taskq`)
+	}
+}
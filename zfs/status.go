@@ -0,0 +1,391 @@
+package zfs
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// OperationState enum of the states of long-running pool and vdev operations, such as TRIM or device removal
+type OperationState string
+
+const (
+	// OperationNone enum entry, the operation never ran
+	OperationNone OperationState = `none`
+	// OperationActive enum entry
+	OperationActive OperationState = `active`
+	// OperationSuspended enum entry
+	OperationSuspended OperationState = `suspended`
+	// OperationCompleted enum entry
+	OperationCompleted OperationState = `completed`
+	// OperationCanceled enum entry
+	OperationCanceled OperationState = `canceled`
+	// OperationUnsupported enum entry, the vdev does not support the operation
+	OperationUnsupported OperationState = `unsupported`
+)
+
+var (
+	statusSectionRegexp = regexp.MustCompile(`^\s*([a-z]+):\s?(.*)$`)
+	// statusProgressRegexp matches the progress of TRIM or initialization of a vdev, e.g. `(12% trimmed, started at ...)`
+	statusProgressRegexp = regexp.MustCompile(`\((\d+)% (trimmed|initialized)(, [^)]*)?\)`)
+	// Removed devices may only be known by their index, e.g. `vdev 1`.
+	removalActiveRegexp    = regexp.MustCompile(`^Evacuation of (.+?) in progress`)
+	removalCompletedRegexp = regexp.MustCompile(`^Removal of (.+?) copied (\S+) in .*completed`)
+	removalCanceledRegexp  = regexp.MustCompile(`^Removal of (.+?) canceled`)
+	removalProgressRegexp  = regexp.MustCompile(`^(\S+) copied out of (\S+)`)
+	removalMappingRegexp   = regexp.MustCompile(`^(\S+) memory used for removed device mappings`)
+	checkpointRegexp       = regexp.MustCompile(`consumes (\S+)$`)
+	// messageCodeRegexp matches the code of the message explaining the status of the pool, e.g. in the URL of `see:`
+	messageCodeRegexp = regexp.MustCompile(`ZFS-\d{4}-[0-9A-Z]+`)
+	errorCountRegexp  = regexp.MustCompile(`^(\d+) data errors`)
+)
+
+// mountsPath lists the mounted file systems, used to find the datasets of files with permanent errors
+var mountsPath = `/proc/self/mounts`
+
+// Status holds the state of a pool as reported by `zpool status`
+type Status struct {
+	// Vdevs holds the pool itself, followed by its vdevs in the order of the configuration
+	Vdevs []VdevStatus
+	// Removal is the latest device removal, nil if no device was ever removed
+	Removal *RemovalStatus
+	// Checkpoint is the pool checkpoint, nil if the pool has none
+	Checkpoint *CheckpointStatus
+	// MessageCode is the code of the message explaining the status of the pool and the action to take (e.g.
+	// `ZFS-8000-8A`), empty if the pool has no issue
+	MessageCode string
+	// Errors holds the permanent data errors of the pool, nil if they could not be listed
+	Errors *ErrorsStatus
+}
+
+// ErrorsStatus holds the permanent data errors of a pool
+type ErrorsStatus struct {
+	// Count is the number of permanent errors, each file or object being counted once when listed
+	Count uint64
+	// Files lists the files and objects with permanent errors, only known when listed with `zpool status -v`
+	Files []PermanentError
+}
+
+// PermanentError identifies a file or object with permanent errors
+type PermanentError struct {
+	// Dataset is the name of the dataset holding the file, or an identifier such as `<metadata>`. It is empty if the
+	// file is reported by its path, and no mounted dataset holds the path.
+	Dataset string
+	// Path is the path of the file, relative to the dataset if the dataset is not mounted, or the object number
+	// (e.g. `<0x1b>`) if the file has no path
+	Path string
+}
+
+// VdevStatus holds the state of a pool or vdev
+type VdevStatus struct {
+	Name       string
+	State      PoolStatus
+	Trim       VdevOperation
+	Initialize VdevOperation
+}
+
+// VdevOperation holds the state of a vdev operation, such as TRIM or initialization
+type VdevOperation struct {
+	// State is empty if not reported for the vdev, e.g. for the pool itself or a mirror
+	State OperationState
+	// Progress is the completed fraction of the operation, from 0 to 1
+	Progress float64
+}
+
+// RemovalStatus holds the state of a device removal
+type RemovalStatus struct {
+	Vdev  string
+	State OperationState
+	// Copied is the amount of data in bytes evacuated from the device
+	Copied uint64
+	// Total is the amount of data in bytes to evacuate, only known while the removal is active
+	Total uint64
+	// MappingMemory is the amount of memory in bytes used by the mappings of removed devices
+	MappingMemory uint64
+}
+
+// CheckpointStatus holds the state of a pool checkpoint
+type CheckpointStatus struct {
+	// Discarding reports whether the checkpoint is being discarded
+	Discarding bool
+	// Space is the amount of space in bytes consumed by the checkpoint
+	Space uint64
+}
+
+// parseStatus parses the output of `zpool status -pti`, optionally with `-v`, for a single pool. Each section starts with its name (e.g.
+// `remove:`), and is continued by lines indented with a tab, except for the files listed by `errors:`, which are
+// indented with spaces.
+func parseStatus(r io.Reader) (Status, error) {
+	status := Status{}
+	var section string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.TrimSpace(line) == `` {
+			continue
+		}
+		text := line
+		if section == `errors` && strings.HasPrefix(line, ` `) {
+			parseStatusErrorFile(&status, strings.TrimSpace(line))
+			continue
+		}
+		if !strings.HasPrefix(line, "\t") {
+			// Lines which do not start a section continue the current one.
+			if m := statusSectionRegexp.FindStringSubmatch(line); m != nil {
+				section, text = m[1], m[2]
+			}
+		}
+		text = strings.TrimSpace(text)
+
+		var err error
+		switch section {
+		case `config`:
+			err = parseStatusVdev(&status, text)
+		case `remove`:
+			err = parseStatusRemoval(&status, text)
+		case `checkpoint`:
+			err = parseStatusCheckpoint(&status, text)
+		case `see`:
+			if code := messageCodeRegexp.FindString(text); code != `` {
+				status.MessageCode = code
+			}
+		case `errors`:
+			err = parseStatusErrors(&status, text)
+		}
+		if err != nil {
+			return status, err
+		}
+	}
+
+	return status, scanner.Err()
+}
+
+// parseStatusVdev parses a line of the pool configuration. Allocation class headers (e.g. `logs`) and the table header
+// are skipped.
+func parseStatusVdev(status *Status, text string) error {
+	fields := strings.Fields(text)
+	if len(fields) < 2 || fields[0] == `NAME` {
+		return nil
+	}
+	vdev := VdevStatus{Name: fields[0], State: PoolStatus(fields[1])}
+	if strings.Contains(text, `(untrimmed)`) {
+		vdev.Trim.State = OperationNone
+	}
+	if strings.Contains(text, `(trim unsupported)`) {
+		vdev.Trim.State = OperationUnsupported
+	}
+	if strings.Contains(text, `(uninitialized)`) {
+		vdev.Initialize.State = OperationNone
+	}
+	for _, m := range statusProgressRegexp.FindAllStringSubmatch(text, -1) {
+		pct, err := strconv.ParseFloat(m[1], 64)
+		if err != nil {
+			return err
+		}
+		op := VdevOperation{State: OperationActive, Progress: pct / 100}
+		switch {
+		case strings.Contains(m[3], `suspended`):
+			op.State = OperationSuspended
+		case strings.Contains(m[3], `completed`):
+			op.State = OperationCompleted
+		case strings.Contains(m[3], `canceled`):
+			op.State = OperationCanceled
+		}
+		if m[2] == `trimmed` {
+			vdev.Trim = op
+		} else {
+			vdev.Initialize = op
+		}
+	}
+	status.Vdevs = append(status.Vdevs, vdev)
+
+	return nil
+}
+
+func parseStatusRemoval(status *Status, text string) error {
+	if status.Removal == nil {
+		status.Removal = &RemovalStatus{}
+	}
+	removal := status.Removal
+	var err error
+	if m := removalActiveRegexp.FindStringSubmatch(text); m != nil {
+		removal.Vdev, removal.State = m[1], OperationActive
+	} else if m = removalCompletedRegexp.FindStringSubmatch(text); m != nil {
+		removal.Vdev, removal.State = m[1], OperationCompleted
+		removal.Copied, err = parseNicenum(m[2])
+	} else if m = removalCanceledRegexp.FindStringSubmatch(text); m != nil {
+		removal.Vdev, removal.State = m[1], OperationCanceled
+	} else if m = removalProgressRegexp.FindStringSubmatch(text); m != nil {
+		if removal.Copied, err = parseNicenum(m[1]); err == nil {
+			removal.Total, err = parseNicenum(m[2])
+		}
+	} else if m = removalMappingRegexp.FindStringSubmatch(text); m != nil {
+		removal.MappingMemory, err = parseNicenum(m[1])
+	}
+
+	return err
+}
+
+func parseStatusCheckpoint(status *Status, text string) error {
+	if text == `discarding` {
+		status.Checkpoint = &CheckpointStatus{Discarding: true}
+		return nil
+	}
+	m := checkpointRegexp.FindStringSubmatch(text)
+	if m == nil {
+		return fmt.Errorf("%w: invalid checkpoint status '%s'", ErrInvalidOutput, text)
+	}
+	space, err := parseNicenum(m[1])
+	if err != nil {
+		return err
+	}
+	status.Checkpoint = &CheckpointStatus{Space: space}
+
+	return nil
+}
+
+// parseStatusErrors parses the summary of the permanent errors, reported as a count unless listed
+func parseStatusErrors(status *Status, text string) error {
+	switch {
+	case text == `No known data errors`:
+		status.Errors = &ErrorsStatus{}
+	case strings.HasPrefix(text, `Permanent errors have been detected`):
+		status.Errors = &ErrorsStatus{Files: make([]PermanentError, 0)}
+	default:
+		// The list of errors may be unavailable, e.g. without the necessary privileges.
+		if m := errorCountRegexp.FindStringSubmatch(text); m != nil {
+			count, err := strconv.ParseUint(m[1], 10, 64)
+			if err != nil {
+				return err
+			}
+			status.Errors = &ErrorsStatus{Count: count}
+		}
+	}
+
+	return nil
+}
+
+// parseStatusErrorFile parses a listed file with permanent errors, either the path of a file in a mounted dataset, or
+// the dataset and the path or object number of the file (e.g. `tank/data:<0x1b>` or `<metadata>:<0x3d>`)
+func parseStatusErrorFile(status *Status, text string) {
+	if status.Errors == nil {
+		status.Errors = &ErrorsStatus{}
+	}
+	file := PermanentError{Path: text}
+	if !strings.HasPrefix(text, `/`) {
+		if dataset, path, ok := strings.Cut(text, `:`); ok {
+			file = PermanentError{Dataset: dataset, Path: path}
+		}
+	}
+	status.Errors.Files = append(status.Errors.Files, file)
+	status.Errors.Count++
+}
+
+// resolveErrorDatasets sets the dataset of the files with permanent errors reported by their path, from the mounted
+// file systems as listed by /proc/self/mounts
+func resolveErrorDatasets(errs *ErrorsStatus, r io.Reader) error {
+	mounts := make(map[string]string)
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 3 || fields[2] != `zfs` {
+			continue
+		}
+		mounts[unescapeMountField(fields[1])] = unescapeMountField(fields[0])
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	for i, file := range errs.Files {
+		if file.Dataset != `` {
+			continue
+		}
+		// The longest mountpoint holding the path is the dataset of the file.
+		var mountpoint string
+		for mp := range mounts {
+			if len(mp) > len(mountpoint) && (file.Path == mp || strings.HasPrefix(file.Path, strings.TrimSuffix(mp, `/`)+`/`)) {
+				mountpoint = mp
+			}
+		}
+		if mountpoint != `` {
+			errs.Files[i].Dataset = mounts[mountpoint]
+		}
+	}
+
+	return nil
+}
+
+// unescapeMountField decodes the octal escapes of whitespace in /proc/self/mounts (e.g. `\040` for a space)
+func unescapeMountField(field string) string {
+	if !strings.Contains(field, `\`) {
+		return field
+	}
+	var b strings.Builder
+	for i := 0; i < len(field); i++ {
+		if field[i] == '\\' && i+3 < len(field) {
+			if v, err := strconv.ParseUint(field[i+1:i+4], 8, 8); err == nil {
+				b.WriteByte(byte(v))
+				i += 3
+				continue
+			}
+		}
+		b.WriteByte(field[i])
+	}
+
+	return b.String()
+}
+
+// parseNicenum parses a number formatted by the CLI, either literal or with a binary suffix (e.g. `1.50G`), which
+// `zpool status` uses for some sizes regardless of the `-p` flag
+func parseNicenum(value string) (uint64, error) {
+	value = strings.TrimSuffix(value, `B`)
+	multiplier := 1.0
+	if value != `` {
+		if i := strings.IndexByte(`KMGTPE`, value[len(value)-1]); i >= 0 {
+			multiplier = float64(uint64(1) << (10 * (i + 1)))
+			value = value[:len(value)-1]
+		}
+	}
+	v, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%w: invalid number '%s'", ErrInvalidOutput, value)
+	}
+
+	return uint64(v * multiplier), nil
+}
+
+// Status reads the state of the pool and its vdevs from `zpool status`. The files with permanent errors are only listed
+// with errorFiles, as `zpool status -v` looks up the path of every error, otherwise only their count is known.
+func (p poolImpl) Status(errorFiles bool) (Status, error) {
+	flags := `-pti`
+	if errorFiles {
+		flags += `v`
+	}
+	var status Status
+	err := p.exec.run(p.name, func(r io.Reader) error {
+		var err error
+		status, err = parseStatus(r)
+		return err
+	}, `zpool`, `status`, flags, p.name)
+
+	return withErrorDatasets(status, err)
+}
+
+// withErrorDatasets sets the dataset of the files with permanent errors of a parsed status, from the mounted file
+// systems. Datasets of files which cannot be resolved are reported as unknown, rather than failing.
+func withErrorDatasets(status Status, err error) (Status, error) {
+	if err != nil || status.Errors == nil || len(status.Errors.Files) == 0 {
+		return status, err
+	}
+	if f, err := os.Open(mountsPath); err == nil {
+		defer f.Close()
+		_ = resolveErrorDatasets(status.Errors, f)
+	}
+
+	return status, nil
+}
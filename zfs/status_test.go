@@ -0,0 +1,139 @@
+package zfs
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseStatus(t *testing.T) {
+	status, err := parseStatus(openFixture(t, `zpool_status.txt`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []VdevStatus{
+		{Name: `tank`, State: PoolOnline},
+		{Name: `mirror-0`, State: PoolOnline},
+		{Name: `sda`, State: PoolOnline, Trim: VdevOperation{State: OperationActive, Progress: 0.12}, Initialize: VdevOperation{State: OperationSuspended, Progress: 0.5}},
+		{Name: `sdb`, State: PoolOnline, Trim: VdevOperation{State: OperationUnsupported}, Initialize: VdevOperation{State: OperationCompleted, Progress: 1}},
+		{Name: `sdd`, State: PoolOnline, Trim: VdevOperation{State: OperationNone}, Initialize: VdevOperation{State: OperationNone}},
+		{Name: `nvme0n1`, State: PoolOnline, Trim: VdevOperation{State: OperationCompleted, Progress: 1}, Initialize: VdevOperation{State: OperationNone}},
+	}
+	// The allocation class header is skipped.
+	if len(status.Vdevs) != len(want) {
+		t.Fatalf(`got %d vdevs, want %d`, len(status.Vdevs), len(want))
+	}
+	for i, v := range want {
+		if status.Vdevs[i] != v {
+			t.Errorf(`got vdev %+v, want %+v`, status.Vdevs[i], v)
+		}
+	}
+
+	wantRemoval := RemovalStatus{Vdev: `sdd`, State: OperationActive, Copied: 1 << 30, Total: 4 << 30, MappingMemory: 98816}
+	if status.Removal == nil || *status.Removal != wantRemoval {
+		t.Errorf(`got removal %+v, want %+v`, status.Removal, wantRemoval)
+	}
+	wantCheckpoint := CheckpointStatus{Space: 268435456}
+	if status.Checkpoint == nil || *status.Checkpoint != wantCheckpoint {
+		t.Errorf(`got checkpoint %+v, want %+v`, status.Checkpoint, wantCheckpoint)
+	}
+}
+
+func TestParseStatusCompleted(t *testing.T) {
+	const input = `  pool: tank
+ state: ONLINE
+remove: Removal of vdev 1 copied 2.50G in 0h1m, completed on Sun Jun 16 10:01:00 2024
+	1.50M memory used for removed device mappings
+checkpoint: discarding
+config:
+
+	NAME        STATE     READ WRITE CKSUM
+	tank        ONLINE       0     0     0
+`
+	status, err := parseStatus(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.Removal == nil || status.Removal.Vdev != `vdev 1` || status.Removal.State != OperationCompleted || status.Removal.Copied != 5<<29 || status.Removal.MappingMemory != 3<<19 {
+		t.Errorf(`got removal %+v`, status.Removal)
+	}
+	if status.Checkpoint == nil || !status.Checkpoint.Discarding {
+		t.Errorf(`got checkpoint %+v`, status.Checkpoint)
+	}
+	if len(status.Vdevs) != 1 {
+		t.Errorf(`got %d vdevs, want 1`, len(status.Vdevs))
+	}
+}
+
+func TestParseNicenum(t *testing.T) {
+	testCases := map[string]uint64{
+		`268435456`: 268435456,
+		`512B`:      512,
+		`96.5K`:     98816,
+		`4.00G`:     4 << 30,
+	}
+	for input, want := range testCases {
+		got, err := parseNicenum(input)
+		if err != nil {
+			t.Errorf(`%s: %v`, input, err)
+		}
+		if got != want {
+			t.Errorf(`%s: got %d, want %d`, input, got, want)
+		}
+	}
+	if _, err := parseNicenum(`-`); err == nil {
+		t.Error(`expected error parsing invalid number`)
+	}
+}
+
+func TestParseStatusErrors(t *testing.T) {
+	status, err := parseStatus(openFixture(t, `zpool_status_errors.txt`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.MessageCode != `ZFS-8000-8A` {
+		t.Errorf(`got message code %q, want ZFS-8000-8A`, status.MessageCode)
+	}
+	if len(status.Vdevs) != 4 {
+		t.Errorf(`got %d vdevs, want 4`, len(status.Vdevs))
+	}
+	if status.Errors == nil {
+		t.Fatal(`expected permanent errors`)
+	}
+
+	if err = resolveErrorDatasets(status.Errors, openFixture(t, `proc_mounts.txt`)); err != nil {
+		t.Fatal(err)
+	}
+	want := []PermanentError{
+		{Dataset: `tank/backup`, Path: `/tank/backup/2024-06-15.tar`},
+		{Dataset: `tank/backup/my dir`, Path: `/tank/backup/my dir/notes.txt`},
+		{Dataset: `tank/archive`, Path: `/2019/photo.jpg`},
+		{Dataset: `tank`, Path: `<0x1b>`},
+		{Dataset: `<metadata>`, Path: `<0x3d>`},
+	}
+	if status.Errors.Count != uint64(len(want)) || len(status.Errors.Files) != len(want) {
+		t.Fatalf(`got %d errors in %d files, want %d`, status.Errors.Count, len(status.Errors.Files), len(want))
+	}
+	for i, v := range want {
+		if status.Errors.Files[i] != v {
+			t.Errorf(`got file %+v, want %+v`, status.Errors.Files[i], v)
+		}
+	}
+}
+
+func TestParseStatusErrorsSummary(t *testing.T) {
+	testCases := map[string]*ErrorsStatus{
+		`errors: No known data errors`:                          {},
+		`errors: 4 data errors, use '-v' for a list`:            {Count: 4},
+		`errors: List of errors unavailable: permission denied`: nil,
+	}
+	for input, want := range testCases {
+		status, err := parseStatus(strings.NewReader("  pool: tank\n state: ONLINE\n" + input + "\n"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if (status.Errors == nil) != (want == nil) || (want != nil && status.Errors.Count != want.Count) {
+			t.Errorf(`%s: got %+v, want %+v`, input, status.Errors, want)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package zfs
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// modulePath is the root of the kernel module parameters on Linux
+const modulePath = `/sys/module`
+
+// readTunables reads the parameters of the kernel module under root matching any of the patterns, which may contain
+// '*' wildcards, indexed by name. Only the matching parameters are read, rather than every parameter of the module.
+// Parameters which cannot be read (e.g. write-only parameters) are skipped.
+func readTunables(root, module string, patterns []string) (map[string]string, error) {
+	dir := filepath.Join(root, module, `parameters`)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	values := make(map[string]string)
+	for _, e := range entries {
+		if e.IsDir() || !matchAny(patterns, e.Name()) {
+			continue
+		}
+		b, err := os.ReadFile(filepath.Join(dir, e.Name()))
+		if err != nil {
+			if errors.Is(err, fs.ErrPermission) {
+				continue
+			}
+			return nil, err
+		}
+		values[e.Name()] = strings.TrimSpace(string(b))
+	}
+
+	return values, nil
+}
+
+// matchAny reports whether the name matches any of the patterns
+func matchAny(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+// tunables reads the parameters of the kernel module (e.g. `zfs` or `spl`) matching any of the patterns
+func tunables(module string, patterns []string) (map[string]string, error) {
+	return readTunables(modulePath, module, patterns)
+}
@@ -0,0 +1,54 @@
+package zfs
+
+import (
+	"errors"
+	"io/fs"
+	"reflect"
+	"testing"
+)
+
+func TestReadTunables(t *testing.T) {
+	testCases := []struct {
+		module   string
+		patterns []string
+		want     map[string]string
+	}{
+		{
+			module:   `zfs`,
+			patterns: []string{`zfs_arc_*`, `zfs_txg_timeout`, `zfs_vdev_raidz_impl`},
+			want: map[string]string{
+				`zfs_arc_max`:         `8589934592`,
+				`zfs_arc_min`:         `0`,
+				`zfs_txg_timeout`:     `5`,
+				`zfs_vdev_raidz_impl`: `cycle [fastest] original scalar sse2 ssse3 avx2`,
+			},
+		},
+		{
+			module:   `spl`,
+			patterns: []string{`spl_kmem_cache_max_size`, `spl_hostid_path`, `icp_*`},
+			want: map[string]string{
+				`spl_kmem_cache_max_size`: `32`,
+				`spl_hostid_path`:         `/etc/hostid`,
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.module, func(t *testing.T) {
+			values, err := readTunables(`testdata/sys/module`, tc.module, tc.patterns)
+			if err != nil {
+				t.Fatal(err)
+			}
+			// Parameters matching none of the patterns are not read.
+			if !reflect.DeepEqual(values, tc.want) {
+				t.Errorf(`got %v, want %v`, values, tc.want)
+			}
+		})
+	}
+}
+
+func TestReadTunablesMissingModule(t *testing.T) {
+	if _, err := readTunables(`testdata/sys/module`, `icp`, []string{`*`}); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf(`got error %v, want %v`, err, fs.ErrNotExist)
+	}
+}
@@ -0,0 +1,67 @@
+package zfs
+
+import (
+	"io"
+	"strings"
+)
+
+type vdevPropertiesImpl struct {
+	vdevName   string
+	properties map[string]string
+}
+
+func (p *vdevPropertiesImpl) VdevName() string {
+	return p.vdevName
+}
+
+func (p *vdevPropertiesImpl) Properties() map[string]string {
+	return p.properties
+}
+
+// vdevHandler handles parsing of the data returned from the CLI into VdevProperties, in the order of the pool
+// configuration
+type vdevHandler struct {
+	order []string
+	store map[string]*vdevPropertiesImpl
+}
+
+// processLine implements the handler interface
+func (h *vdevHandler) processLine(pool string, line []string) error {
+	if len(line) != 3 {
+		return ErrInvalidOutput
+	}
+	if _, ok := h.store[line[0]]; !ok {
+		h.order = append(h.order, line[0])
+		h.store[line[0]] = &vdevPropertiesImpl{vdevName: line[0], properties: make(map[string]string)}
+	}
+	h.store[line[0]].properties[line[1]] = line[2]
+
+	return nil
+}
+
+func (h *vdevHandler) vdevs() []VdevProperties {
+	result := make([]VdevProperties, len(h.order))
+	for i, name := range h.order {
+		result[i] = h.store[name]
+	}
+
+	return result
+}
+
+func newVdevHandler() *vdevHandler {
+	return &vdevHandler{store: make(map[string]*vdevPropertiesImpl)}
+}
+
+// VdevProperties queries the properties of every vdev of the pool, including the root vdev, requiring OpenZFS 2.2 or
+// newer
+func (p poolImpl) VdevProperties(props ...string) ([]VdevProperties, error) {
+	handler := newVdevHandler()
+	err := p.exec.run(p.name, func(r io.Reader) error {
+		return parseTabular(r, p.name, handler)
+	}, `zpool`, `get`, `-Hpo`, `name,property,value`, strings.Join(props, `,`), p.name, `all-vdevs`)
+	if err != nil {
+		return nil, err
+	}
+
+	return handler.vdevs(), nil
+}
@@ -0,0 +1,27 @@
+package zfs
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestVdevPropertiesParser(t *testing.T) {
+	h := newVdevHandler()
+	if err := parseTabular(openFixture(t, `zpool_get_vdevs.txt`), `tank`, h); err != nil {
+		t.Fatal(err)
+	}
+	vdevs := h.vdevs()
+
+	// Vdevs are returned in the order of the pool configuration.
+	names := make([]string, len(vdevs))
+	for i, v := range vdevs {
+		names[i] = v.VdevName()
+	}
+	if want := []string{`root-0`, `mirror-0`, `sda`, `sdb`}; !reflect.DeepEqual(names, want) {
+		t.Fatalf(`got vdevs %v, want %v`, names, want)
+	}
+	want := map[string]string{`allocating`: `-`, `removing`: `off`, `checksum_errors`: `3`, `checksum_n`: `10`}
+	if !reflect.DeepEqual(vdevs[2].Properties(), want) {
+		t.Errorf(`got %v, want %v`, vdevs[2].Properties(), want)
+	}
+}
@@ -0,0 +1,65 @@
+package zfs
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+)
+
+var versionPattern = regexp.MustCompile(`(\d+)\.(\d+)(?:\.(\d+))?`)
+
+// Version holds an OpenZFS release version
+type Version struct {
+	Major int
+	Minor int
+	Patch int
+	// Raw holds the unparsed version string (e.g. `zfs-2.2.2-1`)
+	Raw string
+}
+
+// AtLeast reports whether the version is equal to or newer than major.minor
+func (v Version) AtLeast(major, minor int) bool {
+	return v.Major > major || (v.Major == major && v.Minor >= minor)
+}
+
+func (v Version) String() string {
+	return fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+}
+
+// ParseVersion parses an OpenZFS version string, such as `zfs-2.2.2-1`, `zfs-kmod-2.2.2-1` or `2.2.2-1`
+func ParseVersion(value string) (Version, error) {
+	m := versionPattern.FindStringSubmatch(value)
+	if m == nil {
+		return Version{}, fmt.Errorf("%w: could not parse version '%s'", ErrInvalidOutput, value)
+	}
+	v := Version{Raw: value}
+	v.Major, _ = strconv.Atoi(m[1])
+	v.Minor, _ = strconv.Atoi(m[2])
+	if m[3] != `` {
+		v.Patch, _ = strconv.Atoi(m[3])
+	}
+
+	return v, nil
+}
+
+// userlandVersion returns the version of the installed `zfs` command, as reported by the first line of `zfs version`
+func (e *executor) userlandVersion() (Version, error) {
+	var line string
+	err := e.run(``, func(out io.Reader) error {
+		scanner := bufio.NewScanner(out)
+		if scanner.Scan() {
+			line = scanner.Text()
+		}
+		// Drain remaining output, so that the command exits cleanly.
+		for scanner.Scan() {
+		}
+		return scanner.Err()
+	}, `zfs`, `version`)
+	if err != nil {
+		return Version{}, err
+	}
+
+	return ParseVersion(line)
+}
@@ -1,12 +1,13 @@
 package zfs
 
 import (
+	"context"
 	"encoding/csv"
 	"errors"
 	"fmt"
 	"io"
-	"os/exec"
 	"strings"
+	"time"
 )
 
 var (
@@ -19,12 +20,28 @@ type Client interface {
 	PoolNames() ([]string, error)
 	Pool(name string) Pool
 	Datasets(pool string, kind DatasetKind) Datasets
+	Kstat(name string) (map[string]string, error)
+	KstatTable(name string) ([]map[string]string, error)
+	Slabs() ([]map[string]string, error)
+	Taskqs() ([]map[string]string, error)
+	Tunables(module string, patterns []string) (map[string]string, error)
+	HostID() (string, error)
+	CompatibilityFeatures(compatibility string) ([]string, error)
+	Iostat(ctx context.Context, pool string, interval time.Duration, h IostatHandler) error
+	IostatHistograms(pool string, kind IostatHistogramKind) ([]IostatHistogram, error)
+	Events(ctx context.Context, h EventHandler) error
 }
 
 // Pool allows querying pool properties
 type Pool interface {
 	Name() string
 	Properties(props ...string) (PoolProperties, error)
+	State() (PoolStatus, error)
+	Status(errorFiles bool) (Status, error)
+	DedupStats() (DedupStats, error)
+	History(cursor HistoryCursor, h HistoryHandler) (HistoryCursor, error)
+	VdevSpace() ([]VdevSpace, error)
+	VdevProperties(props ...string) ([]VdevProperties, error)
 }
 
 // PoolProperties provides access to the properties for a pool
@@ -45,27 +62,90 @@ type DatasetProperties interface {
 	Properties() map[string]string
 }
 
+// VdevProperties provides access to the properties for a vdev
+type VdevProperties interface {
+	VdevName() string
+	Properties() map[string]string
+}
+
 type handler interface {
 	processLine(pool string, line []string) error
 }
 
 type clientImpl struct {
+	exec *executor
 }
 
 func (z clientImpl) PoolNames() ([]string, error) {
-	return poolNames()
+	return z.exec.poolNames()
 }
 
 func (z clientImpl) Pool(name string) Pool {
-	return newPoolImpl(name)
+	return newPoolImpl(name, z.exec)
 }
 
 func (z clientImpl) Datasets(pool string, kind DatasetKind) Datasets {
-	return newDatasetsImpl(pool, kind)
+	return newDatasetsImpl(pool, kind, z.exec)
+}
+
+func (z clientImpl) Kstat(name string) (map[string]string, error) {
+	return kstat(name)
+}
+
+func (z clientImpl) KstatTable(name string) ([]map[string]string, error) {
+	return kstatTable(name)
+}
+
+func (z clientImpl) Slabs() ([]map[string]string, error) {
+	return slabs()
+}
+
+func (z clientImpl) Taskqs() ([]map[string]string, error) {
+	return taskqs()
+}
+
+func (z clientImpl) Tunables(module string, patterns []string) (map[string]string, error) {
+	return tunables(module, patterns)
+}
+
+func (z clientImpl) HostID() (string, error) {
+	return hostID()
+}
+
+func (z clientImpl) CompatibilityFeatures(compatibility string) ([]string, error) {
+	return compatibilityFeatures(compatibility)
+}
+
+func (z clientImpl) Iostat(ctx context.Context, pool string, interval time.Duration, h IostatHandler) error {
+	return z.exec.iostat(ctx, pool, interval, h)
+}
+
+func (z clientImpl) IostatHistograms(pool string, kind IostatHistogramKind) ([]IostatHistogram, error) {
+	return z.exec.iostatHistograms(pool, kind)
+}
+
+func (z clientImpl) Events(ctx context.Context, h EventHandler) error {
+	return z.exec.events(ctx, h)
+}
+
+func (e *executor) execute(pool string, h handler, cmd string, args ...string) error {
+	return e.run(pool, func(r io.Reader) error {
+		return parseTabular(r, pool, h)
+	}, cmd, append(args, pool)...)
+}
+
+// run executes the command under the ExecPolicy, passing its output to the parse func
+func (e *executor) run(pool string, parse func(io.Reader) error, cmd string, args ...string) error {
+	return e.runInput(pool, nil, parse, cmd, args...)
 }
 
-func execute(pool string, h handler, cmd string, args ...string) error {
-	c := exec.Command(cmd, append(args, pool)...)
+// runInput executes the command like run, additionally feeding it the provided input
+func (e *executor) runInput(pool string, input io.Reader, parse func(io.Reader) error, cmd string, args ...string) error {
+	release := e.acquire(pool)
+	defer release()
+
+	c := e.command(cmd, args...)
+	c.Stdin = input
 	out, err := c.StdoutPipe()
 	if err != nil {
 		return err
@@ -76,16 +156,46 @@ func execute(pool string, h handler, cmd string, args ...string) error {
 		return err
 	}
 
+	if err = c.Start(); err != nil {
+		return fmt.Errorf("Failed to start command '%s': %w", c.String(), err)
+	}
+
+	if err = parse(out); err != nil {
+		_ = c.Process.Kill()
+		_ = c.Wait()
+		return err
+	}
+
+	stde, _ := io.ReadAll(stderr)
+	if err = c.Wait(); err != nil {
+		return &commandError{command: c.String(), stderr: strings.TrimSpace(string(stde)), err: err}
+	}
+	return nil
+}
+
+// commandError is returned when a command exits unsuccessfully, holding its error output
+type commandError struct {
+	command string
+	stderr  string
+	err     error
+}
+
+func (e *commandError) Error() string {
+	return fmt.Sprintf("Failed to execute command '%s'; output: '%s' (%s)", e.command, e.stderr, e.err)
+}
+
+func (e *commandError) Unwrap() error {
+	return e.err
+}
+
+// parseTabular parses the tab-separated `name,property,value` output of the CLI
+func parseTabular(out io.Reader, pool string, h handler) error {
 	r := csv.NewReader(out)
 	r.Comma = '\t'
 	r.LazyQuotes = true
 	r.ReuseRecord = true
 	r.FieldsPerRecord = 3
 
-	if err = c.Start(); err != nil {
-		return fmt.Errorf("Failed to start command '%s': %w", c.String(), err)
-	}
-
 	for {
 		line, err := r.Read()
 		if err == io.EOF {
@@ -99,14 +209,10 @@ func execute(pool string, h handler, cmd string, args ...string) error {
 		}
 	}
 
-	stde, _ := io.ReadAll(stderr)
-	if err = c.Wait(); err != nil {
-		return fmt.Errorf("Failed to execute command '%s'; output: '%s' (%w)", c.String(), strings.TrimSpace(string(stde)), err)
-	}
 	return nil
 }
 
-// New instantiates a ZFS Client
-func New() Client {
-	return clientImpl{}
+// New instantiates a ZFS Client, executing commands according to the provided ExecPolicy
+func New(policy ExecPolicy) Client {
+	return clientImpl{exec: newExecutor(policy)}
 }
@@ -1,9 +1,12 @@
 package main
 
 import (
+	"fmt"
+	"net"
 	"net/http"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/waitingsong/zfs_exporter/v3/collector"
 	"github.com/waitingsong/zfs_exporter/v3/zfs"
@@ -19,6 +22,11 @@ import (
 	"github.com/prometheus/exporter-toolkit/web/kingpinflag"
 )
 
+const (
+	zedSocketMode        = 0o600
+	zedReadHeaderTimeout = 10 * time.Second
+)
+
 func main() {
 	var (
 		metricsPath             = kingpin.Flag("web.telemetry-path", "Path under which to expose metrics.").Default("/metrics").String()
@@ -26,6 +34,14 @@ func main() {
 		deadline                = kingpin.Flag("deadline", "Maximum duration that a collection should run before returning cached data. Should be set to a value shorter than your scrape timeout duration. The current collection run will continue and update the cache when complete (default: 8s)").Default("8s").Duration()
 		pools                   = kingpin.Flag("pool", "Name of the pool(s) to collect, repeat for multiple pools (default: all pools).").Strings()
 		excludes                = kingpin.Flag("exclude", "Exclude datasets/snapshots/volumes that match the provided regex (e.g. '^rpool/docker/'), may be specified multiple times.").Strings()
+		execMaxConcurrency      = kingpin.Flag("exec.max-concurrency", "Maximum number of zfs/zpool commands to run concurrently, 0 for unlimited (default: 0)").Default("0").Int()
+		execSerializePools      = kingpin.Flag("exec.serialize-pools", "Run at most one zfs/zpool command per pool at a time (default: false)").Default("false").Bool()
+		execNice                = kingpin.Flag("exec.nice", "Niceness adjustment applied to zfs/zpool commands, 0 to disable (default: 0)").Default("0").Int()
+		execIOClass             = kingpin.Flag("exec.ionice-class", "I/O scheduling class applied to zfs/zpool commands, one of: [none, idle, best-effort] (default: none)").Default(string(zfs.IOClassNone)).Enum(string(zfs.IOClassNone), string(zfs.IOClassIdle), string(zfs.IOClassBestEffort))
+		execIOPriority          = kingpin.Flag("exec.ionice-priority", "I/O priority level within the best-effort class, from 0 (highest) to 7 (lowest) (default: 7)").Default("7").Int()
+		channelPrograms         = kingpin.Flag("zfs.channel-programs", "Collect dataset properties with a single read-only channel program per pool, rather than 'zfs get'. Requires root privileges, pools on which the channel program fails fall back to the configured backend (default: false)").Default("false").Bool()
+		backend                 = kingpin.Flag("zfs.backend", "Parser for zfs/zpool output, one of: [auto, text, json]. The json backend requires OpenZFS 2.3 or newer, auto selects it when supported (default: auto)").Default(string(zfs.BackendAuto)).Enum(string(zfs.BackendAuto), string(zfs.BackendText), string(zfs.BackendJSON))
+		zedListenAddress        = kingpin.Flag("zed.listen-address", "Address on which to accept the events posted by the sample zedlet: a Unix socket path (e.g. '/run/zfs_exporter/zed.sock') or a loopback host:port (e.g. '127.0.0.1:9135'), empty to disable (default: disabled)").Default("").String()
 		toolkitFlags            = kingpinflag.AddFlags(kingpin.CommandLine, ":9134")
 	)
 
@@ -39,13 +55,57 @@ func main() {
 	logger.Info("Starting zfs_exporter", "version", version.Info())
 	logger.Info("Build context", "context", version.BuildContext())
 
+	policy := zfs.ExecPolicy{
+		MaxConcurrency: *execMaxConcurrency,
+		SerializePools: *execSerializePools,
+		Nice:           *execNice,
+		IOClass:        zfs.IOClass(*execIOClass),
+		IOPriority:     *execIOPriority,
+	}
+	if err := policy.Validate(); err != nil {
+		logger.Error("Invalid execution policy", "err", err)
+		os.Exit(1)
+	}
+	env, err := zfs.Probe(policy)
+	if err != nil {
+		logger.Warn("Unable to fully probe the OpenZFS environment", "err", err)
+	}
+	for _, name := range zfs.RequiredBinaries {
+		if !env.Binaries[name] {
+			logger.Warn("Required command not found on PATH", "binary", name)
+		}
+	}
+	logger.Info("Detected OpenZFS", "kmod", env.Kmod.Raw, "userland", env.Userland.Raw)
+	if env.Mismatch() {
+		logger.Warn("OpenZFS kernel module and userland versions differ", "kmod", env.Kmod, "userland", env.Userland)
+	}
+
+	zfsBackend := zfs.Backend(*backend)
+	if zfsBackend == zfs.BackendAuto {
+		if env.Userland.Raw == `` {
+			logger.Warn("Unable to detect OpenZFS version, falling back to text backend")
+		}
+		zfsBackend = env.Backend()
+	}
+	logger.Info("Selected zfs backend", "backend", zfsBackend)
+	zfsClient := zfs.New(policy)
+	if zfsBackend == zfs.BackendJSON {
+		zfsClient = zfs.NewJSON(policy)
+	}
+	if *channelPrograms {
+		logger.Info("Enabling channel programs for dataset properties")
+		zfsClient = zfs.NewProgram(zfsClient, logger)
+	}
+
 	c, err := collector.NewZFS(collector.ZFSConfig{
 		DisableMetrics: *metricsExporterDisabled,
 		Deadline:       *deadline,
 		Pools:          *pools,
 		Excludes:       *excludes,
 		Logger:         logger,
-		ZFSClient:      zfs.New(),
+		ZFSClient:      zfsClient,
+		Environment:    &env,
+		ZED:            *zedListenAddress != "",
 	})
 	if err != nil {
 		logger.Error("Error creating an exporter", "err", err)
@@ -74,6 +134,23 @@ func main() {
 	}
 	logger.Info("Enabling collectors", "collectors", strings.Join(collectorNames, ", "))
 
+	if *zedListenAddress != "" {
+		listener, err := listenZED(*zedListenAddress)
+		if err != nil {
+			logger.Error("Error starting ZED listener", "err", err)
+			os.Exit(1)
+		}
+		logger.Info("Accepting ZED events", "address", listener.Addr())
+		mux := http.NewServeMux()
+		mux.Handle("/zed", c.ZEDHandler())
+		zedServer := &http.Server{Handler: mux, ReadHeaderTimeout: zedReadHeaderTimeout}
+		go func() {
+			err := zedServer.Serve(listener)
+			logger.Error("Error serving ZED events", "err", err)
+			os.Exit(1)
+		}()
+	}
+
 	http.Handle(*metricsPath, promhttp.Handler())
 	if *metricsPath != "/" {
 		landingConfig := web.LandingConfig{
@@ -102,3 +179,34 @@ func main() {
 		os.Exit(1)
 	}
 }
+
+// listenZED listens on a Unix socket if the address is a path, replacing any stale socket, or otherwise on a TCP
+// address which must be a loopback address, as events are accepted without authentication. The Unix socket is only
+// accessible to the owner, as ZED runs its zedlets as root.
+func listenZED(address string) (net.Listener, error) {
+	if strings.HasPrefix(address, "/") {
+		if fi, err := os.Stat(address); err == nil && fi.Mode()&os.ModeSocket != 0 {
+			if err = os.Remove(address); err != nil {
+				return nil, err
+			}
+		}
+		listener, err := net.Listen("unix", address)
+		if err != nil {
+			return nil, err
+		}
+		if err = os.Chmod(address, zedSocketMode); err != nil {
+			listener.Close()
+			return nil, err
+		}
+		return listener, nil
+	}
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return nil, err
+	}
+	if ip := net.ParseIP(host); host != "localhost" && (ip == nil || !ip.IsLoopback()) {
+		return nil, fmt.Errorf("ZED listen address '%s' is not a loopback address", address)
+	}
+
+	return net.Listen("tcp", address)
+}